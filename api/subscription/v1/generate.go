@@ -0,0 +1,5 @@
+// Package subscriptionv1 holds the protobuf messages and the gRPC service of the
+// subscription API, generated from subscription.proto.
+package subscriptionv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative subscription/v1/subscription.proto
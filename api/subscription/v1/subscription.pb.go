@@ -0,0 +1,1152 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: subscription/v1/subscription.proto
+
+package subscriptionv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MatchMode int32
+
+const (
+	MatchMode_MATCH_MODE_UNSPECIFIED MatchMode = 0
+	MatchMode_MATCH_MODE_EXACT       MatchMode = 1
+	MatchMode_MATCH_MODE_PREFIX      MatchMode = 2
+	// The default.
+	MatchMode_MATCH_MODE_CONTAINS MatchMode = 3
+	MatchMode_MATCH_MODE_FUZZY    MatchMode = 4
+)
+
+// Enum value maps for MatchMode.
+var (
+	MatchMode_name = map[int32]string{
+		0: "MATCH_MODE_UNSPECIFIED",
+		1: "MATCH_MODE_EXACT",
+		2: "MATCH_MODE_PREFIX",
+		3: "MATCH_MODE_CONTAINS",
+		4: "MATCH_MODE_FUZZY",
+	}
+	MatchMode_value = map[string]int32{
+		"MATCH_MODE_UNSPECIFIED": 0,
+		"MATCH_MODE_EXACT":       1,
+		"MATCH_MODE_PREFIX":      2,
+		"MATCH_MODE_CONTAINS":    3,
+		"MATCH_MODE_FUZZY":       4,
+	}
+)
+
+func (x MatchMode) Enum() *MatchMode {
+	p := new(MatchMode)
+	*p = x
+	return p
+}
+
+func (x MatchMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MatchMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_subscription_v1_subscription_proto_enumTypes[0].Descriptor()
+}
+
+func (MatchMode) Type() protoreflect.EnumType {
+	return &file_subscription_v1_subscription_proto_enumTypes[0]
+}
+
+func (x MatchMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MatchMode.Descriptor instead.
+func (MatchMode) EnumDescriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{0}
+}
+
+type Subscription struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ServiceName string                 `protobuf:"bytes,2,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	Price       int64                  `protobuf:"varint,3,opt,name=price,proto3" json:"price,omitempty"`
+	UserId      string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// YYYY-MM-DD.
+	StartDate string `protobuf:"bytes,5,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	// YYYY-MM-DD; unset for an open-ended subscription.
+	EndDate       *string                `protobuf:"bytes,6,opt,name=end_date,json=endDate,proto3,oneof" json:"end_date,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Version       int64                  `protobuf:"varint,9,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subscription) Reset() {
+	*x = Subscription{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscription) ProtoMessage() {}
+
+func (x *Subscription) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
+func (*Subscription) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Subscription) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Subscription) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *Subscription) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Subscription) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Subscription) GetStartDate() string {
+	if x != nil {
+		return x.StartDate
+	}
+	return ""
+}
+
+func (x *Subscription) GetEndDate() string {
+	if x != nil && x.EndDate != nil {
+		return *x.EndDate
+	}
+	return ""
+}
+
+func (x *Subscription) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// SubscriptionInput holds every writable field of a subscription.
+type SubscriptionInput struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	ServiceName string                 `protobuf:"bytes,1,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	Price       int64                  `protobuf:"varint,2,opt,name=price,proto3" json:"price,omitempty"`
+	UserId      string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// YYYY-MM-DD.
+	StartDate string `protobuf:"bytes,4,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	// YYYY-MM-DD; unset makes the subscription open-ended.
+	EndDate       *string `protobuf:"bytes,5,opt,name=end_date,json=endDate,proto3,oneof" json:"end_date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscriptionInput) Reset() {
+	*x = SubscriptionInput{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscriptionInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriptionInput) ProtoMessage() {}
+
+func (x *SubscriptionInput) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriptionInput.ProtoReflect.Descriptor instead.
+func (*SubscriptionInput) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubscriptionInput) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *SubscriptionInput) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *SubscriptionInput) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SubscriptionInput) GetStartDate() string {
+	if x != nil {
+		return x.StartDate
+	}
+	return ""
+}
+
+func (x *SubscriptionInput) GetEndDate() string {
+	if x != nil && x.EndDate != nil {
+		return *x.EndDate
+	}
+	return ""
+}
+
+type CreateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscription  *SubscriptionInput     `protobuf:"bytes,1,opt,name=subscription,proto3" json:"subscription,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRequest) Reset() {
+	*x = CreateRequest{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRequest) ProtoMessage() {}
+
+func (x *CreateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRequest.ProtoReflect.Descriptor instead.
+func (*CreateRequest) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateRequest) GetSubscription() *SubscriptionInput {
+	if x != nil {
+		return x.Subscription
+	}
+	return nil
+}
+
+type ReadRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Reads the subscription as it was at this point in time.
+	AsOf          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadRequest) Reset() {
+	*x = ReadRequest{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadRequest) ProtoMessage() {}
+
+func (x *ReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadRequest.ProtoReflect.Descriptor instead.
+func (*ReadRequest) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReadRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ReadRequest) GetAsOf() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AsOf
+	}
+	return nil
+}
+
+type UpdateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Subscription  *SubscriptionInput     `protobuf:"bytes,2,opt,name=subscription,proto3" json:"subscription,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRequest) Reset() {
+	*x = UpdateRequest{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRequest) ProtoMessage() {}
+
+func (x *UpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UpdateRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetSubscription() *SubscriptionInput {
+	if x != nil {
+		return x.Subscription
+	}
+	return nil
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeleteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// SubscriptionFilter selects subscriptions. Unset fields do not restrict the result;
+// date ranges are inclusive and dates are YYYY-MM-DD.
+type SubscriptionFilter struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	UserId       *string                `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3,oneof" json:"user_id,omitempty"`
+	ServiceNames []string               `protobuf:"bytes,2,rep,name=service_names,json=serviceNames,proto3" json:"service_names,omitempty"`
+	Match        MatchMode              `protobuf:"varint,3,opt,name=match,proto3,enum=subscription.v1.MatchMode" json:"match,omitempty"`
+	// Similarity threshold of the fuzzy match, between 0 and 1; 0.3 when unset.
+	Threshold *float64 `protobuf:"fixed64,4,opt,name=threshold,proto3,oneof" json:"threshold,omitempty"`
+	PriceMin  *int64   `protobuf:"varint,5,opt,name=price_min,json=priceMin,proto3,oneof" json:"price_min,omitempty"`
+	PriceMax  *int64   `protobuf:"varint,6,opt,name=price_max,json=priceMax,proto3,oneof" json:"price_max,omitempty"`
+	StartFrom *string  `protobuf:"bytes,7,opt,name=start_from,json=startFrom,proto3,oneof" json:"start_from,omitempty"`
+	StartTo   *string  `protobuf:"bytes,8,opt,name=start_to,json=startTo,proto3,oneof" json:"start_to,omitempty"`
+	EndFrom   *string  `protobuf:"bytes,9,opt,name=end_from,json=endFrom,proto3,oneof" json:"end_from,omitempty"`
+	EndTo     *string  `protobuf:"bytes,10,opt,name=end_to,json=endTo,proto3,oneof" json:"end_to,omitempty"`
+	// Keeps the subscriptions running on that day.
+	ActiveAt *string `protobuf:"bytes,11,opt,name=active_at,json=activeAt,proto3,oneof" json:"active_at,omitempty"`
+	// Keeps the subscriptions without an end date.
+	OpenEnded bool `protobuf:"varint,12,opt,name=open_ended,json=openEnded,proto3" json:"open_ended,omitempty"`
+	// A filter expression, as the filter parameter of the REST API.
+	Expr          string `protobuf:"bytes,13,opt,name=expr,proto3" json:"expr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscriptionFilter) Reset() {
+	*x = SubscriptionFilter{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscriptionFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriptionFilter) ProtoMessage() {}
+
+func (x *SubscriptionFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriptionFilter.ProtoReflect.Descriptor instead.
+func (*SubscriptionFilter) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SubscriptionFilter) GetUserId() string {
+	if x != nil && x.UserId != nil {
+		return *x.UserId
+	}
+	return ""
+}
+
+func (x *SubscriptionFilter) GetServiceNames() []string {
+	if x != nil {
+		return x.ServiceNames
+	}
+	return nil
+}
+
+func (x *SubscriptionFilter) GetMatch() MatchMode {
+	if x != nil {
+		return x.Match
+	}
+	return MatchMode_MATCH_MODE_UNSPECIFIED
+}
+
+func (x *SubscriptionFilter) GetThreshold() float64 {
+	if x != nil && x.Threshold != nil {
+		return *x.Threshold
+	}
+	return 0
+}
+
+func (x *SubscriptionFilter) GetPriceMin() int64 {
+	if x != nil && x.PriceMin != nil {
+		return *x.PriceMin
+	}
+	return 0
+}
+
+func (x *SubscriptionFilter) GetPriceMax() int64 {
+	if x != nil && x.PriceMax != nil {
+		return *x.PriceMax
+	}
+	return 0
+}
+
+func (x *SubscriptionFilter) GetStartFrom() string {
+	if x != nil && x.StartFrom != nil {
+		return *x.StartFrom
+	}
+	return ""
+}
+
+func (x *SubscriptionFilter) GetStartTo() string {
+	if x != nil && x.StartTo != nil {
+		return *x.StartTo
+	}
+	return ""
+}
+
+func (x *SubscriptionFilter) GetEndFrom() string {
+	if x != nil && x.EndFrom != nil {
+		return *x.EndFrom
+	}
+	return ""
+}
+
+func (x *SubscriptionFilter) GetEndTo() string {
+	if x != nil && x.EndTo != nil {
+		return *x.EndTo
+	}
+	return ""
+}
+
+func (x *SubscriptionFilter) GetActiveAt() string {
+	if x != nil && x.ActiveAt != nil {
+		return *x.ActiveAt
+	}
+	return ""
+}
+
+func (x *SubscriptionFilter) GetOpenEnded() bool {
+	if x != nil {
+		return x.OpenEnded
+	}
+	return false
+}
+
+func (x *SubscriptionFilter) GetExpr() string {
+	if x != nil {
+		return x.Expr
+	}
+	return ""
+}
+
+// Page selects a page by keyset: page_token is empty for the first page and the
+// next_page_token of the previous response afterwards.
+type Page struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 100 when unset, at most 1000.
+	PageSize  int32  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Sort fields, descending when prefixed with -, such as "-price".
+	Sort          []string `protobuf:"bytes,3,rep,name=sort,proto3" json:"sort,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Page) Reset() {
+	*x = Page{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Page) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Page) ProtoMessage() {}
+
+func (x *Page) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Page.ProtoReflect.Descriptor instead.
+func (*Page) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Page) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *Page) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *Page) GetSort() []string {
+	if x != nil {
+		return x.Sort
+	}
+	return nil
+}
+
+type ListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          *Page                  `protobuf:"bytes,1,opt,name=page,proto3" json:"page,omitempty"`
+	AsOf          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListRequest) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+func (x *ListRequest) GetAsOf() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AsOf
+	}
+	return nil
+}
+
+type FindFilteredRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *SubscriptionFilter    `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Page          *Page                  `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	AsOf          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindFilteredRequest) Reset() {
+	*x = FindFilteredRequest{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindFilteredRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindFilteredRequest) ProtoMessage() {}
+
+func (x *FindFilteredRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindFilteredRequest.ProtoReflect.Descriptor instead.
+func (*FindFilteredRequest) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FindFilteredRequest) GetFilter() *SubscriptionFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *FindFilteredRequest) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+func (x *FindFilteredRequest) GetAsOf() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AsOf
+	}
+	return nil
+}
+
+type ListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscriptions []*Subscription        `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	// Empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResponse) Reset() {
+	*x = ListResponse{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResponse) ProtoMessage() {}
+
+func (x *ListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResponse.ProtoReflect.Descriptor instead.
+func (*ListResponse) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListResponse) GetSubscriptions() []*Subscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+func (x *ListResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type CalculateCostRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// First and last month of the period, YYYY-MM.
+	StartMonth    string                 `protobuf:"bytes,1,opt,name=start_month,json=startMonth,proto3" json:"start_month,omitempty"`
+	EndMonth      string                 `protobuf:"bytes,2,opt,name=end_month,json=endMonth,proto3" json:"end_month,omitempty"`
+	Filter        *SubscriptionFilter    `protobuf:"bytes,3,opt,name=filter,proto3" json:"filter,omitempty"`
+	AsOf          *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CalculateCostRequest) Reset() {
+	*x = CalculateCostRequest{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CalculateCostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalculateCostRequest) ProtoMessage() {}
+
+func (x *CalculateCostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalculateCostRequest.ProtoReflect.Descriptor instead.
+func (*CalculateCostRequest) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CalculateCostRequest) GetStartMonth() string {
+	if x != nil {
+		return x.StartMonth
+	}
+	return ""
+}
+
+func (x *CalculateCostRequest) GetEndMonth() string {
+	if x != nil {
+		return x.EndMonth
+	}
+	return ""
+}
+
+func (x *CalculateCostRequest) GetFilter() *SubscriptionFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *CalculateCostRequest) GetAsOf() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AsOf
+	}
+	return nil
+}
+
+type CalculateCostResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int64                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CalculateCostResponse) Reset() {
+	*x = CalculateCostResponse{}
+	mi := &file_subscription_v1_subscription_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CalculateCostResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalculateCostResponse) ProtoMessage() {}
+
+func (x *CalculateCostResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_subscription_v1_subscription_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalculateCostResponse.ProtoReflect.Descriptor instead.
+func (*CalculateCostResponse) Descriptor() ([]byte, []int) {
+	return file_subscription_v1_subscription_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CalculateCostResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+var File_subscription_v1_subscription_proto protoreflect.FileDescriptor
+
+const file_subscription_v1_subscription_proto_rawDesc = "" +
+	"\n" +
+	"\"subscription/v1/subscription.proto\x12\x0fsubscription.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xcc\x02\n" +
+	"\fSubscription\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
+	"\fservice_name\x18\x02 \x01(\tR\vserviceName\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x03R\x05price\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"start_date\x18\x05 \x01(\tR\tstartDate\x12\x1e\n" +
+	"\bend_date\x18\x06 \x01(\tH\x00R\aendDate\x88\x01\x01\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x18\n" +
+	"\aversion\x18\t \x01(\x03R\aversionB\v\n" +
+	"\t_end_date\"\xb1\x01\n" +
+	"\x11SubscriptionInput\x12!\n" +
+	"\fservice_name\x18\x01 \x01(\tR\vserviceName\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\x03R\x05price\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"start_date\x18\x04 \x01(\tR\tstartDate\x12\x1e\n" +
+	"\bend_date\x18\x05 \x01(\tH\x00R\aendDate\x88\x01\x01B\v\n" +
+	"\t_end_date\"W\n" +
+	"\rCreateRequest\x12F\n" +
+	"\fsubscription\x18\x01 \x01(\v2\".subscription.v1.SubscriptionInputR\fsubscription\"N\n" +
+	"\vReadRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12/\n" +
+	"\x05as_of\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04asOf\"g\n" +
+	"\rUpdateRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12F\n" +
+	"\fsubscription\x18\x02 \x01(\v2\".subscription.v1.SubscriptionInputR\fsubscription\"\x1f\n" +
+	"\rDeleteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xbd\x04\n" +
+	"\x12SubscriptionFilter\x12\x1c\n" +
+	"\auser_id\x18\x01 \x01(\tH\x00R\x06userId\x88\x01\x01\x12#\n" +
+	"\rservice_names\x18\x02 \x03(\tR\fserviceNames\x120\n" +
+	"\x05match\x18\x03 \x01(\x0e2\x1a.subscription.v1.MatchModeR\x05match\x12!\n" +
+	"\tthreshold\x18\x04 \x01(\x01H\x01R\tthreshold\x88\x01\x01\x12 \n" +
+	"\tprice_min\x18\x05 \x01(\x03H\x02R\bpriceMin\x88\x01\x01\x12 \n" +
+	"\tprice_max\x18\x06 \x01(\x03H\x03R\bpriceMax\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"start_from\x18\a \x01(\tH\x04R\tstartFrom\x88\x01\x01\x12\x1e\n" +
+	"\bstart_to\x18\b \x01(\tH\x05R\astartTo\x88\x01\x01\x12\x1e\n" +
+	"\bend_from\x18\t \x01(\tH\x06R\aendFrom\x88\x01\x01\x12\x1a\n" +
+	"\x06end_to\x18\n" +
+	" \x01(\tH\aR\x05endTo\x88\x01\x01\x12 \n" +
+	"\tactive_at\x18\v \x01(\tH\bR\bactiveAt\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"open_ended\x18\f \x01(\bR\topenEnded\x12\x12\n" +
+	"\x04expr\x18\r \x01(\tR\x04exprB\n" +
+	"\n" +
+	"\b_user_idB\f\n" +
+	"\n" +
+	"_thresholdB\f\n" +
+	"\n" +
+	"_price_minB\f\n" +
+	"\n" +
+	"_price_maxB\r\n" +
+	"\v_start_fromB\v\n" +
+	"\t_start_toB\v\n" +
+	"\t_end_fromB\t\n" +
+	"\a_end_toB\f\n" +
+	"\n" +
+	"_active_at\"V\n" +
+	"\x04Page\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x12\n" +
+	"\x04sort\x18\x03 \x03(\tR\x04sort\"i\n" +
+	"\vListRequest\x12)\n" +
+	"\x04page\x18\x01 \x01(\v2\x15.subscription.v1.PageR\x04page\x12/\n" +
+	"\x05as_of\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04asOf\"\xae\x01\n" +
+	"\x13FindFilteredRequest\x12;\n" +
+	"\x06filter\x18\x01 \x01(\v2#.subscription.v1.SubscriptionFilterR\x06filter\x12)\n" +
+	"\x04page\x18\x02 \x01(\v2\x15.subscription.v1.PageR\x04page\x12/\n" +
+	"\x05as_of\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04asOf\"{\n" +
+	"\fListResponse\x12C\n" +
+	"\rsubscriptions\x18\x01 \x03(\v2\x1d.subscription.v1.SubscriptionR\rsubscriptions\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xc2\x01\n" +
+	"\x14CalculateCostRequest\x12\x1f\n" +
+	"\vstart_month\x18\x01 \x01(\tR\n" +
+	"startMonth\x12\x1b\n" +
+	"\tend_month\x18\x02 \x01(\tR\bendMonth\x12;\n" +
+	"\x06filter\x18\x03 \x01(\v2#.subscription.v1.SubscriptionFilterR\x06filter\x12/\n" +
+	"\x05as_of\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x04asOf\"-\n" +
+	"\x15CalculateCostResponse\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x03R\x05total*\x83\x01\n" +
+	"\tMatchMode\x12\x1a\n" +
+	"\x16MATCH_MODE_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10MATCH_MODE_EXACT\x10\x01\x12\x15\n" +
+	"\x11MATCH_MODE_PREFIX\x10\x02\x12\x17\n" +
+	"\x13MATCH_MODE_CONTAINS\x10\x03\x12\x14\n" +
+	"\x10MATCH_MODE_FUZZY\x10\x042\xa8\x04\n" +
+	"\x13SubscriptionService\x12G\n" +
+	"\x06Create\x12\x1e.subscription.v1.CreateRequest\x1a\x1d.subscription.v1.Subscription\x12C\n" +
+	"\x04Read\x12\x1c.subscription.v1.ReadRequest\x1a\x1d.subscription.v1.Subscription\x12G\n" +
+	"\x06Update\x12\x1e.subscription.v1.UpdateRequest\x1a\x1d.subscription.v1.Subscription\x12@\n" +
+	"\x06Delete\x12\x1e.subscription.v1.DeleteRequest\x1a\x16.google.protobuf.Empty\x12C\n" +
+	"\x04List\x12\x1c.subscription.v1.ListRequest\x1a\x1d.subscription.v1.ListResponse\x12S\n" +
+	"\fFindFiltered\x12$.subscription.v1.FindFilteredRequest\x1a\x1d.subscription.v1.ListResponse\x12^\n" +
+	"\rCalculateCost\x12%.subscription.v1.CalculateCostRequest\x1a&.subscription.v1.CalculateCostResponseB9Z7Subscription_Service/api/subscription/v1;subscriptionv1b\x06proto3"
+
+var (
+	file_subscription_v1_subscription_proto_rawDescOnce sync.Once
+	file_subscription_v1_subscription_proto_rawDescData []byte
+)
+
+func file_subscription_v1_subscription_proto_rawDescGZIP() []byte {
+	file_subscription_v1_subscription_proto_rawDescOnce.Do(func() {
+		file_subscription_v1_subscription_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_subscription_v1_subscription_proto_rawDesc), len(file_subscription_v1_subscription_proto_rawDesc)))
+	})
+	return file_subscription_v1_subscription_proto_rawDescData
+}
+
+var file_subscription_v1_subscription_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_subscription_v1_subscription_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_subscription_v1_subscription_proto_goTypes = []any{
+	(MatchMode)(0),                // 0: subscription.v1.MatchMode
+	(*Subscription)(nil),          // 1: subscription.v1.Subscription
+	(*SubscriptionInput)(nil),     // 2: subscription.v1.SubscriptionInput
+	(*CreateRequest)(nil),         // 3: subscription.v1.CreateRequest
+	(*ReadRequest)(nil),           // 4: subscription.v1.ReadRequest
+	(*UpdateRequest)(nil),         // 5: subscription.v1.UpdateRequest
+	(*DeleteRequest)(nil),         // 6: subscription.v1.DeleteRequest
+	(*SubscriptionFilter)(nil),    // 7: subscription.v1.SubscriptionFilter
+	(*Page)(nil),                  // 8: subscription.v1.Page
+	(*ListRequest)(nil),           // 9: subscription.v1.ListRequest
+	(*FindFilteredRequest)(nil),   // 10: subscription.v1.FindFilteredRequest
+	(*ListResponse)(nil),          // 11: subscription.v1.ListResponse
+	(*CalculateCostRequest)(nil),  // 12: subscription.v1.CalculateCostRequest
+	(*CalculateCostResponse)(nil), // 13: subscription.v1.CalculateCostResponse
+	(*timestamppb.Timestamp)(nil), // 14: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 15: google.protobuf.Empty
+}
+var file_subscription_v1_subscription_proto_depIdxs = []int32{
+	14, // 0: subscription.v1.Subscription.created_at:type_name -> google.protobuf.Timestamp
+	14, // 1: subscription.v1.Subscription.updated_at:type_name -> google.protobuf.Timestamp
+	2,  // 2: subscription.v1.CreateRequest.subscription:type_name -> subscription.v1.SubscriptionInput
+	14, // 3: subscription.v1.ReadRequest.as_of:type_name -> google.protobuf.Timestamp
+	2,  // 4: subscription.v1.UpdateRequest.subscription:type_name -> subscription.v1.SubscriptionInput
+	0,  // 5: subscription.v1.SubscriptionFilter.match:type_name -> subscription.v1.MatchMode
+	8,  // 6: subscription.v1.ListRequest.page:type_name -> subscription.v1.Page
+	14, // 7: subscription.v1.ListRequest.as_of:type_name -> google.protobuf.Timestamp
+	7,  // 8: subscription.v1.FindFilteredRequest.filter:type_name -> subscription.v1.SubscriptionFilter
+	8,  // 9: subscription.v1.FindFilteredRequest.page:type_name -> subscription.v1.Page
+	14, // 10: subscription.v1.FindFilteredRequest.as_of:type_name -> google.protobuf.Timestamp
+	1,  // 11: subscription.v1.ListResponse.subscriptions:type_name -> subscription.v1.Subscription
+	7,  // 12: subscription.v1.CalculateCostRequest.filter:type_name -> subscription.v1.SubscriptionFilter
+	14, // 13: subscription.v1.CalculateCostRequest.as_of:type_name -> google.protobuf.Timestamp
+	3,  // 14: subscription.v1.SubscriptionService.Create:input_type -> subscription.v1.CreateRequest
+	4,  // 15: subscription.v1.SubscriptionService.Read:input_type -> subscription.v1.ReadRequest
+	5,  // 16: subscription.v1.SubscriptionService.Update:input_type -> subscription.v1.UpdateRequest
+	6,  // 17: subscription.v1.SubscriptionService.Delete:input_type -> subscription.v1.DeleteRequest
+	9,  // 18: subscription.v1.SubscriptionService.List:input_type -> subscription.v1.ListRequest
+	10, // 19: subscription.v1.SubscriptionService.FindFiltered:input_type -> subscription.v1.FindFilteredRequest
+	12, // 20: subscription.v1.SubscriptionService.CalculateCost:input_type -> subscription.v1.CalculateCostRequest
+	1,  // 21: subscription.v1.SubscriptionService.Create:output_type -> subscription.v1.Subscription
+	1,  // 22: subscription.v1.SubscriptionService.Read:output_type -> subscription.v1.Subscription
+	1,  // 23: subscription.v1.SubscriptionService.Update:output_type -> subscription.v1.Subscription
+	15, // 24: subscription.v1.SubscriptionService.Delete:output_type -> google.protobuf.Empty
+	11, // 25: subscription.v1.SubscriptionService.List:output_type -> subscription.v1.ListResponse
+	11, // 26: subscription.v1.SubscriptionService.FindFiltered:output_type -> subscription.v1.ListResponse
+	13, // 27: subscription.v1.SubscriptionService.CalculateCost:output_type -> subscription.v1.CalculateCostResponse
+	21, // [21:28] is the sub-list for method output_type
+	14, // [14:21] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_subscription_v1_subscription_proto_init() }
+func file_subscription_v1_subscription_proto_init() {
+	if File_subscription_v1_subscription_proto != nil {
+		return
+	}
+	file_subscription_v1_subscription_proto_msgTypes[0].OneofWrappers = []any{}
+	file_subscription_v1_subscription_proto_msgTypes[1].OneofWrappers = []any{}
+	file_subscription_v1_subscription_proto_msgTypes[6].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_subscription_v1_subscription_proto_rawDesc), len(file_subscription_v1_subscription_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_subscription_v1_subscription_proto_goTypes,
+		DependencyIndexes: file_subscription_v1_subscription_proto_depIdxs,
+		EnumInfos:         file_subscription_v1_subscription_proto_enumTypes,
+		MessageInfos:      file_subscription_v1_subscription_proto_msgTypes,
+	}.Build()
+	File_subscription_v1_subscription_proto = out.File
+	file_subscription_v1_subscription_proto_goTypes = nil
+	file_subscription_v1_subscription_proto_depIdxs = nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"Subscription_Service/internal/app"
+)
+
+const usage = `Usage: admin <command>
+
+Commands:
+  rebuild-spend             recompute the monthly spend aggregates from the subscription table
+  purge-idempotency-keys    delete the idempotency keys whose TTL has passed`
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+
+	switch os.Args[1] {
+	case "rebuild-spend":
+		err = app.RebuildMonthlySpend(context.Background())
+	case "purge-idempotency-keys":
+		err = app.PurgeIdempotencyKeys(context.Background())
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Printf("Failed to run %s: %s", os.Args[1], err.Error())
+		os.Exit(1)
+	}
+}
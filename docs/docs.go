@@ -15,160 +15,175 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscriptions": {
-            "get": {
-                "description": "Get list of subscriptions with optional filters",
+        "/graphql": {
+            "post": {
+                "description": "Run a GraphQL query or mutation. A request that could be read is answered with status 200, the errors of the query being reported in the result",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "graphql"
                 ],
-                "summary": "List subscriptions",
+                "summary": "GraphQL",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "default": 100,
-                        "description": "Limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 0,
-                        "description": "Offset",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "User ID (UUID)",
-                        "name": "user_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Service name",
-                        "name": "service_name",
-                        "in": "query"
+                        "description": "GraphQL request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.GraphQLRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/dto.SubscriptionResponse"
-                            }
+                            "$ref": "#/definitions/dto.GraphQLResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Create a new subscription record",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/stats": {
+            "get": {
+                "description": "Report the active subscriptions, the distinct users and the revenue of every service over a period of months, with the monthly new and ended subscriptions",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "stats"
                 ],
-                "summary": "Create subscription",
+                "summary": "Subscription statistics",
                 "parameters": [
                     {
-                        "description": "Subscription data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.CreateSubscriptionRequest"
-                        }
+                        "type": "string",
+                        "pattern": "^\\d{4}-\\d{2}$",
+                        "description": "First month of the period (YYYY-MM), eleven months before end_date by default",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "pattern": "^\\d{4}-\\d{2}$",
+                        "description": "Last month of the period (YYYY-MM), the current month by default",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "maximum": 100,
+                        "minimum": 1,
+                        "description": "Number of services in the top lists",
+                        "name": "top",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.SubscriptionResponse"
+                            "$ref": "#/definitions/dto.StatsResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             }
         },
-        "/subscriptions/cost": {
+        "/stats/cohorts": {
             "get": {
-                "description": "Calculate the total cost of subscriptions in a period",
+                "description": "Report the retention of the monthly cohorts of subscriptions, the average lifetime of the subscriptions of every service and the monthly churn over a period",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "stats"
                 ],
-                "summary": "Calculate total subscription cost",
+                "summary": "Cohort report",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Start date (YYYY-MM)",
+                        "pattern": "^\\d{4}-\\d{2}$",
+                        "description": "First month of the period (YYYY-MM), eleven months before end_date by default",
                         "name": "start_date",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "End date (YYYY-MM)",
+                        "pattern": "^\\d{4}-\\d{2}$",
+                        "description": "Last month of the period (YYYY-MM), the current month by default",
                         "name": "end_date",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "User ID (UUID)",
-                        "name": "user_id",
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Service name, repeatable",
+                        "name": "service_name",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Service name",
-                        "name": "service_name",
+                        "enum": [
+                            "exact",
+                            "prefix",
+                            "contains",
+                            "fuzzy"
+                        ],
+                        "default": "contains",
+                        "description": "How service_name is matched",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "maximum": 1,
+                        "minimum": 0,
+                        "description": "Similarity threshold of the fuzzy match",
+                        "name": "threshold",
                         "in": "query"
                     }
                 ],
@@ -176,78 +191,267 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.CostResponse"
+                            "$ref": "#/definitions/dto.CohortReportResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             }
         },
-        "/subscriptions/{id}": {
+        "/subscriptions": {
             "get": {
-                "description": "Get subscription by ID",
+                "description": "Get list of subscriptions with optional filters. Passing cursor switches to keyset pagination, which responds with an envelope instead of an array",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "subscriptions"
                 ],
-                "summary": "Get subscription",
+                "summary": "List subscriptions",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "minimum": 0,
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Subscription ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Cursor of the next page, empty for the first page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Count the matching subscriptions",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Service name, repeatable",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "enum": [
+                            "exact",
+                            "prefix",
+                            "contains",
+                            "fuzzy"
+                        ],
+                        "default": "contains",
+                        "description": "How service_name is matched",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "maximum": 1,
+                        "minimum": 0,
+                        "description": "Similarity threshold of the fuzzy match",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum price",
+                        "name": "price_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum price",
+                        "name": "price_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Start date from (YYYY-MM-DD)",
+                        "name": "start_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Start date to (YYYY-MM-DD)",
+                        "name": "start_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "End date from (YYYY-MM-DD)",
+                        "name": "end_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "End date to (YYYY-MM-DD)",
+                        "name": "end_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Active at date (YYYY-MM-DD)",
+                        "name": "active_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only subscriptions without an end date",
+                        "name": "open_ended",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "maxLength": 2000,
+                        "description": "Filter expression",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sort fields, prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Point in time to report the data for (RFC 3339)",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string",
+                            "enum": [
+                                "id",
+                                "service_name",
+                                "price",
+                                "user_id",
+                                "start_date",
+                                "end_date",
+                                "created_at",
+                                "updated_at"
+                            ]
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Fields to return, id is always returned",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string",
+                            "enum": [
+                                "cost"
+                            ]
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Related data to embed: cost of the current month, or of the month of as_of",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the representation the client has",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of the representation the client has",
+                        "name": "If-Modified-Since",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "An array of dto.SubscriptionResponse, or dto.SubscriptionListResponse when cursor is passed",
                         "schema": {
-                            "$ref": "#/definitions/dto.SubscriptionResponse"
+                            "x-oneOf": [
+                                {
+                                    "type": "array",
+                                    "items": {
+                                        "$ref": "#/definitions/dto.SubscriptionResponse"
+                                    }
+                                },
+                                {
+                                    "$ref": "#/definitions/dto.SubscriptionListResponse"
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Validator of the representation"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time of the last change"
+                            }
                         }
                     },
+                    "304": {
+                        "description": "Not Modified"
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Update subscription by ID",
+            "post": {
+                "description": "Create a new subscription record",
                 "consumes": [
                     "application/json"
                 ],
@@ -257,28 +461,28 @@ const docTemplate = `{
                 "tags": [
                     "subscriptions"
                 ],
-                "summary": "Update subscription",
+                "summary": "Create subscription",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Subscription ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "maxLength": 255,
+                        "description": "Idempotency key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     },
                     {
-                        "description": "Update data",
+                        "description": "Subscription data",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateSubscriptionRequest"
+                            "$ref": "#/definitions/dto.CreateSubscriptionRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "$ref": "#/definitions/dto.SubscriptionResponse"
                         }
@@ -286,130 +490,2428 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete subscription by ID",
-                "tags": [
-                    "subscriptions"
+            }
+        },
+        "/subscriptions/batch": {
+            "post": {
+                "description": "Apply create, update and delete operations atomically or one by one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Batch subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "maxLength": 255,
+                        "description": "Idempotency key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Operations",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/cost": {
+            "get": {
+                "description": "Calculate the total cost of subscriptions in a period",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Calculate total subscription cost",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "pattern": "^\\d{4}-\\d{2}$",
+                        "description": "Start date (YYYY-MM)",
+                        "name": "start_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "pattern": "^\\d{4}-\\d{2}$",
+                        "description": "End date (YYYY-MM)",
+                        "name": "end_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Service name, repeatable",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "enum": [
+                            "exact",
+                            "prefix",
+                            "contains",
+                            "fuzzy"
+                        ],
+                        "default": "contains",
+                        "description": "How service_name is matched",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "maximum": 1,
+                        "minimum": 0,
+                        "description": "Similarity threshold of the fuzzy match",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum price",
+                        "name": "price_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum price",
+                        "name": "price_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Start date from (YYYY-MM-DD)",
+                        "name": "start_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Start date to (YYYY-MM-DD)",
+                        "name": "start_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "End date from (YYYY-MM-DD)",
+                        "name": "end_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "End date to (YYYY-MM-DD)",
+                        "name": "end_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Active at date (YYYY-MM-DD)",
+                        "name": "active_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only subscriptions without an end date",
+                        "name": "open_ended",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "maxLength": 2000,
+                        "description": "Filter expression",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sort fields, prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Point in time to report the data for (RFC 3339)",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CostResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/cost/export": {
+            "get": {
+                "description": "Stream the cost of every subscription billed in the period, the breakdown of the total cost, as CSV or XLSX",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Export subscription costs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "pattern": "^\\d{4}-\\d{2}$",
+                        "description": "Start date (YYYY-MM)",
+                        "name": "start_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "pattern": "^\\d{4}-\\d{2}$",
+                        "description": "End date (YYYY-MM)",
+                        "name": "end_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Service name, repeatable",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "enum": [
+                            "exact",
+                            "prefix",
+                            "contains",
+                            "fuzzy"
+                        ],
+                        "default": "contains",
+                        "description": "How service_name is matched",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "maximum": 1,
+                        "minimum": 0,
+                        "description": "Similarity threshold of the fuzzy match",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum price",
+                        "name": "price_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum price",
+                        "name": "price_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Start date from (YYYY-MM-DD)",
+                        "name": "start_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Start date to (YYYY-MM-DD)",
+                        "name": "start_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "End date from (YYYY-MM-DD)",
+                        "name": "end_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "End date to (YYYY-MM-DD)",
+                        "name": "end_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Active at date (YYYY-MM-DD)",
+                        "name": "active_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only subscriptions without an end date",
+                        "name": "open_ended",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "maxLength": 2000,
+                        "description": "Filter expression",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sort fields, prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Point in time to report the data for (RFC 3339)",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "default": "csv",
+                        "description": "File format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string",
+                            "enum": [
+                                "id",
+                                "service_name",
+                                "price",
+                                "user_id",
+                                "start_date",
+                                "end_date",
+                                "months",
+                                "cost"
+                            ]
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Columns in the order to write, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "pattern": "^(YYYY|MM|DD|[-./])+$",
+                        "description": "Date layout built from YYYY, MM, DD and the separators - . /, export.date_format of the configuration by default",
+                        "name": "date_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The file, sent as it is read from the database",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "Attachment with the file name"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/export": {
+            "get": {
+                "description": "Stream the subscriptions matching the list filters as CSV or XLSX, without pagination",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Export subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Service name, repeatable",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "enum": [
+                            "exact",
+                            "prefix",
+                            "contains",
+                            "fuzzy"
+                        ],
+                        "default": "contains",
+                        "description": "How service_name is matched",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "maximum": 1,
+                        "minimum": 0,
+                        "description": "Similarity threshold of the fuzzy match",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum price",
+                        "name": "price_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum price",
+                        "name": "price_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Start date from (YYYY-MM-DD)",
+                        "name": "start_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Start date to (YYYY-MM-DD)",
+                        "name": "start_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "End date from (YYYY-MM-DD)",
+                        "name": "end_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "End date to (YYYY-MM-DD)",
+                        "name": "end_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Active at date (YYYY-MM-DD)",
+                        "name": "active_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only subscriptions without an end date",
+                        "name": "open_ended",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "maxLength": 2000,
+                        "description": "Filter expression",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sort fields, prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Point in time to report the data for (RFC 3339)",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "default": "csv",
+                        "description": "File format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string",
+                            "enum": [
+                                "id",
+                                "service_name",
+                                "price",
+                                "user_id",
+                                "start_date",
+                                "end_date",
+                                "created_at",
+                                "updated_at"
+                            ]
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Columns in the order to write, all by default",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "pattern": "^(YYYY|MM|DD|[-./])+$",
+                        "description": "Date layout built from YYYY, MM, DD and the separators - . /, export.date_format of the configuration by default",
+                        "name": "date_format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The file, sent as it is read from the database",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "Attachment with the file name"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/import": {
+            "post": {
+                "description": "Import subscriptions from a CSV file with the columns service_name, price, user_id, start_date and end_date, sent as the body or as the file field of a form. The dry_run mode only reports what would be created; the commit mode creates the new subscriptions in one transaction, or none when a row is rejected",
+                "consumes": [
+                    "text/csv",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Import subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "enum": [
+                            "dry_run",
+                            "commit"
+                        ],
+                        "default": "dry_run",
+                        "description": "Import mode",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "maxLength": 255,
+                        "description": "Idempotency key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "file",
+                        "description": "CSV file, when sent as a form",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Rows were rejected and nothing was committed, reported as dto.ImportResponse; or an idempotency key reused with another request, reported as dto.Problem",
+                        "schema": {
+                            "x-oneOf": [
+                                {
+                                    "$ref": "#/definitions/dto.ImportResponse"
+                                },
+                                {
+                                    "$ref": "#/definitions/dto.Problem"
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/search": {
+            "get": {
+                "description": "Rank subscriptions by the trigram similarity of their service name to q, tolerating typos and Cyrillic or Latin spelling",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Search subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "maxLength": 100,
+                        "description": "Search text",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "maximum": 1,
+                        "minimum": 0,
+                        "description": "Minimum similarity score",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "maximum": 100,
+                        "minimum": 1,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "minimum": 0,
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.SearchHitResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/stream": {
+            "get": {
+                "description": "Push the subscription changes as Server-Sent Events. Each event is named after its type: subscription.created, subscription.updated or subscription.deleted; its data is the JSON event with the id, type and occurred_at of the event, the subscription and, for updates, its previous state. A client resuming with Last-Event-ID first receives the events it missed, or a reset event when they are no longer buffered",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Stream subscription changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID (UUID)",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Service name, repeatable",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "enum": [
+                            "exact",
+                            "prefix",
+                            "contains",
+                            "fuzzy"
+                        ],
+                        "default": "contains",
+                        "description": "How service_name is matched",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "maximum": 1,
+                        "minimum": 0,
+                        "description": "Similarity threshold of the fuzzy match",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received, sent by EventSource when it reconnects",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received, to resume on the first connection",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "description": "Get subscription by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Point in time to report the data for (RFC 3339)",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string",
+                            "enum": [
+                                "id",
+                                "service_name",
+                                "price",
+                                "user_id",
+                                "start_date",
+                                "end_date",
+                                "created_at",
+                                "updated_at"
+                            ]
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Fields to return, id is always returned",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string",
+                            "enum": [
+                                "cost"
+                            ]
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Related data to embed: cost of the current month, or of the month of as_of",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the representation the client has",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of the representation the client has",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SubscriptionResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Validator of the representation"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time of the last change"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace subscription by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Update subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Apply a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) to subscription by ID",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Patch subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch object or JSON Patch operations",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {}
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete subscription by ID",
+                "tags": [
+                    "subscriptions"
                 ],
                 "summary": "Delete subscription",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Subscription ID",
+                        "format": "uuid",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/calendar-token": {
+            "post": {
+                "description": "Issue the token authorizing the calendar feed of the user, revoking the previous one. The token is only shown in this response",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Issue calendar token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CalendarTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/calendar.ics": {
+            "get": {
+                "description": "Serve the charges of the user as an iCalendar feed (RFC 5545): a monthly event on the charge day of every subscription and an event on its end date",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Charge calendar",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Calendar token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "iCalendar feed",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/insights": {
+            "get": {
+                "description": "Find ways for the user to save: services paid for several times, price increases and monthly plans cheaper as annual ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Saving insights",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.InsightsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "List the registered webhooks",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.WebhookResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Register a URL receiving the subscription events of the listed types, signed with the secret returned only in this response",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Create webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "description": "Get webhook by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete webhook by ID together with its deliveries",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/dead-letters": {
+            "get": {
+                "description": "Get the deliveries of a webhook that failed every attempt, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List dead deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.WebhookDeliveryResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Get the deliveries of a webhook with the log of their attempts, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "enum": [
+                            "pending",
+                            "succeeded",
+                            "dead"
+                        ],
+                        "description": "Delivery status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.WebhookDeliveryResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{delivery_id}/redeliver": {
+            "post": {
+                "description": "Queue a dead delivery again, starting over its attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Redeliver webhook delivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Webhook ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Delivery ID",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookDeliveryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.BatchItemResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "not_found"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "subscription with id a3e7f924-7d11-4f36-91bb-8f69cb1c1a91 not found"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FieldErrorResponse"
+                    }
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "op": {
+                    "type": "string",
+                    "example": "create"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "applied"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/dto.SubscriptionResponse"
+                }
+            }
+        },
+        "dto.BatchOperationRequest": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "a3e7f924-7d11-4f36-91bb-8f69cb1c1a91"
+                },
+                "op": {
+                    "type": "string",
+                    "example": "create"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/dto.UpdateSubscriptionRequest"
+                }
+            }
+        },
+        "dto.BatchRequest": {
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "atomic",
+                        "best_effort"
+                    ],
+                    "example": "atomic"
+                },
+                "operations": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/dto.BatchOperationRequest"
+                    }
+                }
+            }
+        },
+        "dto.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "best_effort"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BatchItemResponse"
+                    }
+                }
+            }
+        },
+        "dto.CalendarTokenResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "Jx3s0bVd4yJ0m3hQm9tC2m2rZr8lKp1n5d0e7vQw6aQ"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://subscriptions.example.com/api/v1/users/60601fee-2bf1-4721-ae6f-7636e79a0cba/calendar.ics?token=Jx3s0bVd4yJ0m3hQm9tC2m2rZr8lKp1n5d0e7vQw6aQ"
+                }
+            }
+        },
+        "dto.CohortReportResponse": {
+            "type": "object",
+            "properties": {
+                "churn": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MonthlyChurnResponse"
+                    }
+                },
+                "cohorts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CohortResponse"
+                    }
+                },
+                "lifetimes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ServiceLifetimeResponse"
+                    }
+                },
+                "period_end": {
+                    "type": "string",
+                    "example": "2025-07"
+                },
+                "period_start": {
+                    "type": "string",
+                    "example": "2024-08"
+                }
+            }
+        },
+        "dto.CohortResponse": {
+            "type": "object",
+            "properties": {
+                "month": {
+                    "type": "string",
+                    "example": "2025-01"
+                },
+                "retention": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    },
+                    "example": [
+                        1,
+                        0.9,
+                        0.85
+                    ]
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 40
+                }
+            }
+        },
+        "dto.CostResponse": {
+            "type": "object",
+            "properties": {
+                "total": {
+                    "type": "integer",
+                    "example": 1200
+                }
+            }
+        },
+        "dto.CreateSubscriptionRequest": {
+            "type": "object",
+            "required": [
+                "price",
+                "service_name",
+                "start_date",
+                "user_id"
+            ],
+            "properties": {
+                "end_date": {
+                    "type": "string",
+                    "pattern": "^(\\d{4}-\\d{2}-\\d{2})?$",
+                    "x-nullable": true,
+                    "example": "2025-12-31"
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 400
+                },
+                "service_name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2,
+                    "example": "Yandex Plus"
+                },
+                "start_date": {
+                    "type": "string",
+                    "pattern": "^\\d{4}-\\d{2}-\\d{2}$",
+                    "example": "2025-07-01"
+                },
+                "user_id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "dto.CreateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "subscription.created",
+                            "subscription.updated",
+                            "subscription.ended",
+                            "subscription.renewed",
+                            "subscription.deleted"
+                        ]
+                    },
+                    "example": [
+                        "subscription.created",
+                        "subscription.ended"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048,
+                    "example": "https://billing.example.com/hooks/subscriptions"
+                }
+            }
+        },
+        "dto.DeliveryAttemptResponse": {
+            "type": "object",
+            "properties": {
+                "attempted_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-07-01T12:00:00Z"
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 118
+                },
+                "error": {
+                    "type": "string",
+                    "example": "unexpected response status 503 Service Unavailable"
+                },
+                "status_code": {
+                    "type": "integer",
+                    "example": 503
+                }
+            }
+        },
+        "dto.FieldErrorResponse": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "price"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "price is required"
+                }
+            }
+        },
+        "dto.GraphQLError": {
+            "type": "object",
+            "properties": {
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GraphQLErrorLocation"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Cannot query field \"name\" on type \"Subscription\"."
+                },
+                "path": {
+                    "type": "array",
+                    "items": {}
+                }
+            }
+        },
+        "dto.GraphQLErrorLocation": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "integer",
+                    "example": 25
+                },
+                "line": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.GraphQLRequest": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string",
+                    "example": "{ subscriptions(limit: 10) { id serviceName price } }"
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "dto.GraphQLResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "x-nullable": true,
+                    "additionalProperties": true
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GraphQLError"
+                    }
+                }
+            }
+        },
+        "dto.ImportResponse": {
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "dry_run",
+                        "commit"
+                    ],
+                    "example": "dry_run"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ImportRowResponse"
                     }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "No Content"
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+                },
+                "summary": {
+                    "$ref": "#/definitions/dto.ImportSummary"
+                }
+            }
+        },
+        "dto.ImportRowResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "validation_failed"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "the request has invalid fields"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FieldErrorResponse"
                     }
+                },
+                "line": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "duplicate",
+                        "rejected"
+                    ],
+                    "example": "create"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/dto.SubscriptionResponse"
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.CostResponse": {
+        },
+        "dto.ImportSummary": {
             "type": "object",
             "properties": {
-                "total": {
+                "create": {
                     "type": "integer",
-                    "example": 1200
+                    "example": 12
+                },
+                "duplicate": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "rejected": {
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "dto.CreateSubscriptionRequest": {
+        "dto.InsightResponse": {
             "type": "object",
-            "required": [
-                "price",
-                "service_name",
-                "start_date",
-                "user_id"
-            ],
             "properties": {
-                "end_date": {
+                "kind": {
                     "type": "string",
-                    "example": "2025-12-31"
+                    "enum": [
+                        "duplicate_service",
+                        "price_increase",
+                        "annual_plan"
+                    ],
+                    "example": "duplicate_service"
                 },
-                "price": {
+                "message": {
+                    "type": "string",
+                    "example": "Yandex Plus is paid for 2 times"
+                },
+                "monthly_saving": {
                     "type": "integer",
-                    "minimum": 0,
                     "example": 400
                 },
                 "service_name": {
                     "type": "string",
-                    "maxLength": 100,
-                    "minLength": 2,
                     "example": "Yandex Plus"
                 },
-                "start_date": {
-                    "type": "string",
-                    "example": "2025-07-01"
+                "subscription_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "format": "uuid"
+                    }
+                }
+            }
+        },
+        "dto.InsightsResponse": {
+            "type": "object",
+            "properties": {
+                "insights": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.InsightResponse"
+                    }
+                },
+                "total_monthly_saving": {
+                    "type": "integer",
+                    "example": 460
                 },
                 "user_id": {
                     "type": "string",
+                    "format": "uuid",
                     "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
                 }
             }
         },
+        "dto.MonthCostResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2025-07"
+                }
+            }
+        },
+        "dto.MonthlyChurnResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "churn_rate": {
+                    "type": "number",
+                    "example": 0.04
+                },
+                "churned": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2025-07"
+                }
+            }
+        },
+        "dto.MonthlyStatsResponse": {
+            "type": "object",
+            "properties": {
+                "ended": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2025-07"
+                },
+                "new": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "dto.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "subscription with id a3e7f924-7d11-4f36-91bb-8f69cb1c1a91 not found"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FieldErrorResponse"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/subscriptions/a3e7f924-7d11-4f36-91bb-8f69cb1c1a91"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:subscription-service:problem:not_found"
+                }
+            }
+        },
+        "dto.SearchHitResponse": {
+            "type": "object",
+            "properties": {
+                "highlight": {
+                    "type": "string",
+                    "example": "<mark>Yandex</mark> Plus"
+                },
+                "score": {
+                    "type": "number",
+                    "example": 0.83
+                },
+                "subscription": {
+                    "$ref": "#/definitions/dto.SubscriptionResponse"
+                }
+            }
+        },
+        "dto.ServiceLifetimeResponse": {
+            "type": "object",
+            "properties": {
+                "average_lifetime_months": {
+                    "type": "number",
+                    "example": 7.5
+                },
+                "ended": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "subscriptions": {
+                    "type": "integer",
+                    "example": 40
+                }
+            }
+        },
+        "dto.ServiceStatsResponse": {
+            "type": "object",
+            "properties": {
+                "average_price": {
+                    "type": "number",
+                    "example": 399.5
+                },
+                "median_price": {
+                    "type": "number",
+                    "example": 400
+                },
+                "revenue": {
+                    "type": "integer",
+                    "example": 144000
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "subscribers": {
+                    "type": "integer",
+                    "example": 30
+                }
+            }
+        },
+        "dto.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "active_subscriptions": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "distinct_users": {
+                    "type": "integer",
+                    "example": 45
+                },
+                "monthly": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MonthlyStatsResponse"
+                    }
+                },
+                "period_end": {
+                    "type": "string",
+                    "example": "2025-07"
+                },
+                "period_start": {
+                    "type": "string",
+                    "example": "2024-08"
+                },
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ServiceStatsResponse"
+                    }
+                },
+                "top_by_revenue": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ServiceStatsResponse"
+                    }
+                },
+                "top_by_subscribers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ServiceStatsResponse"
+                    }
+                }
+            }
+        },
+        "dto.SubscriptionListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SubscriptionResponse"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "eyJjIjoiMjAyNS0wNy0wMVQxMjowMDowMFoiLCJpIjoiYTNlN2Y5MjQtN2QxMS00ZjM2LTkxYmItOGY2OWNiMWMxYTkxIn0"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 250
+                }
+            }
+        },
         "dto.SubscriptionResponse": {
             "type": "object",
             "properties": {
+                "cost": {
+                    "$ref": "#/definitions/dto.MonthCostResponse"
+                },
                 "created_at": {
                     "type": "string",
+                    "format": "date-time",
                     "example": "2025-07-01T12:00:00Z"
                 },
                 "end_date": {
                     "type": "string",
-                    "example": "2025-12-31"
+                    "format": "date-time",
+                    "example": "2025-12-31T00:00:00Z"
                 },
                 "id": {
                     "type": "string",
+                    "format": "uuid",
                     "example": "a3e7f924-7d11-4f36-91bb-8f69cb1c1a91"
                 },
                 "price": {
@@ -422,35 +2924,167 @@ const docTemplate = `{
                 },
                 "start_date": {
                     "type": "string",
-                    "example": "2025-07-01"
+                    "format": "date-time",
+                    "example": "2025-07-01T00:00:00Z"
                 },
                 "updated_at": {
                     "type": "string",
+                    "format": "date-time",
                     "example": "2025-07-02T12:00:00Z"
                 },
                 "user_id": {
                     "type": "string",
+                    "format": "uuid",
                     "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
                 }
             }
         },
         "dto.UpdateSubscriptionRequest": {
             "type": "object",
+            "required": [
+                "price",
+                "service_name",
+                "start_date",
+                "user_id"
+            ],
             "properties": {
                 "end_date": {
-                    "type": "string"
+                    "type": "string",
+                    "pattern": "^(\\d{4}-\\d{2}-\\d{2})?$",
+                    "x-nullable": true,
+                    "example": "2025-12-31"
                 },
                 "price": {
                     "type": "integer",
-                    "minimum": 0
+                    "minimum": 0,
+                    "example": 400
                 },
                 "service_name": {
                     "type": "string",
                     "maxLength": 100,
-                    "minLength": 2
+                    "minLength": 2,
+                    "example": "Yandex Plus"
                 },
                 "start_date": {
-                    "type": "string"
+                    "type": "string",
+                    "pattern": "^\\d{4}-\\d{2}-\\d{2}$",
+                    "example": "2025-07-01"
+                },
+                "user_id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "dto.WebhookDeliveryResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-07-01T12:00:00Z"
+                },
+                "delivered_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-07-01T12:00:01Z"
+                },
+                "event_id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "9c1d2e3f-4a5b-4c6d-8e7f-0a1b2c3d4e5f"
+                },
+                "event_type": {
+                    "type": "string",
+                    "enum": [
+                        "subscription.created",
+                        "subscription.updated",
+                        "subscription.ended",
+                        "subscription.renewed",
+                        "subscription.deleted"
+                    ],
+                    "example": "subscription.created"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "5b0c7d8e-1f2a-4b3c-8d9e-0a1b2c3d4e5f"
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "unexpected response status 503 Service Unavailable"
+                },
+                "last_status_code": {
+                    "type": "integer",
+                    "example": 503
+                },
+                "log": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.DeliveryAttemptResponse"
+                    }
+                },
+                "next_attempt_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-07-01T12:05:00Z"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "succeeded",
+                        "dead"
+                    ],
+                    "example": "dead"
+                }
+            }
+        },
+        "dto.WebhookResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2025-07-01T12:00:00Z"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "subscription.created",
+                            "subscription.updated",
+                            "subscription.ended",
+                            "subscription.renewed",
+                            "subscription.deleted"
+                        ]
+                    },
+                    "example": [
+                        "subscription.created",
+                        "subscription.ended"
+                    ]
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid",
+                    "example": "0f8f5c2e-3b1a-4c8e-9d6f-2a7b1c9e4d30"
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "whsec_Jx3s0bVd4yJ0m3hQm9tC2m2rZr8lKp1n5d0e7vQw6aQ"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://billing.example.com/hooks/subscriptions"
                 }
             }
         }
@@ -461,7 +3095,7 @@ const docTemplate = `{
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
 	Host:             "localhost:8080",
-	BasePath:         "/",
+	BasePath:         "/api/v1",
 	Schemes:          []string{"http"},
 	Title:            "Subscription Service API",
 	Description:      "REST API for managing user subscriptions and calculating costs.",
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"Subscription_Service/internal/config"
+	"Subscription_Service/internal/infrastructure/repository"
+)
+
+// RebuildMonthlySpend recomputes the monthly spend aggregates from the subscription table.
+func RebuildMonthlySpend(ctx context.Context) error {
+	return withDatabase(func(db *sqlx.DB, logger *slog.Logger) error {
+		rows, err := repository.NewSpendRepository(db).Rebuild(ctx)
+		if err != nil {
+			logger.Error("Failed to rebuild monthly spend", "error", err)
+			return err
+		}
+
+		logger.Info("Monthly spend rebuilt", "rows", rows)
+		return nil
+	})
+}
+
+// PurgeIdempotencyKeys deletes the idempotency keys whose TTL has passed.
+func PurgeIdempotencyKeys(ctx context.Context) error {
+	return withDatabase(func(db *sqlx.DB, logger *slog.Logger) error {
+		rows, err := repository.NewIdempotencyRepository(db).DeleteExpired(ctx, time.Now().UTC())
+		if err != nil {
+			logger.Error("Failed to purge idempotency keys", "error", err)
+			return err
+		}
+
+		logger.Info("Expired idempotency keys purged", "rows", rows)
+		return nil
+	})
+}
+
+func withDatabase(fn func(db *sqlx.DB, logger *slog.Logger) error) error {
+	logger := setupLogger()
+
+	cfg, err := config.LoadConfig("config/config.yaml", ".env")
+	if err != nil {
+		logger.Error("Failed to load config", "error", err)
+		return err
+	}
+
+	db, err := initDatabase(cfg, logger)
+	if err != nil {
+		logger.Error("Failed to initialize database", "error", err)
+		return err
+	}
+	defer db.Close()
+
+	return fn(db, logger)
+}
@@ -2,13 +2,17 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/lmittmann/tint"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 
 	// Register PostgreSQL driver.
 	_ "github.com/lib/pq"
@@ -16,20 +20,29 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
-	_ "Subscription_Service/docs"
+	subscriptionv1 "Subscription_Service/api/subscription/v1"
+	"Subscription_Service/docs"
 	"Subscription_Service/internal/application/service"
 	"Subscription_Service/internal/config"
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+	"Subscription_Service/internal/infrastructure/controllers/graphql"
+	grpcHandler "Subscription_Service/internal/infrastructure/controllers/grpc"
 	httpHandler "Subscription_Service/internal/infrastructure/controllers/http"
 	"Subscription_Service/internal/infrastructure/repository"
+	grpcServer "Subscription_Service/pkg/grpc_server"
 	httpServer "Subscription_Service/pkg/http_server"
+	"Subscription_Service/pkg/openapi"
 )
 
 type App struct {
-	config   *config.Config
-	logger   *slog.Logger
-	db       *sqlx.DB
-	server   *httpServer.HTTPServer
-	services service.Service
+	config     *config.Config
+	logger     *slog.Logger
+	db         *sqlx.DB
+	server     *httpServer.HTTPServer
+	grpcServer *grpcServer.GRPCServer
+	webhooks   service.WebhookDispatcher
+	events     service.EventStream
+	services   service.Service
 }
 
 func New() (*App, error) {
@@ -47,10 +60,83 @@ func New() (*App, error) {
 		return nil, err
 	}
 
-	subscriptionRepo := repository.NewSubscriptionRepository(db)
-	subscriptionService := service.NewSubscriptionService(subscriptionRepo, logger)
-	services := service.NewService(subscriptionService)
-	router := initRouter(services, logger)
+	webhookRepo := repository.NewWebhookRepository(db)
+	webhookDispatcher := service.NewWebhookDispatcher(webhookRepo, service.WebhookConfig{
+		MaxAttempts:    cfg.Webhooks.MaxAttempts,
+		InitialBackoff: time.Duration(cfg.Webhooks.InitialBackoffSeconds) * time.Second,
+		MaxBackoff:     time.Duration(cfg.Webhooks.MaxBackoffSeconds) * time.Second,
+		Timeout:        time.Duration(cfg.Webhooks.TimeoutSeconds) * time.Second,
+		Workers:        cfg.Webhooks.Workers,
+		PollInterval:   time.Duration(cfg.Webhooks.PollIntervalSeconds) * time.Second,
+	}, logger)
+	webhookService := service.NewWebhookService(webhookRepo, logger)
+	eventBus := repository.NewEventBus(db, cfg.Database.GetDSN())
+	eventStream := service.NewEventStream(eventBus, service.StreamConfig{
+		ReplaySize:   cfg.Stream.ReplaySize,
+		ClientBuffer: cfg.Stream.ClientBuffer,
+		QueueSize:    cfg.Stream.QueueSize,
+	}, logger)
+	subscriptionRepo := repository.NewSubscriptionRepository(db, service.EncodeEvent)
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, service.Publishers{webhookDispatcher, eventStream}, service.SubscriptionConfig{
+		MaxBatchSize:  cfg.Batch.MaxSize,
+		MaxImportRows: cfg.Import.MaxRows,
+	}, logger)
+	statsRepo := repository.NewStatsRepository(db)
+	statsService := service.NewStatsService(statsRepo, logger)
+	insightsService := service.NewInsightsService(subscriptionRepo, service.InsightsConfig{
+		PriceIncreaseWindow: time.Duration(cfg.Insights.PriceIncreaseWindowDays) * 24 * time.Hour,
+		AnnualPlanMinMonths: cfg.Insights.AnnualPlanMinMonths,
+		AnnualPlanDiscount:  cfg.Insights.AnnualPlanDiscount,
+	}, logger)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, service.IdempotencyConfig{
+		TTL:   time.Duration(cfg.Idempotency.TTLHours) * time.Hour,
+		Lease: time.Duration(cfg.Idempotency.LeaseSeconds) * time.Second,
+	}, logger)
+	calendarTokenRepo := repository.NewCalendarTokenRepository(db)
+	calendarService := service.NewCalendarService(subscriptionRepo, calendarTokenRepo, logger)
+	services := service.NewService(subscriptionService, statsService, insightsService, idempotencyService, calendarService, webhookService, eventStream)
+	legacyDeprecatedAt, legacySunset, err := cfg.API.LegacyDates()
+	if err != nil {
+		logger.Error("Failed to load config", "error", err)
+		return nil, err
+	}
+
+	exportDateLayout, err := dto.DateLayout(cfg.Export.DateFormat)
+	if err != nil {
+		logger.Error("Failed to load config", "error", err)
+		return nil, fmt.Errorf("invalid export.date_format: %w", err)
+	}
+
+	spec, err := openapi.New([]byte(docs.SwaggerInfo.ReadDoc()))
+	if err != nil {
+		logger.Error("Failed to load OpenAPI document", "error", err)
+		return nil, err
+	}
+
+	contract := httpHandler.ContractConfig{
+		Spec:              spec,
+		ValidateRequests:  cfg.OpenAPI.ValidateRequests,
+		ValidateResponses: cfg.Env == "dev",
+	}
+
+	graphQL, err := graphql.NewExecutor(services, graphql.Limits{
+		MaxComplexity: cfg.GraphQL.MaxComplexity,
+		MaxDepth:      cfg.GraphQL.MaxDepth,
+	}, logger)
+	if err != nil {
+		logger.Error("Failed to build GraphQL schema", "error", err)
+		return nil, err
+	}
+
+	router := initRouter(services, graphQL, httpHandler.Config{
+		ExportDateLayout: exportDateLayout,
+		StreamHeartbeat:  time.Duration(cfg.Stream.HeartbeatSeconds) * time.Second,
+	}, httpHandler.RoutesConfig{
+		LegacyRoutes:       cfg.API.LegacyRoutes,
+		LegacyDeprecatedAt: legacyDeprecatedAt,
+		LegacySunset:       legacySunset,
+	}, contract, logger)
 	serverConfig := &httpServer.Config{
 		Host:              cfg.Service.Host,
 		Port:              cfg.Service.Port,
@@ -62,18 +148,67 @@ func New() (*App, error) {
 		ShutdownTimeout:   30 * time.Second,
 	}
 	server := httpServer.NewServer(logger, serverConfig)
+	server.RegisterOnShutdown(eventStream.Disconnect)
+
+	grpcServerConfig := &grpcServer.Config{
+		Host:            cfg.Service.Host,
+		Port:            cfg.GRPC.Port,
+		StartMsg:        "Subscription gRPC service started!",
+		ShutdownTimeout: 30 * time.Second,
+	}
+	rpcServer := grpcServer.NewServer(logger, grpcServerConfig, func(s *grpc.Server) {
+		subscriptionv1.RegisterSubscriptionServiceServer(s, grpcHandler.NewServer(services))
+	})
 
 	return &App{
-		config:   cfg,
-		logger:   logger,
-		db:       db,
-		server:   server,
-		services: services,
+		config:     cfg,
+		logger:     logger,
+		db:         db,
+		server:     server,
+		grpcServer: rpcServer,
+		webhooks:   webhookDispatcher,
+		events:     eventStream,
+		services:   services,
 	}, nil
 }
 
+// Start runs the HTTP and gRPC servers until a shutdown signal arrives or either of
+// them fails, which stops the other one too. The webhook dispatcher and the event
+// stream run until both have stopped, so that they pass on the events of the last
+// requests.
 func (a *App) Start(ctx context.Context) error {
-	if err := a.server.Start(ctx); err != nil {
+	workersCtx, stopWorkers := context.WithCancel(context.WithoutCancel(ctx))
+
+	var workers sync.WaitGroup
+
+	for _, w := range []interface{ Run(context.Context) error }{a.webhooks, a.events} {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+
+			if err := w.Run(workersCtx); err != nil {
+				a.logger.Error("Background worker failed", "error", err)
+			}
+		}()
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		return a.server.Start(ctx)
+	})
+
+	g.Go(func() error {
+		return a.grpcServer.Start(ctx)
+	})
+
+	err := g.Wait()
+
+	stopWorkers()
+	workers.Wait()
+
+	if err != nil {
 		a.logger.Error("Failed to start server", "error", err)
 		return err
 	}
@@ -111,19 +246,23 @@ func initDatabase(cfg *config.Config, logger *slog.Logger) (*sqlx.DB, error) {
 	return db, nil
 }
 
-func initRouter(service service.Service, logger *slog.Logger) *gin.Engine {
-	handler := httpHandler.NewHandler(service)
+func initRouter(service service.Service, graphQL *graphql.Executor, cfg httpHandler.Config, routes httpHandler.RoutesConfig, contract httpHandler.ContractConfig, logger *slog.Logger) *gin.Engine {
+	handler := httpHandler.NewHandler(service, graphQL, cfg, logger)
 
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	router.Use(httpHandler.AccessLog())
+	router.Use(gin.CustomRecovery(handler.Recovered))
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(handler.NoRoute)
+	router.NoMethod(handler.NoMethod)
 
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key, If-None-Match, If-Modified-Since, Last-Event-ID")
+		c.Header("Access-Control-Expose-Headers", "Link, X-Total-Count, Idempotent-Replayed, Deprecation, Sunset, ETag, Last-Modified")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -135,7 +274,7 @@ func initRouter(service service.Service, logger *slog.Logger) *gin.Engine {
 
 	router.GET("/health", healthCheck)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	handler.RegisterRoutes(router)
+	handler.RegisterRoutes(router, routes, contract)
 
 	logger.Info("Router initialized")
 	return router
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/repository"
+)
+
+type CalendarService interface {
+	// IssueCalendarToken generates a new secret token for the calendar feed of the user,
+	// revoking the previous one. Only a hash of the token is kept, so it cannot be
+	// shown again.
+	IssueCalendarToken(ctx context.Context, userID uuid.UUID) (string, error)
+	// CalendarSubscriptions checks the token and returns the subscriptions of the user
+	// that have not ended yet.
+	CalendarSubscriptions(ctx context.Context, userID uuid.UUID, token string) ([]model.Subscription, error)
+}
+
+type calendarService struct {
+	subscriptionRepo  repository.SubscriptionRepository
+	calendarTokenRepo repository.CalendarTokenRepository
+	logger            *slog.Logger
+}
+
+func NewCalendarService(subscriptionRepo repository.SubscriptionRepository, calendarTokenRepo repository.CalendarTokenRepository, logger *slog.Logger) CalendarService {
+	return &calendarService{
+		subscriptionRepo:  subscriptionRepo,
+		calendarTokenRepo: calendarTokenRepo,
+		logger:            logger,
+	}
+}
+
+func (s *calendarService) IssueCalendarToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	s.logger.Debug("Issuing calendar token",
+		slog.String("user_id", userID.String()),
+	)
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	if err := s.calendarTokenRepo.Save(ctx, userID, hashToken(token), time.Now().UTC()); err != nil {
+		s.logger.Error("Failed to issue calendar token",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+		)
+
+		return "", err
+	}
+
+	s.logger.Info("Calendar token issued",
+		slog.String("user_id", userID.String()),
+	)
+
+	return token, nil
+}
+
+func (s *calendarService) CalendarSubscriptions(ctx context.Context, userID uuid.UUID, token string) ([]model.Subscription, error) {
+	s.logger.Debug("Building calendar",
+		slog.String("user_id", userID.String()),
+	)
+
+	hash, err := s.calendarTokenRepo.FindHash(ctx, userID)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.ErrInvalidCalendarToken
+	}
+
+	if err != nil {
+		s.logger.Error("Failed to find calendar token",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(hashToken(token))) != 1 {
+		return nil, model.ErrInvalidCalendarToken
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var subs []model.Subscription
+
+	err = s.subscriptionRepo.Stream(ctx, model.SubscriptionFilter{UserID: &userID}, nil, func(sub model.Subscription) error {
+		if sub.EndDate.IsZero() || !sub.EndDate.Before(today) {
+			subs = append(subs, sub)
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to build calendar",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	s.logger.Info("Calendar built successfully",
+		slog.String("user_id", userID.String()),
+		slog.Int("subscriptions", len(subs)),
+	)
+
+	return subs, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
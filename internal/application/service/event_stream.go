@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/repository"
+	"Subscription_Service/pkg/textsearch"
+)
+
+// notifyTimeout bounds sending one event to the other instances.
+const notifyTimeout = 5 * time.Second
+
+// streamedEvents are the event types sent to the streams. The ended and renewed events
+// repeat an updated one.
+var streamedEvents = []model.EventType{model.EventCreated, model.EventUpdated, model.EventDeleted}
+
+type StreamService interface {
+	// SubscribeEvents opens a stream of the subscription events matching filter. With a
+	// lastEventID the stream starts with the buffered events following that event.
+	SubscribeEvents(filter StreamFilter, lastEventID string) (*EventSubscription, error)
+}
+
+// EventStream passes the subscription events of every instance to the open streams.
+// Publish sends the events of this instance to all of them over the event bus; Run
+// receives the events of all instances and keeps the latest ones for clients resuming
+// a stream.
+type EventStream interface {
+	EventPublisher
+	StreamService
+	// Run passes the events until ctx is done. The events still queued then are sent
+	// before it returns.
+	Run(ctx context.Context) error
+	// Disconnect ends every open stream and those opened later. The streams do not end
+	// on their own, so it is called when the server shuts down.
+	Disconnect()
+}
+
+type StreamConfig struct {
+	// ReplaySize is the number of latest events kept for clients resuming a stream.
+	ReplaySize int
+	// ClientBuffer is the number of events waiting to be written to a client. A client
+	// falling further behind is disconnected and resumes from the replay buffer.
+	ClientBuffer int
+	// QueueSize is the number of published events waiting to be sent. When it is full,
+	// Publish sends the events itself instead of dropping them.
+	QueueSize int
+}
+
+// StreamFilter selects the events of a stream by the subscription they report. An
+// update matches when the subscription matched before or after it, so that clients
+// also learn of subscriptions leaving their filter.
+type StreamFilter struct {
+	UserID      *uuid.UUID
+	ServiceName model.ServiceNameFilter
+}
+
+// StreamEvent is an event as sent to the streams: Data is its JSON payload.
+type StreamEvent struct {
+	ID   string
+	Type model.EventType
+	Data []byte
+
+	// subscriptions are the states of the subscription the filters are matched against.
+	subscriptions []subscriptionPayload
+}
+
+// EventSubscription is an open stream. Replay holds the buffered events following the
+// last event ID, after which Events delivers the new events; it is closed when the
+// stream is disconnected. Missed reports that the last event ID is no longer buffered,
+// so the events following it are lost and the client has to reload its state; Latest is
+// then the ID of the newest buffered event, which Events follows, or empty when none is
+// buffered.
+type EventSubscription struct {
+	Replay []StreamEvent
+	Events <-chan StreamEvent
+	Missed bool
+	Latest string
+
+	filter StreamFilter
+	events chan StreamEvent
+	close  func()
+}
+
+// Close ends the stream.
+func (s *EventSubscription) Close() {
+	s.close()
+}
+
+type eventStream struct {
+	eventBus repository.EventBus
+	cfg      StreamConfig
+	queue    chan model.Event
+	logger   *slog.Logger
+
+	mu           sync.Mutex
+	replay       []StreamEvent
+	replayStart  int
+	subscribers  map[*EventSubscription]struct{}
+	disconnected bool
+}
+
+func NewEventStream(eventBus repository.EventBus, cfg StreamConfig, logger *slog.Logger) EventStream {
+	return &eventStream{
+		eventBus:    eventBus,
+		cfg:         cfg,
+		queue:       make(chan model.Event, cfg.QueueSize),
+		logger:      logger,
+		replay:      make([]StreamEvent, 0, cfg.ReplaySize),
+		subscribers: make(map[*EventSubscription]struct{}),
+	}
+}
+
+func (s *eventStream) Publish(events ...model.Event) {
+	for _, e := range events {
+		if !slices.Contains(streamedEvents, e.Type) {
+			continue
+		}
+
+		select {
+		case s.queue <- e:
+		default:
+			s.logger.Warn("Event stream queue is full, sending the event synchronously",
+				slog.String("event_id", e.ID.String()),
+			)
+
+			s.notify(e)
+		}
+	}
+}
+
+func (s *eventStream) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		// Without the listener the streams stay silent, but the events of this
+		// instance still reach the others.
+		if err := s.eventBus.Listen(ctx, s.receive); err != nil {
+			s.logger.Error("Failed to listen for subscription events",
+				slog.String("error", err.Error()),
+			)
+		}
+	}()
+
+	for {
+		select {
+		case e := <-s.queue:
+			s.notify(e)
+		case <-ctx.Done():
+			s.drain()
+			wg.Wait()
+
+			s.logger.Info("Event stream stopped")
+
+			return nil
+		}
+	}
+}
+
+// drain sends the events left in the queue.
+func (s *eventStream) drain() {
+	for {
+		select {
+		case e := <-s.queue:
+			s.notify(e)
+		default:
+			return
+		}
+	}
+}
+
+// notify sends e to the streams of every instance.
+func (s *eventStream) notify(e model.Event) {
+	payload, err := EncodeEvent(e)
+	if err != nil {
+		s.logger.Error("Failed to encode subscription event",
+			slog.String("event_id", e.ID.String()),
+			slog.String("error", err.Error()),
+		)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	if err := s.eventBus.Notify(ctx, payload); err != nil {
+		s.logger.Error("Failed to send subscription event",
+			slog.String("event_id", e.ID.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// receive passes an event notified by any instance to the matching streams. A nil
+// payload reports events lost while the bus reconnected: the replay buffer is dropped
+// and the streams are disconnected, so that clients resume and learn that they missed
+// events.
+func (s *eventStream) receive(payload []byte) {
+	if payload == nil {
+		s.logger.Warn("Subscription event bus reconnected, events may have been lost")
+		s.reset()
+
+		return
+	}
+
+	var p eventPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		s.logger.Error("Failed to decode subscription event",
+			slog.String("error", err.Error()),
+		)
+
+		return
+	}
+
+	e := StreamEvent{
+		ID:            p.ID.String(),
+		Type:          p.Type,
+		Data:          payload,
+		subscriptions: []subscriptionPayload{p.Subscription},
+	}
+
+	if p.Previous != nil {
+		e.subscriptions = append(e.subscriptions, *p.Previous)
+	}
+
+	s.broadcast(e)
+}
+
+func (s *eventStream) broadcast(e StreamEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buffer(e)
+
+	for sub := range s.subscribers {
+		if !e.matches(sub.filter) {
+			continue
+		}
+
+		select {
+		case sub.events <- e:
+		default:
+			// The client resumes from the replay buffer.
+			s.unsubscribe(sub)
+		}
+	}
+}
+
+// buffer appends e to the replay buffer, replacing the oldest event once it is full.
+func (s *eventStream) buffer(e StreamEvent) {
+	if s.cfg.ReplaySize <= 0 {
+		return
+	}
+
+	if len(s.replay) < s.cfg.ReplaySize {
+		s.replay = append(s.replay, e)
+		return
+	}
+
+	s.replay[s.replayStart] = e
+	s.replayStart = (s.replayStart + 1) % len(s.replay)
+}
+
+func (s *eventStream) SubscribeEvents(filter StreamFilter, lastEventID string) (*EventSubscription, error) {
+	if err := filter.ServiceName.Validate(); err != nil {
+		return nil, err
+	}
+
+	events := make(chan StreamEvent, s.cfg.ClientBuffer)
+
+	sub := &EventSubscription{
+		Events: events,
+		filter: filter,
+		events: events,
+	}
+
+	sub.close = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.unsubscribe(sub)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disconnected {
+		close(events)
+		return sub, nil
+	}
+
+	// The replay and the registration happen under one lock, so that no event is
+	// missed or sent twice in between.
+	if lastEventID != "" {
+		sub.Replay, sub.Missed = s.replayAfter(lastEventID, filter)
+	}
+
+	if sub.Missed {
+		sub.Latest = s.latestID()
+	}
+
+	s.subscribers[sub] = struct{}{}
+
+	return sub, nil
+}
+
+// replayAfter returns the buffered events following the event with the given ID that
+// match filter, or reports the event missed when it is not buffered.
+func (s *eventStream) replayAfter(id string, filter StreamFilter) ([]StreamEvent, bool) {
+	n := len(s.replay)
+
+	for i := n - 1; i >= 0; i-- {
+		if s.replay[(s.replayStart+i)%n].ID != id {
+			continue
+		}
+
+		var events []StreamEvent
+
+		for j := i + 1; j < n; j++ {
+			if e := s.replay[(s.replayStart+j)%n]; e.matches(filter) {
+				events = append(events, e)
+			}
+		}
+
+		return events, false
+	}
+
+	return nil, true
+}
+
+// latestID returns the ID of the newest buffered event, or an empty ID when none is
+// buffered. It is called with mu held.
+func (s *eventStream) latestID() string {
+	n := len(s.replay)
+	if n == 0 {
+		return ""
+	}
+
+	return s.replay[(s.replayStart+n-1)%n].ID
+}
+
+func (s *eventStream) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.disconnected = true
+
+	for sub := range s.subscribers {
+		s.unsubscribe(sub)
+	}
+}
+
+// reset drops the replay buffer and disconnects the open streams.
+func (s *eventStream) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replay = s.replay[:0]
+	s.replayStart = 0
+
+	for sub := range s.subscribers {
+		s.unsubscribe(sub)
+	}
+}
+
+// unsubscribe closes the events of sub unless that was done already. It is called
+// with mu held.
+func (s *eventStream) unsubscribe(sub *EventSubscription) {
+	if _, ok := s.subscribers[sub]; !ok {
+		return
+	}
+
+	delete(s.subscribers, sub)
+	close(sub.events)
+}
+
+func (e StreamEvent) matches(f StreamFilter) bool {
+	for _, sub := range e.subscriptions {
+		if f.UserID != nil && sub.UserID != *f.UserID {
+			continue
+		}
+
+		if matchesServiceName(f.ServiceName, sub.ServiceName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesServiceName applies f to a service name the way the database applies it in
+// the subscription filters.
+func matchesServiceName(f model.ServiceNameFilter, name string) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	for _, n := range f.Names {
+		var ok bool
+
+		switch f.Match {
+		case model.MatchExact:
+			ok = strings.EqualFold(name, n)
+		case model.MatchPrefix:
+			ok = strings.HasPrefix(strings.ToLower(name), strings.ToLower(n))
+		case model.MatchFuzzy:
+			ok = textsearch.Similarity(name, n) >= f.Threshold
+		default:
+			ok = strings.Contains(strings.ToLower(name), strings.ToLower(n))
+		}
+
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+// EventPublisher receives the events of committed subscription changes. Publish is
+// called on the request path, so it must hand the events off rather than wait on I/O.
+type EventPublisher interface {
+	Publish(events ...model.Event)
+}
+
+// Publishers passes the events to each of its publishers in turn.
+type Publishers []EventPublisher
+
+func (p Publishers) Publish(events ...model.Event) {
+	for _, publisher := range p {
+		publisher.Publish(events...)
+	}
+}
+
+// eventPayload is the JSON form of an event, sent to webhooks and event streams.
+type eventPayload struct {
+	ID           uuid.UUID            `json:"id"`
+	Type         model.EventType      `json:"type"`
+	OccurredAt   time.Time            `json:"occurred_at"`
+	Subscription subscriptionPayload  `json:"subscription"`
+	Previous     *subscriptionPayload `json:"previous,omitempty"`
+}
+
+type subscriptionPayload struct {
+	ID          uuid.UUID `json:"id"`
+	ServiceName string    `json:"service_name"`
+	Price       int       `json:"price"`
+	UserID      uuid.UUID `json:"user_id"`
+	StartDate   string    `json:"start_date"`
+	EndDate     *string   `json:"end_date"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Version     int       `json:"version"`
+}
+
+// EncodeEvent returns the JSON payload of e.
+func EncodeEvent(e model.Event) ([]byte, error) {
+	return json.Marshal(newEventPayload(e))
+}
+
+func newEventPayload(e model.Event) eventPayload {
+	p := eventPayload{
+		ID:           e.ID,
+		Type:         e.Type,
+		OccurredAt:   e.OccurredAt,
+		Subscription: newSubscriptionPayload(e.Subscription),
+	}
+
+	if e.Previous != nil {
+		previous := newSubscriptionPayload(*e.Previous)
+		p.Previous = &previous
+	}
+
+	return p
+}
+
+func newSubscriptionPayload(s model.Subscription) subscriptionPayload {
+	p := subscriptionPayload{
+		ID:          s.ID,
+		ServiceName: s.ServiceName,
+		Price:       s.Price,
+		UserID:      s.UserID,
+		StartDate:   s.StartDate.Format("2006-01-02"),
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+		Version:     s.Version,
+	}
+
+	if !s.EndDate.IsZero() {
+		end := s.EndDate.Format("2006-01-02")
+		p.EndDate = &end
+	}
+
+	return p
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/repository"
+)
+
+type IdempotencyService interface {
+	// BeginIdempotent claims the key for a request with the given hash. It returns the
+	// stored response when the request is a replay, nil when the caller should process
+	// the request and then call CompleteIdempotent or ReleaseIdempotent.
+	BeginIdempotent(ctx context.Context, key model.IdempotencyKey, requestHash string) (*model.IdempotentResponse, error)
+	CompleteIdempotent(ctx context.Context, key model.IdempotencyKey, resp model.IdempotentResponse) error
+	ReleaseIdempotent(ctx context.Context, key model.IdempotencyKey) error
+}
+
+type IdempotencyConfig struct {
+	// TTL is how long a key and its response are kept.
+	TTL time.Duration
+	// Lease is how long a key stays claimed without a response. A request lost with its
+	// process never completes or releases its key, which is then taken over once the
+	// lease has passed.
+	Lease time.Duration
+}
+
+type idempotencyService struct {
+	idempotencyRepo repository.IdempotencyRepository
+	cfg             IdempotencyConfig
+	logger          *slog.Logger
+}
+
+func NewIdempotencyService(idempotencyRepo repository.IdempotencyRepository, cfg IdempotencyConfig, logger *slog.Logger) IdempotencyService {
+	return &idempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		cfg:             cfg,
+		logger:          logger,
+	}
+}
+
+func (s *idempotencyService) BeginIdempotent(ctx context.Context, key model.IdempotencyKey, requestHash string) (*model.IdempotentResponse, error) {
+	s.logger.Debug("Acquiring idempotency key",
+		slog.String("key", key.Key),
+		slog.String("method", key.Method),
+		slog.String("path", key.Path),
+	)
+
+	now := time.Now().UTC()
+
+	rec, err := s.idempotencyRepo.Acquire(ctx, key, requestHash, now, now.Add(s.cfg.Lease))
+	if err != nil {
+		s.logger.Error("Failed to acquire idempotency key",
+			slog.String("key", key.Key),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	if rec == nil {
+		return nil, nil
+	}
+
+	if rec.RequestHash != requestHash {
+		return nil, model.ErrIdempotencyKeyReused
+	}
+
+	if rec.Response == nil {
+		return nil, model.ErrIdempotencyKeyInProgress
+	}
+
+	s.logger.Info("Replaying idempotent response",
+		slog.String("key", key.Key),
+		slog.Int("status", rec.Response.Status),
+	)
+
+	return rec.Response, nil
+}
+
+func (s *idempotencyService) CompleteIdempotent(ctx context.Context, key model.IdempotencyKey, resp model.IdempotentResponse) error {
+	if err := s.idempotencyRepo.Complete(ctx, key, resp, time.Now().UTC().Add(s.cfg.TTL)); err != nil {
+		s.logger.Error("Failed to store idempotent response",
+			slog.String("key", key.Key),
+			slog.String("error", err.Error()),
+		)
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *idempotencyService) ReleaseIdempotent(ctx context.Context, key model.IdempotencyKey) error {
+	if err := s.idempotencyRepo.Release(ctx, key); err != nil {
+		s.logger.Error("Failed to release idempotency key",
+			slog.String("key", key.Key),
+			slog.String("error", err.Error()),
+		)
+
+		return err
+	}
+
+	return nil
+}
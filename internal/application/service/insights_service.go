@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/repository"
+)
+
+type InsightsService interface {
+	Insights(ctx context.Context, userID uuid.UUID) ([]model.Insight, error)
+}
+
+type InsightsConfig struct {
+	// PriceIncreaseWindow is how far back a price is compared against the current one.
+	PriceIncreaseWindow time.Duration
+	// AnnualPlanMinMonths is how long a subscription has to run before an annual plan
+	// is suggested, AnnualPlanDiscount the assumed discount of an annual plan.
+	AnnualPlanMinMonths int
+	AnnualPlanDiscount  float64
+}
+
+type insightsService struct {
+	subscriptionRepo repository.SubscriptionRepository
+	config           InsightsConfig
+	logger           *slog.Logger
+}
+
+func NewInsightsService(subscriptionRepo repository.SubscriptionRepository, config InsightsConfig, logger *slog.Logger) InsightsService {
+	return &insightsService{
+		subscriptionRepo: subscriptionRepo,
+		config:           config,
+		logger:           logger,
+	}
+}
+
+// Insights analyzes the subscriptions the user has active today and returns the
+// findings ordered by estimated monthly saving.
+func (s *insightsService) Insights(ctx context.Context, userID uuid.UUID) ([]model.Insight, error) {
+	s.logger.Debug("Analyzing user subscriptions",
+		slog.String("user_id", userID.String()),
+	)
+
+	now := time.Now().UTC()
+
+	current, err := s.userSubscriptions(ctx, userID, nil)
+	if err != nil {
+		s.logger.Error("Failed to analyze user subscriptions",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	past := now.Add(-s.config.PriceIncreaseWindow)
+
+	previous, err := s.userSubscriptions(ctx, userID, &past)
+	if err != nil {
+		s.logger.Error("Failed to analyze user subscriptions",
+			slog.String("user_id", userID.String()),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	active := make([]model.Subscription, 0, len(current))
+	for _, sub := range current {
+		if isActive(sub, now) {
+			active = append(active, sub)
+		}
+	}
+
+	insights := duplicateServiceInsights(active)
+	insights = append(insights, priceIncreaseInsights(active, previous, s.config.PriceIncreaseWindow)...)
+	insights = append(insights, annualPlanInsights(active, now, s.config.AnnualPlanMinMonths, s.config.AnnualPlanDiscount)...)
+
+	sort.SliceStable(insights, func(i, j int) bool {
+		return insights[i].MonthlySaving > insights[j].MonthlySaving
+	})
+
+	s.logger.Info("User subscriptions analyzed successfully",
+		slog.String("user_id", userID.String()),
+		slog.Int("insights", len(insights)),
+	)
+
+	return insights, nil
+}
+
+func (s *insightsService) userSubscriptions(ctx context.Context, userID uuid.UUID, asOf *time.Time) ([]model.Subscription, error) {
+	var subs []model.Subscription
+
+	page := model.PageRequest{Limit: model.MaxPageLimit}
+
+	for {
+		result, err := s.subscriptionRepo.FindFiltered(ctx, model.SubscriptionFilter{UserID: &userID}, page, asOf)
+		if err != nil {
+			return nil, err
+		}
+
+		subs = append(subs, result.Subscriptions...)
+
+		if result.Next == nil {
+			return subs, nil
+		}
+
+		page.After = result.Next
+	}
+}
+
+func isActive(sub model.Subscription, now time.Time) bool {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	return !sub.StartDate.After(today) && (sub.EndDate.IsZero() || !sub.EndDate.Before(today))
+}
+
+// duplicateServiceInsights flags services the user pays for more than once. Keeping the
+// cheapest subscription saves the price of the others.
+func duplicateServiceInsights(active []model.Subscription) []model.Insight {
+	groups := map[string][]model.Subscription{}
+	order := []string{}
+
+	for _, sub := range active {
+		key := strings.ToLower(strings.TrimSpace(sub.ServiceName))
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+
+		groups[key] = append(groups[key], sub)
+	}
+
+	var insights []model.Insight
+
+	for _, key := range order {
+		subs := groups[key]
+		if len(subs) < 2 {
+			continue
+		}
+
+		cheapest := subs[0]
+		ids := make([]uuid.UUID, 0, len(subs))
+
+		for _, sub := range subs {
+			if sub.Price < cheapest.Price {
+				cheapest = sub
+			}
+
+			ids = append(ids, sub.ID)
+		}
+
+		// Every subscription but the cheapest one is cancelled.
+		total := 0
+		savings := make(map[uuid.UUID]int, len(subs)-1)
+
+		for _, sub := range subs {
+			if sub.ID != cheapest.ID {
+				savings[sub.ID] = sub.Price
+				total += sub.Price
+			}
+		}
+
+		insights = append(insights, model.Insight{
+			Kind:            model.InsightDuplicateService,
+			ServiceName:     subs[0].ServiceName,
+			SubscriptionIDs: ids,
+			Message:         fmt.Sprintf("%s is paid for %d times", subs[0].ServiceName, len(subs)),
+			MonthlySaving:   total,
+			Savings:         savings,
+		})
+	}
+
+	return insights
+}
+
+// priceIncreaseInsights flags subscriptions that cost more now than they did window ago.
+func priceIncreaseInsights(active, previous []model.Subscription, window time.Duration) []model.Insight {
+	prices := make(map[uuid.UUID]int, len(previous))
+	for _, sub := range previous {
+		prices[sub.ID] = sub.Price
+	}
+
+	days := int(window.Hours() / 24)
+
+	var insights []model.Insight
+
+	for _, sub := range active {
+		price, ok := prices[sub.ID]
+		if !ok || sub.Price <= price {
+			continue
+		}
+
+		insights = append(insights, model.Insight{
+			Kind:            model.InsightPriceIncrease,
+			ServiceName:     sub.ServiceName,
+			SubscriptionIDs: []uuid.UUID{sub.ID},
+			Message:         fmt.Sprintf("%s price rose from %d to %d in the last %d days", sub.ServiceName, price, sub.Price, days),
+			MonthlySaving:   sub.Price - price,
+			Savings:         map[uuid.UUID]int{sub.ID: sub.Price - price},
+		})
+	}
+
+	return insights
+}
+
+// annualPlanInsights suggests an annual plan for long-running monthly subscriptions,
+// assuming the annual plan is cheaper by discount.
+func annualPlanInsights(active []model.Subscription, now time.Time, minMonths int, discount float64) []model.Insight {
+	if discount <= 0 {
+		return nil
+	}
+
+	var insights []model.Insight
+
+	for _, sub := range active {
+		months := (now.Year()-sub.StartDate.Year())*12 + int(now.Month()) - int(sub.StartDate.Month())
+		if months < minMonths {
+			continue
+		}
+
+		saving := int(math.Round(float64(sub.Price) * discount))
+		if saving <= 0 {
+			continue
+		}
+
+		insights = append(insights, model.Insight{
+			Kind:            model.InsightAnnualPlan,
+			ServiceName:     sub.ServiceName,
+			SubscriptionIDs: []uuid.UUID{sub.ID},
+			Message:         fmt.Sprintf("%s has been paid monthly for %d months, an annual plan is usually cheaper", sub.ServiceName, months),
+			MonthlySaving:   saving,
+			Savings:         map[uuid.UUID]int{sub.ID: saving},
+		})
+	}
+
+	return insights
+}
@@ -2,14 +2,32 @@ package service
 
 type Service interface {
 	SubscriptionService
+	StatsService
+	InsightsService
+	IdempotencyService
+	CalendarService
+	WebhookService
+	StreamService
 }
 
 type service struct {
 	SubscriptionService
+	StatsService
+	InsightsService
+	IdempotencyService
+	CalendarService
+	WebhookService
+	StreamService
 }
 
-func NewService(subscriptionService SubscriptionService) Service {
+func NewService(subscriptionService SubscriptionService, statsService StatsService, insightsService InsightsService, idempotencyService IdempotencyService, calendarService CalendarService, webhookService WebhookService, streamService StreamService) Service {
 	return &service{
 		SubscriptionService: subscriptionService,
+		StatsService:        statsService,
+		InsightsService:     insightsService,
+		IdempotencyService:  idempotencyService,
+		CalendarService:     calendarService,
+		WebhookService:      webhookService,
+		StreamService:       streamService,
 	}
 }
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/repository"
+)
+
+type StatsService interface {
+	Stats(ctx context.Context, startDate, endDate time.Time, top int) (*model.Stats, error)
+	Cohorts(ctx context.Context, serviceName model.ServiceNameFilter, startDate, endDate time.Time) (*model.CohortReport, error)
+}
+
+type statsService struct {
+	statsRepo repository.StatsRepository
+	logger    *slog.Logger
+}
+
+func NewStatsService(statsRepo repository.StatsRepository, logger *slog.Logger) StatsService {
+	return &statsService{
+		statsRepo: statsRepo,
+		logger:    logger,
+	}
+}
+
+func (s *statsService) Stats(ctx context.Context, startDate, endDate time.Time, top int) (*model.Stats, error) {
+	s.logger.Debug("Collecting subscription stats",
+		slog.Time("start_date", startDate),
+		slog.Time("end_date", endDate),
+		slog.Int("top", top),
+	)
+
+	if endDate.Before(startDate) {
+		return nil, model.Invalid("end_date", "end_date cannot be before start_date")
+	}
+
+	if top <= 0 {
+		return nil, model.Invalid("top", "top must be positive")
+	}
+
+	stats, err := s.statsRepo.Stats(ctx, startDate, endDate, top)
+	if err != nil {
+		s.logger.Error("Failed to collect stats",
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	s.logger.Info("Subscription stats collected successfully")
+
+	return stats, nil
+}
+
+func (s *statsService) Cohorts(ctx context.Context, serviceName model.ServiceNameFilter, startDate, endDate time.Time) (*model.CohortReport, error) {
+	s.logger.Debug("Building subscription cohorts",
+		slog.String("service_name", serviceNames(serviceName)),
+		slog.String("match", string(serviceName.Match)),
+		slog.Time("start_date", startDate),
+		slog.Time("end_date", endDate),
+	)
+
+	if endDate.Before(startDate) {
+		return nil, model.Invalid("end_date", "end_date cannot be before start_date")
+	}
+
+	if err := serviceName.Validate(); err != nil {
+		return nil, err
+	}
+
+	report, err := s.statsRepo.Cohorts(ctx, serviceName, startDate, endDate)
+	if err != nil {
+		s.logger.Error("Failed to build cohorts",
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	s.logger.Info("Subscription cohorts built successfully",
+		slog.String("service_name", serviceNames(serviceName)),
+	)
+
+	return report, nil
+}
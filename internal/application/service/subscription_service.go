@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	model "Subscription_Service/internal/domain/subscription"
@@ -14,22 +15,53 @@ import (
 
 type SubscriptionService interface {
 	Create(ctx context.Context, s *model.Subscription) error
-	Read(ctx context.Context, id uuid.UUID) (*model.Subscription, error)
+	// Read returns the subscription, as of asOf when it is set. Fields restricts the
+	// fields read, as in the repository; empty reads all.
+	Read(ctx context.Context, id uuid.UUID, asOf *time.Time, fields []string) (*model.Subscription, error)
 	Update(ctx context.Context, s *model.Subscription) error
 	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, limit, offset int) ([]model.Subscription, error)
-	FindFiltered(ctx context.Context, userID *uuid.UUID, serviceName *string, limit, offset int) ([]model.Subscription, error)
-	CalculateCost(ctx context.Context, userID *uuid.UUID, serviceName *string, startDate, endDate time.Time) (int64, error)
+	Batch(ctx context.Context, ops []model.BatchOperation, atomic bool) ([]model.BatchResult, error)
+	// CheckBatchSize rejects a batch of n operations with model.ErrBatchTooLarge when it
+	// exceeds the limit, so that callers can refuse it before converting its operations.
+	CheckBatchSize(n int) error
+	Import(ctx context.Context, rows []model.ImportRow, commit bool) (*model.ImportReport, error)
+	Export(ctx context.Context, filter model.SubscriptionFilter, asOf *time.Time, fn func(model.Subscription) error) error
+	ExportCost(ctx context.Context, filter model.SubscriptionFilter, startDate, endDate time.Time, asOf *time.Time, fn func(model.CostLine) error) error
+	List(ctx context.Context, filter model.SubscriptionFilter, page model.PageRequest, asOf *time.Time) (model.SubscriptionPage, error)
+	// ListPerUser returns at most limit subscriptions of each of the users, newest
+	// first, so that the subscriptions of several users are read at once.
+	ListPerUser(ctx context.Context, userIDs []uuid.UUID, limit int) ([]model.Subscription, error)
+	FindFiltered(ctx context.Context, filter model.SubscriptionFilter, page model.PageRequest, asOf *time.Time) (model.SubscriptionPage, error)
+	CalculateCost(ctx context.Context, filter model.SubscriptionFilter, startDate, endDate time.Time, asOf *time.Time) (int64, error)
+	// LastChange identifies the committed state of the subscriptions. It changes
+	// whenever a listing may have, and is cheap to compute.
+	LastChange(ctx context.Context) (model.Change, error)
+	// Search ranks subscriptions by how closely their service name matches the query,
+	// tolerating typos and the Cyrillic or Latin spelling of the name.
+	Search(ctx context.Context, q model.SearchQuery) ([]model.SearchHit, error)
+}
+
+type SubscriptionConfig struct {
+	// MaxBatchSize is the largest number of operations a batch may contain.
+	MaxBatchSize int
+	// MaxImportRows is the largest number of rows an import may contain.
+	MaxImportRows int
 }
 
 type subscriptionService struct {
 	subscriptionRepo repository.SubscriptionRepository
+	events           EventPublisher
+	cfg              SubscriptionConfig
 	logger           *slog.Logger
 }
 
-func NewSubscriptionService(subscriptionRepo repository.SubscriptionRepository, logger *slog.Logger) SubscriptionService {
+// NewSubscriptionService returns the subscription service. Every committed create,
+// update and delete is reported to events.
+func NewSubscriptionService(subscriptionRepo repository.SubscriptionRepository, events EventPublisher, cfg SubscriptionConfig, logger *slog.Logger) SubscriptionService {
 	return &subscriptionService{
 		subscriptionRepo: subscriptionRepo,
+		events:           events,
+		cfg:              cfg,
 		logger:           logger,
 	}
 }
@@ -40,11 +72,11 @@ func (s *subscriptionService) Create(ctx context.Context, sub *model.Subscriptio
 		slog.String("user_id", sub.UserID.String()),
 	)
 
-	if sub.Price <= 0 {
-		return fmt.Errorf("price must be positive")
+	if err := validateSubscription(sub); err != nil {
+		return err
 	}
 
-	err := s.subscriptionRepo.Create(ctx, sub)
+	events, err := s.subscriptionRepo.Create(ctx, sub)
 	if err != nil {
 		s.logger.Error("Failed to create subscription",
 			slog.String("error", err.Error()),
@@ -58,15 +90,22 @@ func (s *subscriptionService) Create(ctx context.Context, sub *model.Subscriptio
 		slog.String("subscription_id", sub.ID.String()),
 	)
 
+	s.events.Publish(events...)
+
 	return nil
 }
 
-func (s *subscriptionService) Read(ctx context.Context, id uuid.UUID) (sub *model.Subscription, err error) {
+func (s *subscriptionService) Read(ctx context.Context, id uuid.UUID, asOf *time.Time, fields []string) (sub *model.Subscription, err error) {
 	s.logger.Debug("Fetching subscription",
 		slog.String("id", id.String()),
+		slog.String("as_of", safeTime(asOf)),
 	)
 
-	sub, err = s.subscriptionRepo.Read(ctx, id)
+	if err := model.ValidateFields(fields); err != nil {
+		return nil, err
+	}
+
+	sub, err = s.subscriptionRepo.Read(ctx, id, asOf, fields)
 	if err != nil {
 		s.logger.Error("Failed to fetch subscription",
 			slog.String("id", id.String()),
@@ -79,12 +118,29 @@ func (s *subscriptionService) Read(ctx context.Context, id uuid.UUID) (sub *mode
 	return sub, nil
 }
 
+func (s *subscriptionService) LastChange(ctx context.Context) (model.Change, error) {
+	last, err := s.subscriptionRepo.LastChange(ctx)
+	if err != nil {
+		s.logger.Error("Failed to find last change",
+			slog.String("error", err.Error()),
+		)
+
+		return model.Change{}, err
+	}
+
+	return last, nil
+}
+
 func (s *subscriptionService) Update(ctx context.Context, sub *model.Subscription) error {
 	s.logger.Debug("Updating subscription",
 		slog.String("id", sub.ID.String()),
 	)
 
-	err := s.subscriptionRepo.Update(ctx, sub)
+	if err := validateSubscription(sub); err != nil {
+		return err
+	}
+
+	events, err := s.subscriptionRepo.Update(ctx, sub)
 	if err != nil {
 		s.logger.Error("Failed to update subscription",
 			slog.String("id", sub.ID.String()),
@@ -98,6 +154,8 @@ func (s *subscriptionService) Update(ctx context.Context, sub *model.Subscriptio
 		slog.String("id", sub.ID.String()),
 	)
 
+	s.events.Publish(events...)
+
 	return nil
 }
 
@@ -106,7 +164,7 @@ func (s *subscriptionService) Delete(ctx context.Context, id uuid.UUID) error {
 		slog.String("id", id.String()),
 	)
 
-	err := s.subscriptionRepo.Delete(ctx, id)
+	events, err := s.subscriptionRepo.Delete(ctx, id)
 	if err != nil {
 		s.logger.Error("Failed to delete subscription",
 			slog.String("id", id.String()),
@@ -120,22 +178,218 @@ func (s *subscriptionService) Delete(ctx context.Context, id uuid.UUID) error {
 		slog.String("id", id.String()),
 	)
 
+	s.events.Publish(events...)
+
+	return nil
+}
+
+func (s *subscriptionService) CheckBatchSize(n int) error {
+	if n > s.cfg.MaxBatchSize {
+		return fmt.Errorf("%w: %d operations, at most %d allowed", model.ErrBatchTooLarge, n, s.cfg.MaxBatchSize)
+	}
+
 	return nil
 }
 
-func (s *subscriptionService) List(ctx context.Context, limit, offset int) ([]model.Subscription, error) {
+// Batch validates and applies the operations. In atomic mode nothing is applied unless
+// every operation succeeds; otherwise the valid operations are applied independently.
+func (s *subscriptionService) Batch(ctx context.Context, ops []model.BatchOperation, atomic bool) ([]model.BatchResult, error) {
+	s.logger.Debug("Applying subscription batch",
+		slog.Int("operations", len(ops)),
+		slog.Bool("atomic", atomic),
+	)
+
+	if err := s.CheckBatchSize(len(ops)); err != nil {
+		return nil, err
+	}
+
+	results := make([]model.BatchResult, len(ops))
+	pending := make([]model.BatchOperation, 0, len(ops))
+	indexes := make([]int, 0, len(ops))
+
+	for i, op := range ops {
+		results[i].Subscription = op.Subscription
+
+		if op.Kind != model.BatchDelete {
+			if err := validateSubscription(&op.Subscription); err != nil {
+				results[i].Err = err
+				continue
+			}
+		}
+
+		pending = append(pending, op)
+		indexes = append(indexes, i)
+	}
+
+	if atomic && len(pending) < len(ops) {
+		return model.RolledBack(results), nil
+	}
+
+	applied, err := s.subscriptionRepo.Batch(ctx, pending, atomic)
+	if err != nil {
+		s.logger.Error("Failed to apply subscription batch",
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	succeeded := 0
+	events := make([]model.Event, 0, len(applied))
+
+	for j, r := range applied {
+		results[indexes[j]] = r
+		if !r.Applied {
+			continue
+		}
+
+		succeeded++
+
+		events = append(events, r.Events...)
+	}
+
+	s.events.Publish(events...)
+
+	s.logger.Info("Subscription batch applied",
+		slog.Int("operations", len(ops)),
+		slog.Int("applied", succeeded),
+	)
+
+	return results, nil
+}
+
+// Import classifies the rows as new, duplicates of existing subscriptions or of
+// earlier rows, or rejected by validation. On commit the new rows are created in one
+// transaction; if any of them fails nothing is created and the failure is reported on
+// its row.
+func (s *subscriptionService) Import(ctx context.Context, rows []model.ImportRow, commit bool) (*model.ImportReport, error) {
+	s.logger.Debug("Importing subscriptions",
+		slog.Int("rows", len(rows)),
+		slog.Bool("commit", commit),
+	)
+
+	if len(rows) > s.cfg.MaxImportRows {
+		return nil, fmt.Errorf("%w: %d rows, at most %d allowed", model.ErrImportTooLarge, len(rows), s.cfg.MaxImportRows)
+	}
+
+	report := &model.ImportReport{Rows: make([]model.ImportRowResult, len(rows))}
+	valid := make([]model.Subscription, 0, len(rows))
+
+	for i, row := range rows {
+		report.Rows[i] = model.ImportRowResult{Line: row.Line, Status: model.ImportRejected, Subscription: row.Subscription, Err: row.Err}
+
+		if row.Err == nil {
+			report.Rows[i].Err = validateSubscription(&row.Subscription)
+		}
+
+		if report.Rows[i].Err == nil {
+			valid = append(valid, row.Subscription)
+		}
+	}
+
+	existing := map[model.DuplicateKey]bool{}
+
+	if len(valid) > 0 {
+		var err error
+
+		existing, err = s.subscriptionRepo.FindDuplicates(ctx, valid)
+		if err != nil {
+			s.logger.Error("Failed to find duplicate subscriptions",
+				slog.String("error", err.Error()),
+			)
+
+			return nil, err
+		}
+	}
+
+	ops := make([]model.BatchOperation, 0, len(valid))
+	indexes := make([]int, 0, len(valid))
+
+	for i := range report.Rows {
+		r := &report.Rows[i]
+		if r.Err != nil {
+			continue
+		}
+
+		key := r.Subscription.DuplicateKey()
+		if existing[key] {
+			r.Status = model.ImportDuplicate
+			continue
+		}
+
+		existing[key] = true
+		r.Status = model.ImportCreate
+
+		ops = append(ops, model.BatchOperation{Kind: model.BatchCreate, Subscription: r.Subscription})
+		indexes = append(indexes, i)
+	}
+
+	if !commit || len(ops) == 0 {
+		return report, nil
+	}
+
+	results, err := s.subscriptionRepo.Batch(ctx, ops, true)
+	if err != nil {
+		s.logger.Error("Failed to import subscriptions",
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	report.Committed = true
+
+	for j, r := range results {
+		row := &report.Rows[indexes[j]]
+		row.Subscription = r.Subscription
+
+		if r.Err != nil {
+			row.Status = model.ImportRejected
+			row.Err = r.Err
+			report.Committed = false
+		}
+	}
+
+	if report.Committed {
+		events := make([]model.Event, 0, len(results))
+		for _, r := range results {
+			events = append(events, r.Events...)
+		}
+
+		s.events.Publish(events...)
+	}
+
+	s.logger.Info("Subscriptions imported",
+		slog.Int("rows", len(rows)),
+		slog.Int("created", len(ops)),
+		slog.Bool("committed", report.Committed),
+	)
+
+	return report, nil
+}
+
+func (s *subscriptionService) List(ctx context.Context, filter model.SubscriptionFilter, page model.PageRequest, asOf *time.Time) (model.SubscriptionPage, error) {
 	s.logger.Debug("Listing subscriptions",
-		slog.Int("limit", limit),
-		slog.Int("offset", offset),
+		slog.String("user_id", safeUUID(filter.UserID)),
+		slog.String("service_name", serviceNames(filter.ServiceName)),
+		slog.String("sort", model.SortKey(filter.SortOrDefault())),
+		slog.Int("limit", page.Limit),
+		slog.Int("offset", page.Offset),
+		slog.Bool("keyset", page.After != nil),
+		slog.String("as_of", safeTime(asOf)),
 	)
 
-	subs, err := s.subscriptionRepo.List(ctx, limit, offset)
+	if err := validateQuery(filter, page); err != nil {
+		return model.SubscriptionPage{}, err
+	}
+
+	subs, err := s.subscriptionRepo.List(ctx, filter, page, asOf)
 	if err != nil {
 		s.logger.Error("Failed to list subscriptions",
 			slog.String("error", err.Error()),
 		)
 
-		return nil, err
+		return model.SubscriptionPage{}, err
 	}
 
 	s.logger.Info("Subscription listed successfully")
@@ -143,42 +397,111 @@ func (s *subscriptionService) List(ctx context.Context, limit, offset int) ([]mo
 	return subs, nil
 }
 
-func (s *subscriptionService) FindFiltered(ctx context.Context, userID *uuid.UUID, serviceName *string, limit, offset int) ([]model.Subscription, error) {
+func (s *subscriptionService) ListPerUser(ctx context.Context, userIDs []uuid.UUID, limit int) ([]model.Subscription, error) {
+	s.logger.Debug("Listing subscriptions per user",
+		slog.Int("users", len(userIDs)),
+		slog.Int("limit", limit),
+	)
+
+	if limit < 1 || limit > model.MaxPageLimit {
+		return nil, model.Invalid("limit", "limit must be between 1 and %d", model.MaxPageLimit)
+	}
+
+	subs, err := s.subscriptionRepo.ListPerUser(ctx, userIDs, limit)
+	if err != nil {
+		s.logger.Error("Failed to list subscriptions per user",
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (s *subscriptionService) FindFiltered(ctx context.Context, filter model.SubscriptionFilter, page model.PageRequest, asOf *time.Time) (model.SubscriptionPage, error) {
 	s.logger.Debug("Filtering subscriptions",
-		slog.String("user_id", safeUUID(userID)),
-		slog.String("service_name", safeStr(serviceName)),
+		slog.String("user_id", safeUUID(filter.UserID)),
+		slog.String("service_name", serviceNames(filter.ServiceName)),
+		slog.String("match", string(filter.ServiceName.Match)),
+		slog.String("sort", model.SortKey(filter.SortOrDefault())),
+		slog.Int("limit", page.Limit),
+		slog.Int("offset", page.Offset),
+		slog.Bool("keyset", page.After != nil),
+		slog.String("as_of", safeTime(asOf)),
 	)
 
-	subs, err := s.subscriptionRepo.FindFiltered(ctx, userID, serviceName, limit, offset)
+	if err := validateQuery(filter, page); err != nil {
+		return model.SubscriptionPage{}, err
+	}
+
+	subs, err := s.subscriptionRepo.FindFiltered(ctx, filter, page, asOf)
 	if err != nil {
 		s.logger.Error("Failed to filter subscriptions",
 			slog.String("error", err.Error()),
 		)
 
-		return nil, err
+		return model.SubscriptionPage{}, err
 	}
 
 	s.logger.Info("Subscription filtered successfully",
-		slog.String("user_id", safeUUID(userID)),
-		slog.String("service_name", safeStr(serviceName)),
+		slog.String("user_id", safeUUID(filter.UserID)),
+		slog.String("service_name", serviceNames(filter.ServiceName)),
 	)
 
 	return subs, nil
 }
 
-func (s *subscriptionService) CalculateCost(ctx context.Context, userID *uuid.UUID, serviceName *string, startDate, endDate time.Time) (int64, error) {
+func (s *subscriptionService) Search(ctx context.Context, q model.SearchQuery) ([]model.SearchHit, error) {
+	s.logger.Debug("Searching subscriptions",
+		slog.String("q", q.Text),
+		slog.String("user_id", safeUUID(q.UserID)),
+		slog.Float64("threshold", q.Threshold),
+		slog.Int("limit", q.Limit),
+		slog.Int("offset", q.Offset),
+	)
+
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	hits, err := s.subscriptionRepo.Search(ctx, q)
+	if err != nil {
+		s.logger.Error("Failed to search subscriptions",
+			slog.String("q", q.Text),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	s.logger.Info("Subscriptions searched successfully",
+		slog.String("q", q.Text),
+		slog.Int("hits", len(hits)),
+	)
+
+	return hits, nil
+}
+
+func (s *subscriptionService) CalculateCost(ctx context.Context, filter model.SubscriptionFilter, startDate, endDate time.Time, asOf *time.Time) (int64, error) {
 	s.logger.Debug("Calculating subscription cost",
-		slog.String("user_id", safeUUID(userID)),
-		slog.String("service_name", safeStr(serviceName)),
+		slog.String("user_id", safeUUID(filter.UserID)),
+		slog.String("service_name", serviceNames(filter.ServiceName)),
+		slog.String("match", string(filter.ServiceName.Match)),
 		slog.Time("start_date", startDate),
 		slog.Time("end_date", endDate),
+		slog.String("as_of", safeTime(asOf)),
 	)
 
 	if endDate.Before(startDate) {
-		return 0, fmt.Errorf("endDate cannot be before startDate")
+		return 0, model.Invalid("end_date", "end_date cannot be before start_date")
+	}
+
+	if err := filter.Validate(); err != nil {
+		return 0, err
 	}
 
-	total, err := s.subscriptionRepo.CalculateCost(ctx, userID, serviceName, startDate, endDate)
+	total, err := s.subscriptionRepo.CalculateCost(ctx, filter, startDate, endDate, asOf)
 	if err != nil {
 		s.logger.Error("Failed to calculate cost",
 			slog.String("error", err.Error()),
@@ -188,13 +511,110 @@ func (s *subscriptionService) CalculateCost(ctx context.Context, userID *uuid.UU
 	}
 
 	s.logger.Info("Subscription calculated successfully",
-		slog.String("user_id", safeUUID(userID)),
-		slog.String("service_name", safeStr(serviceName)),
+		slog.String("user_id", safeUUID(filter.UserID)),
+		slog.String("service_name", serviceNames(filter.ServiceName)),
 	)
 
 	return total, nil
 }
 
+// Export calls fn for every subscription matching filter without loading them all
+// into memory.
+func (s *subscriptionService) Export(ctx context.Context, filter model.SubscriptionFilter, asOf *time.Time, fn func(model.Subscription) error) error {
+	s.logger.Debug("Exporting subscriptions",
+		slog.String("user_id", safeUUID(filter.UserID)),
+		slog.String("service_name", serviceNames(filter.ServiceName)),
+		slog.String("sort", model.SortKey(filter.SortOrDefault())),
+		slog.String("as_of", safeTime(asOf)),
+	)
+
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	count := 0
+
+	err := s.subscriptionRepo.Stream(ctx, filter, asOf, func(sub model.Subscription) error {
+		count++
+		return fn(sub)
+	})
+	if err != nil {
+		s.logger.Error("Failed to export subscriptions",
+			slog.Int("exported", count),
+			slog.String("error", err.Error()),
+		)
+
+		return err
+	}
+
+	s.logger.Info("Subscriptions exported successfully",
+		slog.Int("exported", count),
+	)
+
+	return nil
+}
+
+// ExportCost calls fn for every subscription billed in the period with its share of
+// the cost, without loading them all into memory.
+func (s *subscriptionService) ExportCost(ctx context.Context, filter model.SubscriptionFilter, startDate, endDate time.Time, asOf *time.Time, fn func(model.CostLine) error) error {
+	s.logger.Debug("Exporting subscription cost",
+		slog.String("user_id", safeUUID(filter.UserID)),
+		slog.String("service_name", serviceNames(filter.ServiceName)),
+		slog.Time("start_date", startDate),
+		slog.Time("end_date", endDate),
+		slog.String("as_of", safeTime(asOf)),
+	)
+
+	if endDate.Before(startDate) {
+		return model.Invalid("end_date", "end_date cannot be before start_date")
+	}
+
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	count := 0
+
+	err := s.subscriptionRepo.StreamCostLines(ctx, filter, startDate, endDate, asOf, func(line model.CostLine) error {
+		count++
+		return fn(line)
+	})
+	if err != nil {
+		s.logger.Error("Failed to export cost",
+			slog.Int("exported", count),
+			slog.String("error", err.Error()),
+		)
+
+		return err
+	}
+
+	s.logger.Info("Subscription cost exported successfully",
+		slog.Int("exported", count),
+	)
+
+	return nil
+}
+
+func validateSubscription(sub *model.Subscription) error {
+	if sub.Price <= 0 {
+		return model.Invalid("price", "price must be positive")
+	}
+
+	if !sub.EndDate.IsZero() && sub.EndDate.Before(sub.StartDate) {
+		return model.Invalid("end_date", "end_date cannot be before start_date")
+	}
+
+	return nil
+}
+
+func validateQuery(filter model.SubscriptionFilter, page model.PageRequest) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	return page.Validate()
+}
+
 func safeUUID(u *uuid.UUID) string {
 	if u == nil {
 		return ""
@@ -203,10 +623,14 @@ func safeUUID(u *uuid.UUID) string {
 	return u.String()
 }
 
-func safeStr(s *string) string {
-	if s == nil {
+func safeTime(t *time.Time) string {
+	if t == nil {
 		return ""
 	}
 
-	return *s
+	return t.Format(time.RFC3339)
+}
+
+func serviceNames(f model.ServiceNameFilter) string {
+	return strings.Join(f.Names, ",")
 }
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/repository"
+	"Subscription_Service/pkg/webhook"
+)
+
+const (
+	// leaseMargin is added to the request timeout to lease a claimed delivery long
+	// enough for its attempt to be recorded.
+	leaseMargin = time.Minute
+	// maxWebhookResponseBytes is how much of a response body is read before the
+	// connection is released.
+	maxWebhookResponseBytes = 64 << 10
+)
+
+var (
+	// errForbiddenAddress reports a webhook host resolving to an address of the network
+	// the service runs in, which webhooks are not allowed to reach.
+	errForbiddenAddress = errors.New("webhook address is not allowed")
+	// The errors stored for requests that got no response. The delivery log is shown
+	// through the API, so it only tells the kind of failure: the underlying errors would
+	// tell which hosts and ports of the network behind the service answer.
+	errWebhookTimeout = errors.New("request timed out")
+	errWebhookFailed  = errors.New("request failed")
+)
+
+// WebhookDispatcher delivers the subscription events to the webhooks receiving them.
+// The deliveries are stored by the subscription writes, in the transaction of the
+// change they report; Run sends them in the background, so that the requests making the
+// changes do not wait for the webhooks. Deliveries are claimed from the database, so
+// every instance of the service can run one.
+type WebhookDispatcher interface {
+	// Publish wakes the delivery of the events up rather than waiting for the next poll.
+	EventPublisher
+	// Run delivers the stored deliveries until ctx is done.
+	Run(ctx context.Context) error
+}
+
+type WebhookConfig struct {
+	// MaxAttempts is the number of failed attempts after which a delivery is dead.
+	MaxAttempts int
+	// InitialBackoff is the delay after the first failed attempt. Each later delay
+	// doubles, up to MaxBackoff, and is randomized by up to half to spread retries.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Timeout bounds each request to a webhook.
+	Timeout time.Duration
+	// Workers is the number of deliveries attempted at once.
+	Workers int
+	// PollInterval is how often due deliveries are looked for: the retries, and those
+	// stored by other instances.
+	PollInterval time.Duration
+}
+
+type webhookDispatcher struct {
+	webhookRepo repository.WebhookRepository
+	client      *http.Client
+	cfg         WebhookConfig
+	wake        chan struct{}
+	logger      *slog.Logger
+}
+
+func NewWebhookDispatcher(webhookRepo repository.WebhookRepository, cfg WebhookConfig, logger *slog.Logger) WebhookDispatcher {
+	// The address is checked as it is dialed rather than when the webhook is registered,
+	// since the host may resolve to another address by the time of a delivery.
+	dialer := &net.Dialer{Control: checkWebhookAddress}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// Through a proxy only the address of the proxy would be checked.
+	transport.Proxy = nil
+
+	return &webhookDispatcher{
+		webhookRepo: webhookRepo,
+		client: &http.Client{
+			Transport: transport,
+			// A redirect is reported as the failure it is rather than followed.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg:    cfg,
+		wake:   make(chan struct{}, 1),
+		logger: logger,
+	}
+}
+
+func (d *webhookDispatcher) Publish(...model.Event) {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *webhookDispatcher) Run(ctx context.Context) error {
+	d.deliverLoop(ctx)
+
+	d.logger.Info("Webhook dispatcher stopped")
+
+	return nil
+}
+
+func (d *webhookDispatcher) deliverLoop(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+
+		d.deliverDue(ctx)
+	}
+}
+
+// deliverDue attempts the due deliveries, Workers at a time, until none is left or
+// ctx is done. Attempts already started are completed and recorded.
+func (d *webhookDispatcher) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now().UTC()
+
+		tasks, err := d.webhookRepo.Claim(ctx, now, now.Add(d.cfg.Timeout+leaseMargin), d.cfg.Workers)
+		if err != nil {
+			d.logger.Error("Failed to claim webhook deliveries",
+				slog.String("error", err.Error()),
+			)
+
+			return
+		}
+
+		var wg sync.WaitGroup
+
+		for _, t := range tasks {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				d.attempt(context.WithoutCancel(ctx), t)
+			}()
+		}
+
+		wg.Wait()
+
+		if len(tasks) < d.cfg.Workers {
+			return
+		}
+	}
+}
+
+// attempt sends a claimed delivery and records the outcome: the delivery succeeds,
+// is retried after a backoff, or is dead once it has used up its attempts.
+func (d *webhookDispatcher) attempt(ctx context.Context, t model.DeliveryTask) {
+	delivery := t.Delivery
+	start := time.Now()
+
+	status, err := d.send(ctx, t)
+
+	attempt := model.DeliveryAttempt{
+		StatusCode:  status,
+		Duration:    time.Since(start),
+		AttemptedAt: start.UTC(),
+	}
+
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	delivery.Attempts++
+	delivery.LastStatusCode = status
+	delivery.LastError = attempt.Error
+
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliverySucceeded
+		delivery.DeliveredAt = time.Now().UTC()
+	case delivery.Attempts >= d.cfg.MaxAttempts:
+		delivery.Status = model.DeliveryDead
+
+		d.logger.Warn("Webhook delivery failed every attempt, moved to dead letters",
+			slog.String("delivery_id", delivery.ID.String()),
+			slog.String("webhook_id", delivery.WebhookID.String()),
+			slog.String("error", attempt.Error),
+		)
+	default:
+		delivery.NextAttemptAt = time.Now().UTC().Add(d.backoff(delivery.Attempts))
+	}
+
+	if err := d.webhookRepo.RecordAttempt(ctx, delivery, attempt); err != nil {
+		d.logger.Error("Failed to record webhook delivery attempt",
+			slog.String("delivery_id", delivery.ID.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// send posts the signed payload of the delivery and returns the response status. Any
+// status but 2xx is a failure.
+func (d *webhookDispatcher) send(ctx context.Context, t model.DeliveryTask) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(t.Delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Subscription-Service-Webhooks")
+	req.Header.Set("X-Webhook-Id", t.Delivery.ID.String())
+	req.Header.Set("X-Webhook-Event", string(t.Delivery.EventType))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(t.Secret, time.Now(), t.Delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		d.logger.Warn("Webhook request failed",
+			slog.String("delivery_id", t.Delivery.ID.String()),
+			slog.String("error", err.Error()),
+		)
+
+		return 0, requestError(err)
+	}
+	defer resp.Body.Close()
+
+	// Reading the body lets the connection be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// requestError returns the error stored for a request that got no response.
+func requestError(err error) error {
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, errForbiddenAddress):
+		return errForbiddenAddress
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return errWebhookTimeout
+	default:
+		return errWebhookFailed
+	}
+}
+
+// checkWebhookAddress refuses to connect to loopback, private, link-local, unspecified
+// and multicast addresses, so that webhooks cannot reach the service itself or the
+// hosts next to it.
+func checkWebhookAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errForbiddenAddress
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return errForbiddenAddress
+	}
+
+	addr = addr.Unmap()
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return errForbiddenAddress
+	}
+
+	return nil
+}
+
+// backoff returns the delay before the attempt following the given number of failed
+// ones: InitialBackoff doubled per earlier failure, capped at MaxBackoff, of which a
+// random part of up to half is dropped.
+func (d *webhookDispatcher) backoff(failures int) time.Duration {
+	delay := d.cfg.InitialBackoff
+	for i := 1; i < failures && delay < d.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	delay = min(delay, d.cfg.MaxBackoff)
+
+	return delay - rand.N(delay/2+1)
+}
@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/pkg/webhook"
+)
+
+const testSecret = "whsec_test"
+
+// memoryWebhookRepository keeps one webhook and its deliveries in memory.
+type memoryWebhookRepository struct {
+	mu         sync.Mutex
+	webhook    model.Webhook
+	deliveries []*model.WebhookDelivery
+}
+
+func newMemoryWebhookRepository(url string) *memoryWebhookRepository {
+	return &memoryWebhookRepository{
+		webhook: model.Webhook{ID: uuid.New(), URL: url, Events: model.EventTypes, Secret: testSecret},
+	}
+}
+
+// add stores a pending delivery due at once.
+func (r *memoryWebhookRepository) add(payload string) uuid.UUID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d := &model.WebhookDelivery{
+		ID:            uuid.New(),
+		WebhookID:     r.webhook.ID,
+		EventID:       uuid.New(),
+		EventType:     model.EventCreated,
+		Payload:       []byte(payload),
+		Status:        model.DeliveryPending,
+		NextAttemptAt: time.Now().UTC(),
+	}
+	r.deliveries = append(r.deliveries, d)
+
+	return d.ID
+}
+
+// get returns a copy of the delivery.
+func (r *memoryWebhookRepository) get(id uuid.UUID) model.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range r.deliveries {
+		if d.ID == id {
+			return *d
+		}
+	}
+
+	return model.WebhookDelivery{}
+}
+
+// makeDue moves the next attempt of the delivery to now, as if its backoff had passed.
+func (r *memoryWebhookRepository) makeDue(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range r.deliveries {
+		if d.ID == id {
+			d.NextAttemptAt = time.Now().UTC()
+		}
+	}
+}
+
+func (r *memoryWebhookRepository) Create(context.Context, *model.Webhook) error {
+	return nil
+}
+
+func (r *memoryWebhookRepository) Read(_ context.Context, id uuid.UUID) (*model.Webhook, error) {
+	if id != r.webhook.ID {
+		return nil, fmt.Errorf("webhook with id %s %w", id, model.ErrNotFound)
+	}
+
+	w := r.webhook
+
+	return &w, nil
+}
+
+func (r *memoryWebhookRepository) List(context.Context) ([]model.Webhook, error) {
+	return []model.Webhook{r.webhook}, nil
+}
+
+func (r *memoryWebhookRepository) Delete(context.Context, uuid.UUID) error {
+	return nil
+}
+
+func (r *memoryWebhookRepository) Claim(_ context.Context, now, leaseUntil time.Time, limit int) ([]model.DeliveryTask, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tasks []model.DeliveryTask
+
+	for _, d := range r.deliveries {
+		if len(tasks) == limit {
+			break
+		}
+
+		if d.Status != model.DeliveryPending || d.NextAttemptAt.After(now) {
+			continue
+		}
+
+		d.NextAttemptAt = leaseUntil
+		tasks = append(tasks, model.DeliveryTask{Delivery: *d, URL: r.webhook.URL, Secret: r.webhook.Secret})
+	}
+
+	return tasks, nil
+}
+
+func (r *memoryWebhookRepository) RecordAttempt(_ context.Context, d model.WebhookDelivery, attempt model.DeliveryAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, stored := range r.deliveries {
+		if stored.ID == d.ID {
+			d.Log = append([]model.DeliveryAttempt{attempt}, stored.Log...)
+			r.deliveries[i] = &d
+		}
+	}
+
+	return nil
+}
+
+func (r *memoryWebhookRepository) Deliveries(_ context.Context, _ uuid.UUID, status model.DeliveryStatus, _ int) ([]model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deliveries []model.WebhookDelivery
+
+	for _, d := range r.deliveries {
+		if status == "" || d.Status == status {
+			deliveries = append(deliveries, *d)
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (r *memoryWebhookRepository) Redeliver(_ context.Context, _, deliveryID uuid.UUID, now time.Time) (*model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range r.deliveries {
+		if d.ID != deliveryID {
+			continue
+		}
+
+		if d.Status != model.DeliveryDead {
+			return nil, model.ErrDeliveryNotDead
+		}
+
+		d.Status = model.DeliveryPending
+		d.Attempts = 0
+		d.NextAttemptAt = now
+
+		redelivered := *d
+
+		return &redelivered, nil
+	}
+
+	return nil, fmt.Errorf("delivery with id %s %w", deliveryID, model.ErrNotFound)
+}
+
+func testWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		MaxAttempts:    3,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Hour,
+		Timeout:        time.Second,
+		Workers:        4,
+		PollInterval:   time.Second,
+	}
+}
+
+// newTestDispatcher returns a dispatcher sending to srv. The test server listens on
+// loopback, which the dispatcher refuses to dial, so its transport is replaced.
+func newTestDispatcher(repo *memoryWebhookRepository, cfg WebhookConfig, srv *httptest.Server) *webhookDispatcher {
+	d := NewWebhookDispatcher(repo, cfg, slog.New(slog.DiscardHandler)).(*webhookDispatcher)
+	d.client.Transport = srv.Client().Transport
+
+	return d
+}
+
+// statusServer answers every request with the status stored in status.
+func statusServer(t *testing.T, status *atomic.Int32, requests *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestWebhookDispatcherSignsDeliveries(t *testing.T) {
+	const payload = `{"id":"event"}`
+
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	repo := newMemoryWebhookRepository(srv.URL)
+	id := repo.add(payload)
+
+	newTestDispatcher(repo, testWebhookConfig(), srv).deliverDue(t.Context())
+
+	r, body := <-received, <-bodies
+
+	if string(body) != payload {
+		t.Errorf("body = %q, want %q", body, payload)
+	}
+
+	if got := r.Header.Get("X-Webhook-Id"); got != id.String() {
+		t.Errorf("X-Webhook-Id = %q, want %q", got, id)
+	}
+
+	if got := r.Header.Get("X-Webhook-Event"); got != string(model.EventCreated) {
+		t.Errorf("X-Webhook-Event = %q, want %q", got, model.EventCreated)
+	}
+
+	signature := r.Header.Get(webhook.SignatureHeader)
+
+	if err := webhook.Verify(testSecret, signature, body, time.Minute, time.Now()); err != nil {
+		t.Errorf("Verify with the webhook secret: %v", err)
+	}
+
+	if err := webhook.Verify("whsec_other", signature, body, time.Minute, time.Now()); err != webhook.ErrInvalidSignature {
+		t.Errorf("Verify with another secret = %v, want %v", err, webhook.ErrInvalidSignature)
+	}
+
+	d := repo.get(id)
+	if d.Status != model.DeliverySucceeded || d.Attempts != 1 || d.LastStatusCode != http.StatusOK {
+		t.Errorf("delivery status %s, attempts %d, last status %d; want succeeded after 1 attempt with 200", d.Status, d.Attempts, d.LastStatusCode)
+	}
+}
+
+func TestWebhookDispatcherRetriesFailedDeliveries(t *testing.T) {
+	var status, requests atomic.Int32
+
+	status.Store(http.StatusServiceUnavailable)
+
+	srv := statusServer(t, &status, &requests)
+	repo := newMemoryWebhookRepository(srv.URL)
+	id := repo.add("{}")
+	cfg := testWebhookConfig()
+
+	start := time.Now()
+
+	newTestDispatcher(repo, cfg, srv).deliverDue(t.Context())
+
+	d := repo.get(id)
+
+	if d.Status != model.DeliveryPending || d.Attempts != 1 {
+		t.Fatalf("delivery status %s after %d attempts, want pending after 1", d.Status, d.Attempts)
+	}
+
+	if d.LastStatusCode != http.StatusServiceUnavailable || d.LastError == "" {
+		t.Errorf("last status %d, error %q; want 503 with an error", d.LastStatusCode, d.LastError)
+	}
+
+	if earliest := start.Add(cfg.InitialBackoff / 2); d.NextAttemptAt.Before(earliest) || d.NextAttemptAt.After(time.Now().Add(cfg.InitialBackoff)) {
+		t.Errorf("next attempt at %s, want within the initial backoff of %s", d.NextAttemptAt, cfg.InitialBackoff)
+	}
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("webhook received %d requests, want 1 until the backoff passes", n)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	d := &webhookDispatcher{cfg: WebhookConfig{InitialBackoff: 10 * time.Second, MaxBackoff: time.Minute}}
+
+	for failures, want := range map[int]time.Duration{
+		1:  10 * time.Second,
+		2:  20 * time.Second,
+		3:  40 * time.Second,
+		4:  time.Minute,
+		10: time.Minute,
+		64: time.Minute,
+	} {
+		for range 100 {
+			if got := d.backoff(failures); got < want/2 || got > want {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", failures, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestWebhookDispatcherDeadLettersAndRedelivery(t *testing.T) {
+	var status, requests atomic.Int32
+
+	status.Store(http.StatusInternalServerError)
+
+	srv := statusServer(t, &status, &requests)
+	repo := newMemoryWebhookRepository(srv.URL)
+	id := repo.add("{}")
+	cfg := testWebhookConfig()
+	dispatcher := newTestDispatcher(repo, cfg, srv)
+
+	for range cfg.MaxAttempts {
+		repo.makeDue(id)
+		dispatcher.deliverDue(t.Context())
+	}
+
+	d := repo.get(id)
+
+	if d.Status != model.DeliveryDead || d.Attempts != cfg.MaxAttempts || len(d.Log) != cfg.MaxAttempts {
+		t.Fatalf("delivery status %s after %d attempts with %d logged, want dead after %d", d.Status, d.Attempts, len(d.Log), cfg.MaxAttempts)
+	}
+
+	// A dead delivery is no longer attempted.
+	repo.makeDue(id)
+	dispatcher.deliverDue(t.Context())
+
+	if n := requests.Load(); n != int32(cfg.MaxAttempts) {
+		t.Fatalf("webhook received %d requests, want %d", n, cfg.MaxAttempts)
+	}
+
+	webhooks := NewWebhookService(repo, slog.New(slog.DiscardHandler))
+
+	dead, err := webhooks.WebhookDeliveries(t.Context(), repo.webhook.ID, model.DeliveryDead, 10)
+	if err != nil || len(dead) != 1 || dead[0].ID != id {
+		t.Fatalf("dead letters = %v, %v; want the delivery", dead, err)
+	}
+
+	redelivered, err := webhooks.RedeliverWebhook(t.Context(), repo.webhook.ID, id)
+	if err != nil {
+		t.Fatalf("RedeliverWebhook: %v", err)
+	}
+
+	if redelivered.Status != model.DeliveryPending || redelivered.Attempts != 0 {
+		t.Errorf("redelivered status %s after %d attempts, want pending after 0", redelivered.Status, redelivered.Attempts)
+	}
+
+	status.Store(http.StatusNoContent)
+	dispatcher.deliverDue(t.Context())
+
+	d = repo.get(id)
+
+	if d.Status != model.DeliverySucceeded || d.Attempts != 1 || len(d.Log) != cfg.MaxAttempts+1 {
+		t.Errorf("delivery status %s after %d attempts with %d logged, want succeeded after 1 with %d logged", d.Status, d.Attempts, len(d.Log), cfg.MaxAttempts+1)
+	}
+}
+
+func TestWebhookDispatcherRefusesInternalAddresses(t *testing.T) {
+	var status, requests atomic.Int32
+
+	status.Store(http.StatusOK)
+
+	srv := statusServer(t, &status, &requests)
+	repo := newMemoryWebhookRepository(srv.URL)
+	id := repo.add("{}")
+
+	// The transport of the dispatcher is kept, so loopback is refused.
+	NewWebhookDispatcher(repo, testWebhookConfig(), slog.New(slog.DiscardHandler)).(*webhookDispatcher).deliverDue(t.Context())
+
+	if n := requests.Load(); n != 0 {
+		t.Errorf("webhook on loopback received %d requests, want 0", n)
+	}
+
+	if d := repo.get(id); d.LastError != errForbiddenAddress.Error() {
+		t.Errorf("last error = %q, want %q", d.LastError, errForbiddenAddress)
+	}
+}
+
+func TestWebhookDispatcherStoresGenericErrors(t *testing.T) {
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	repo := newMemoryWebhookRepository(srv.URL)
+	id := repo.add("{}")
+	cfg := testWebhookConfig()
+	cfg.Timeout = 50 * time.Millisecond
+
+	newTestDispatcher(repo, cfg, srv).deliverDue(t.Context())
+
+	if d := repo.get(id); d.LastError != errWebhookTimeout.Error() || d.LastStatusCode != 0 {
+		t.Errorf("last status %d, error %q; want no status and %q", d.LastStatusCode, d.LastError, errWebhookTimeout)
+	}
+}
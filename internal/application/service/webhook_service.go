@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/repository"
+)
+
+// maxWebhookURLLength bounds the length of a webhook URL.
+const maxWebhookURLLength = 2048
+
+type WebhookService interface {
+	// CreateWebhook checks the URL and event types of w, generates the secret signing
+	// its deliveries and stores it.
+	CreateWebhook(ctx context.Context, w *model.Webhook) error
+	ReadWebhook(ctx context.Context, id uuid.UUID) (*model.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]model.Webhook, error)
+	// DeleteWebhook removes the webhook with its pending deliveries and their log.
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	// WebhookDeliveries returns the delivery log of the webhook: its newest deliveries
+	// with every attempt made, optionally only those with the given status.
+	WebhookDeliveries(ctx context.Context, webhookID uuid.UUID, status model.DeliveryStatus, limit int) ([]model.WebhookDelivery, error)
+	// RedeliverWebhook moves a dead delivery of the webhook back to the queue, due at
+	// once and with the full number of attempts.
+	RedeliverWebhook(ctx context.Context, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	webhookRepo repository.WebhookRepository
+	logger      *slog.Logger
+}
+
+func NewWebhookService(webhookRepo repository.WebhookRepository, logger *slog.Logger) WebhookService {
+	return &webhookService{
+		webhookRepo: webhookRepo,
+		logger:      logger,
+	}
+}
+
+func (s *webhookService) CreateWebhook(ctx context.Context, w *model.Webhook) error {
+	s.logger.Debug("Creating webhook",
+		slog.String("url", w.URL),
+	)
+
+	if err := validateWebhook(w); err != nil {
+		return err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+
+	w.ID = uuid.New()
+	w.Secret = "whsec_" + base64.RawURLEncoding.EncodeToString(secret)
+	w.CreatedAt = time.Now().UTC()
+
+	if err := s.webhookRepo.Create(ctx, w); err != nil {
+		s.logger.Error("Failed to create webhook",
+			slog.String("url", w.URL),
+			slog.String("error", err.Error()),
+		)
+
+		return err
+	}
+
+	s.logger.Info("Webhook created successfully",
+		slog.String("webhook_id", w.ID.String()),
+	)
+
+	return nil
+}
+
+func (s *webhookService) ReadWebhook(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	w, err := s.webhookRepo.Read(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to fetch webhook",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	webhooks, err := s.webhookRepo.List(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list webhooks",
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	if err := s.webhookRepo.Delete(ctx, id); err != nil {
+		s.logger.Error("Failed to delete webhook",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()),
+		)
+
+		return err
+	}
+
+	s.logger.Info("Webhook deleted successfully",
+		slog.String("id", id.String()),
+	)
+
+	return nil
+}
+
+func (s *webhookService) WebhookDeliveries(ctx context.Context, webhookID uuid.UUID, status model.DeliveryStatus, limit int) ([]model.WebhookDelivery, error) {
+	if status != "" && !status.Valid() {
+		return nil, model.Invalid("status", "invalid status %q, expected pending, succeeded or dead", status)
+	}
+
+	if limit < 1 || limit > model.MaxPageLimit {
+		return nil, model.Invalid("limit", "limit must be between 1 and %d", model.MaxPageLimit)
+	}
+
+	// Tell an unknown webhook apart from one without deliveries.
+	if _, err := s.ReadWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.webhookRepo.Deliveries(ctx, webhookID, status, limit)
+	if err != nil {
+		s.logger.Error("Failed to list webhook deliveries",
+			slog.String("webhook_id", webhookID.String()),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (s *webhookService) RedeliverWebhook(ctx context.Context, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	d, err := s.webhookRepo.Redeliver(ctx, webhookID, deliveryID, time.Now().UTC())
+	if err != nil {
+		s.logger.Error("Failed to redeliver webhook delivery",
+			slog.String("webhook_id", webhookID.String()),
+			slog.String("delivery_id", deliveryID.String()),
+			slog.String("error", err.Error()),
+		)
+
+		return nil, err
+	}
+
+	s.logger.Info("Webhook delivery requeued",
+		slog.String("delivery_id", deliveryID.String()),
+	)
+
+	return d, nil
+}
+
+// validateWebhook checks the URL of w and its event types, dropping repeated types.
+func validateWebhook(w *model.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return model.Invalid("url", "url must be an absolute http or https URL")
+	}
+
+	if len(w.URL) > maxWebhookURLLength {
+		return model.Invalid("url", "url must be at most %d characters", maxWebhookURLLength)
+	}
+
+	if len(w.Events) == 0 {
+		return model.Invalid("events", "events must list at least one event type")
+	}
+
+	events := make([]model.EventType, 0, len(w.Events))
+
+	for _, e := range w.Events {
+		if !e.Valid() {
+			return model.Invalid("events", "unknown event type %q", e)
+		}
+
+		if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+
+	w.Events = events
+
+	return nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/goccy/go-yaml"
 	"github.com/joho/godotenv"
@@ -13,6 +14,10 @@ type Service struct {
 	Port string `yaml:"port"`
 }
 
+type GRPC struct {
+	Port string `yaml:"port"`
+}
+
 type Database struct {
 	Host         string `yaml:"host"`
 	Port         string `yaml:"port"`
@@ -25,9 +30,75 @@ type Database struct {
 	Password string `yaml:"-"`
 }
 
+type Insights struct {
+	PriceIncreaseWindowDays int     `yaml:"price_increase_window_days"`
+	AnnualPlanMinMonths     int     `yaml:"annual_plan_min_months"`
+	AnnualPlanDiscount      float64 `yaml:"annual_plan_discount"`
+}
+
+type Batch struct {
+	MaxSize int `yaml:"max_size"`
+}
+
+type Import struct {
+	MaxRows int `yaml:"max_rows"`
+}
+
+type Export struct {
+	DateFormat string `yaml:"date_format"`
+}
+
+type OpenAPI struct {
+	ValidateRequests bool `yaml:"validate_requests"`
+}
+
+type GraphQL struct {
+	MaxComplexity int `yaml:"max_complexity"`
+	MaxDepth      int `yaml:"max_depth"`
+}
+
+type Webhooks struct {
+	MaxAttempts           int `yaml:"max_attempts"`
+	InitialBackoffSeconds int `yaml:"initial_backoff_seconds"`
+	MaxBackoffSeconds     int `yaml:"max_backoff_seconds"`
+	TimeoutSeconds        int `yaml:"timeout_seconds"`
+	Workers               int `yaml:"workers"`
+	PollIntervalSeconds   int `yaml:"poll_interval_seconds"`
+}
+
+type Stream struct {
+	ReplaySize       int `yaml:"replay_size"`
+	ClientBuffer     int `yaml:"client_buffer"`
+	HeartbeatSeconds int `yaml:"heartbeat_seconds"`
+	QueueSize        int `yaml:"queue_size"`
+}
+
+type Idempotency struct {
+	TTLHours     int `yaml:"ttl_hours"`
+	LeaseSeconds int `yaml:"lease_seconds"`
+}
+
+type API struct {
+	LegacyRoutes       bool   `yaml:"legacy_routes"`
+	LegacyDeprecatedAt string `yaml:"legacy_deprecated_at"`
+	LegacySunset       string `yaml:"legacy_sunset"`
+}
+
 type Config struct {
-	Service  Service  `yaml:"service"`
-	Database Database `yaml:"database"`
+	Env         string      `yaml:"env"`
+	Service     Service     `yaml:"service"`
+	GRPC        GRPC        `yaml:"grpc"`
+	Database    Database    `yaml:"database"`
+	Insights    Insights    `yaml:"insights"`
+	Batch       Batch       `yaml:"batch"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	API         API         `yaml:"api"`
+	Import      Import      `yaml:"import"`
+	Export      Export      `yaml:"export"`
+	OpenAPI     OpenAPI     `yaml:"openapi"`
+	GraphQL     GraphQL     `yaml:"graphql"`
+	Webhooks    Webhooks    `yaml:"webhooks"`
+	Stream      Stream      `yaml:"stream"`
 }
 
 func (d *Database) GetDSN() string {
@@ -37,6 +108,52 @@ func (d *Database) GetDSN() string {
 	)
 }
 
+// LegacyDates parses the YYYY-MM-DD deprecation and sunset dates of the legacy
+// routes. Empty dates are returned as zero times.
+func (a *API) LegacyDates() (deprecatedAt, sunset time.Time, err error) {
+	if a.LegacyDeprecatedAt != "" {
+		if deprecatedAt, err = time.Parse("2006-01-02", a.LegacyDeprecatedAt); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid api.legacy_deprecated_at %q, expected YYYY-MM-DD", a.LegacyDeprecatedAt)
+		}
+	}
+
+	if a.LegacySunset != "" {
+		if sunset, err = time.Parse("2006-01-02", a.LegacySunset); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid api.legacy_sunset %q, expected YYYY-MM-DD", a.LegacySunset)
+		}
+	}
+
+	return deprecatedAt, sunset, nil
+}
+
+// validate checks the settings the service cannot run correctly without: the intervals
+// of its tickers, the number of webhook workers, the sizes of the stream buffers and
+// the lifetimes of the idempotency keys.
+func (c *Config) validate() error {
+	for _, s := range []struct {
+		name  string
+		value int
+		min   int
+	}{
+		{"stream.heartbeat_seconds", c.Stream.HeartbeatSeconds, 1},
+		// An unbuffered client channel would drop every client not waiting on it.
+		{"stream.client_buffer", c.Stream.ClientBuffer, 1},
+		{"stream.queue_size", c.Stream.QueueSize, 1},
+		{"stream.replay_size", c.Stream.ReplaySize, 0},
+		{"webhooks.poll_interval_seconds", c.Webhooks.PollIntervalSeconds, 1},
+		{"webhooks.workers", c.Webhooks.Workers, 1},
+		// A key claimed without a lease could be taken over by a concurrent retry at once.
+		{"idempotency.lease_seconds", c.Idempotency.LeaseSeconds, 1},
+		{"idempotency.ttl_hours", c.Idempotency.TTLHours, 1},
+	} {
+		if s.value < s.min {
+			return fmt.Errorf("%s must be at least %d, got %d", s.name, s.min, s.value)
+		}
+	}
+
+	return nil
+}
+
 func LoadConfig(configPath, envPath string) (*Config, error) {
 	if err := godotenv.Load(envPath); err != nil {
 		return nil, fmt.Errorf("failed to load env file %s: %s", envPath, err.Error())
@@ -54,6 +171,10 @@ func LoadConfig(configPath, envPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse YAML config %s: %s", configPath, err.Error())
 	}
 
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %s", configPath, err.Error())
+	}
+
 	config.Database.User = os.Getenv("DB_USER")
 	config.Database.Password = os.Getenv("DB_PASSWORD")
 
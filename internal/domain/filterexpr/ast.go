@@ -0,0 +1,87 @@
+// Package filterexpr implements the filter expression language of the subscription
+// list, e.g.
+//
+//	price > 300 and (service_name in ("Netflix", "Okko") or end_date is null)
+//
+// Expressions are parsed into an AST whose fields are checked against an allowlist
+// and whose literals are converted to the types of the fields they are compared with.
+package filterexpr
+
+// Node is a node of a parsed expression: And, Or, Not, Compare, In or IsNull.
+type Node interface {
+	node()
+}
+
+type And struct {
+	Left, Right Node
+}
+
+type Or struct {
+	Left, Right Node
+}
+
+type Not struct {
+	Expr Node
+}
+
+// Compare compares a field with a value using one of =, !=, <, <=, >, >=, like or ilike.
+type Compare struct {
+	Field string
+	Op    string
+	Value interface{}
+}
+
+type In struct {
+	Field   string
+	Values  []interface{}
+	Negated bool
+}
+
+type IsNull struct {
+	Field   string
+	Negated bool
+}
+
+func (And) node()     {}
+func (Or) node()      {}
+func (Not) node()     {}
+func (Compare) node() {}
+func (In) node()      {}
+func (IsNull) node()  {}
+
+// Fields returns the distinct fields referenced by n in order of appearance.
+func Fields(n Node) []string {
+	var fields []string
+
+	seen := map[string]bool{}
+	add := func(f string) {
+		if !seen[f] {
+			seen[f] = true
+			fields = append(fields, f)
+		}
+	}
+
+	var walk func(n Node)
+	walk = func(n Node) {
+		switch n := n.(type) {
+		case And:
+			walk(n.Left)
+			walk(n.Right)
+		case Or:
+			walk(n.Left)
+			walk(n.Right)
+		case Not:
+			walk(n.Expr)
+		case Compare:
+			add(n.Field)
+		case In:
+			add(n.Field)
+		case IsNull:
+			add(n.Field)
+		}
+	}
+
+	walk(n)
+
+	return fields
+}
@@ -0,0 +1,104 @@
+package filterexpr
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Type int
+
+const (
+	TypeText Type = iota
+	TypeInt
+	TypeUUID
+	TypeDate
+	TypeTimestamp
+)
+
+type Field struct {
+	Type     Type
+	Nullable bool
+}
+
+// SubscriptionFields is the allowlist of fields expressions over subscriptions may use.
+var SubscriptionFields = map[string]Field{
+	"id":           {Type: TypeUUID},
+	"service_name": {Type: TypeText},
+	"price":        {Type: TypeInt},
+	"user_id":      {Type: TypeUUID},
+	"start_date":   {Type: TypeDate},
+	"end_date":     {Type: TypeDate, Nullable: true},
+	"created_at":   {Type: TypeTimestamp},
+	"updated_at":   {Type: TypeTimestamp},
+}
+
+func (f Field) allows(op string) bool {
+	switch op {
+	case "=", "!=", "in":
+		return true
+	case "<", "<=", ">", ">=":
+		return f.Type != TypeUUID
+	case "like", "ilike":
+		return f.Type == TypeText
+	}
+
+	return false
+}
+
+// convert turns a literal token into the Go value of the field type.
+func (f Field) convert(lit token) (interface{}, error) {
+	if lit.kind == tokenEOF {
+		return nil, fmt.Errorf("unexpected end of filter expression, expected a value")
+	}
+
+	switch f.Type {
+	case TypeInt:
+		if lit.kind != tokenNumber {
+			return nil, fmt.Errorf("expected a number at position %d", lit.pos)
+		}
+
+		v, err := strconv.ParseInt(lit.text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %s at position %d", lit.text, lit.pos)
+		}
+
+		return v, nil
+	}
+
+	if lit.kind != tokenString {
+		return nil, fmt.Errorf("expected a quoted string at position %d", lit.pos)
+	}
+
+	switch f.Type {
+	case TypeUUID:
+		v, err := uuid.Parse(lit.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UUID %q at position %d", lit.text, lit.pos)
+		}
+
+		return v, nil
+	case TypeDate:
+		v, err := time.Parse("2006-01-02", lit.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q at position %d, expected YYYY-MM-DD", lit.text, lit.pos)
+		}
+
+		return v, nil
+	case TypeTimestamp:
+		if v, err := time.Parse(time.RFC3339, lit.text); err == nil {
+			return v, nil
+		}
+
+		v, err := time.Parse("2006-01-02", lit.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q at position %d, expected RFC 3339 or YYYY-MM-DD", lit.text, lit.pos)
+		}
+
+		return v, nil
+	}
+
+	return lit.text, nil
+}
@@ -0,0 +1,119 @@
+package filterexpr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// pos is the 1-based position of the token in the expression.
+	pos int
+}
+
+// is reports whether the token is the given keyword, ignoring case.
+func (t token) is(keyword string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: pos})
+			i++
+		case r == '=':
+			tokens = append(tokens, token{kind: tokenOp, text: "=", pos: pos})
+			i++
+		case r == '!' || r == '<' || r == '>':
+			op := string(r)
+			if i+1 < len(runes) && (runes[i+1] == '=' || (r == '<' && runes[i+1] == '>')) {
+				op += string(runes[i+1])
+			}
+
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected '!' at position %d", pos)
+			}
+
+			i += len(op)
+
+			if op == "<>" {
+				op = "!="
+			}
+
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: pos})
+		case r == '"' || r == '\'':
+			var sb strings.Builder
+
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+
+				sb.WriteRune(runes[j])
+			}
+
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string starting at position %d", pos)
+			}
+
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: pos})
+			i = j + 1
+		case r == '-' || unicode.IsDigit(r):
+			j := i + 1
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+
+			if r == '-' && j == i+1 {
+				return nil, fmt.Errorf("unexpected '-' at position %d", pos)
+			}
+
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[i:j]), pos: pos})
+			i = j
+		case r == '_' || unicode.IsLetter(r):
+			j := i + 1
+			for j < len(runes) && (runes[j] == '_' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[i:j]), pos: pos})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, pos)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
@@ -0,0 +1,272 @@
+package filterexpr
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxLength bounds the length of an expression in bytes.
+	MaxLength = 2000
+	// MaxDepth bounds the nesting of parentheses and not operators.
+	MaxDepth = 16
+	// MaxInValues bounds the number of values in an in list.
+	MaxInValues = 100
+)
+
+// Parse parses an expression over the fields of SubscriptionFields.
+//
+// The grammar, with keywords matched case-insensitively:
+//
+//	expr    = and {"or" and}
+//	and     = unary {"and" unary}
+//	unary   = "not" unary | "(" expr ")" | cond
+//	cond    = field op literal
+//	        | field ["not"] "in" "(" literal {"," literal} ")"
+//	        | field "is" ["not"] "null"
+//	op      = "=" | "!=" | "<>" | "<" | "<=" | ">" | ">=" | "like" | "ilike"
+//	literal = number | "double quoted" | 'single quoted'
+func Parse(input string) (Node, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, fmt.Errorf("filter expression is empty")
+	}
+
+	if len(input) > MaxLength {
+		return nil, fmt.Errorf("filter expression is longer than %d characters", MaxLength)
+	}
+
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+	}
+
+	return n, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, unexpected(t, what)
+	}
+
+	return t, nil
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if t := p.next(); !t.is(keyword) {
+		return unexpected(t, keyword)
+	}
+
+	return nil
+}
+
+func (p *parser) expr() (Node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().is("or") {
+		p.next()
+
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+
+		left = Or{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) and() (Node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().is("and") {
+		p.next()
+
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = And{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) unary() (Node, error) {
+	t := p.peek()
+
+	if t.is("not") || t.kind == tokenLParen {
+		p.depth++
+		defer func() { p.depth-- }()
+
+		if p.depth > MaxDepth {
+			return nil, fmt.Errorf("filter expression is nested deeper than %d levels", MaxDepth)
+		}
+	}
+
+	if t.is("not") {
+		p.next()
+
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return Not{Expr: n}, nil
+	}
+
+	if t.kind == tokenLParen {
+		p.next()
+
+		n, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(tokenRParen, "')'"); err != nil {
+			return nil, err
+		}
+
+		return n, nil
+	}
+
+	return p.cond()
+}
+
+func (p *parser) cond() (Node, error) {
+	t, err := p.expect(tokenIdent, "a field")
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.ToLower(t.text)
+
+	field, ok := SubscriptionFields[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q at position %d", t.text, t.pos)
+	}
+
+	op := p.next()
+
+	switch {
+	case op.is("is"):
+		negated := false
+		if p.peek().is("not") {
+			p.next()
+			negated = true
+		}
+
+		if err := p.expectKeyword("null"); err != nil {
+			return nil, err
+		}
+
+		if !field.Nullable {
+			return nil, fmt.Errorf("field %s is never null (position %d)", name, t.pos)
+		}
+
+		return IsNull{Field: name, Negated: negated}, nil
+	case op.is("in"), op.is("not"):
+		negated := op.is("not")
+		if negated {
+			if err := p.expectKeyword("in"); err != nil {
+				return nil, err
+			}
+		}
+
+		values, err := p.list(field)
+		if err != nil {
+			return nil, err
+		}
+
+		return In{Field: name, Values: values, Negated: negated}, nil
+	case op.kind == tokenOp, op.is("like"), op.is("ilike"):
+		o := strings.ToLower(op.text)
+		if !field.allows(o) {
+			return nil, fmt.Errorf("operator %s is not supported for field %s (position %d)", o, name, op.pos)
+		}
+
+		v, err := field.convert(p.next())
+		if err != nil {
+			return nil, err
+		}
+
+		return Compare{Field: name, Op: o, Value: v}, nil
+	}
+
+	return nil, unexpected(op, "an operator")
+}
+
+func (p *parser) list(field Field) ([]interface{}, error) {
+	if _, err := p.expect(tokenLParen, "'('"); err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+
+	for {
+		v, err := field.convert(p.next())
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, v)
+		if len(values) > MaxInValues {
+			return nil, fmt.Errorf("in list has more than %d values", MaxInValues)
+		}
+
+		t := p.next()
+		if t.kind == tokenRParen {
+			return values, nil
+		}
+
+		if t.kind != tokenComma {
+			return nil, unexpected(t, "',' or ')'")
+		}
+	}
+}
+
+func unexpected(t token, want string) error {
+	if t.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of filter expression, expected %s", want)
+	}
+
+	return fmt.Errorf("unexpected %q at position %d, expected %s", t.text, t.pos, want)
+}
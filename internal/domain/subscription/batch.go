@@ -0,0 +1,41 @@
+package models
+
+import "errors"
+
+// ErrBatchTooLarge reports a batch with more operations than allowed.
+var ErrBatchTooLarge = errors.New("batch is too large")
+
+type BatchOperationKind string
+
+const (
+	BatchCreate BatchOperationKind = "create"
+	BatchUpdate BatchOperationKind = "update"
+	BatchDelete BatchOperationKind = "delete"
+)
+
+// BatchOperation is one operation of a batch. Creates and updates carry the full new
+// state of the subscription, deletes only its ID.
+type BatchOperation struct {
+	Kind         BatchOperationKind
+	Subscription Subscription
+}
+
+// BatchResult is the outcome of a batch operation. Subscription is the stored state
+// after a create or update and the last state before a delete; Events are the events
+// of the change, reported once it is committed.
+type BatchResult struct {
+	Subscription Subscription
+	Events       []Event
+	Applied      bool
+	Err          error
+}
+
+// RolledBack marks every result as not applied, keeping the errors that caused the
+// rollback.
+func RolledBack(results []BatchResult) []BatchResult {
+	for i := range results {
+		results[i].Applied = false
+	}
+
+	return results
+}
@@ -0,0 +1,7 @@
+package models
+
+import "errors"
+
+// ErrInvalidCalendarToken reports a calendar feed request whose token is missing, was
+// never issued or has been replaced by a newer one.
+var ErrInvalidCalendarToken = errors.New("invalid calendar token")
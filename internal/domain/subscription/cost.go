@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// CostLine is the share of one subscription in the cost of a period: the months of the
+// period it is billed for and its price over those months.
+type CostLine struct {
+	Subscription
+	Months int
+	Cost   int64
+}
+
+// MonthCost returns what s is billed for the calendar month containing t: its price if
+// it is active on any day of that month, as the cost of a period counts it.
+func (s Subscription) MonthCost(t time.Time) int64 {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+
+	if s.StartDate.After(last) || (!s.EndDate.IsZero() && s.EndDate.Before(first)) {
+		return 0
+	}
+
+	return int64(s.Price)
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is wrapped by the errors of lookups that found nothing.
+var ErrNotFound = errors.New("not found")
+
+// ValidationError reports input breaking a domain rule. Field names the offending
+// request field, or is empty when the rule involves several fields.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Invalid returns a ValidationError for field with a formatted message.
+func Invalid(field, format string, args ...interface{}) error {
+	return &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	EventCreated EventType = "subscription.created"
+	EventUpdated EventType = "subscription.updated"
+	// EventEnded follows an update that gave the subscription an end date or moved it
+	// earlier.
+	EventEnded EventType = "subscription.ended"
+	// EventRenewed follows an update that moved the end date of the subscription later
+	// or removed it.
+	EventRenewed EventType = "subscription.renewed"
+	EventDeleted EventType = "subscription.deleted"
+)
+
+// EventTypes lists every event type.
+var EventTypes = []EventType{EventCreated, EventUpdated, EventEnded, EventRenewed, EventDeleted}
+
+func (t EventType) Valid() bool {
+	return slices.Contains(EventTypes, t)
+}
+
+// Event reports a committed change of a subscription. Subscription is its state after
+// the change, or its last state before a delete; Previous is its state before an
+// update.
+type Event struct {
+	ID           uuid.UUID
+	Type         EventType
+	OccurredAt   time.Time
+	Subscription Subscription
+	Previous     *Subscription
+}
+
+func CreatedEvent(s Subscription) Event {
+	return Event{ID: uuid.New(), Type: EventCreated, OccurredAt: s.CreatedAt, Subscription: s}
+}
+
+// UpdatedEvents returns the events of an update from previous to current: an updated
+// event, followed by an ended or renewed one when the end date moved. A missing end
+// date counts as later than any date.
+func UpdatedEvents(previous, current Subscription) []Event {
+	event := func(t EventType) Event {
+		return Event{ID: uuid.New(), Type: t, OccurredAt: current.UpdatedAt, Subscription: current, Previous: &previous}
+	}
+
+	events := []Event{event(EventUpdated)}
+
+	switch {
+	case endsBefore(current.EndDate, previous.EndDate):
+		events = append(events, event(EventEnded))
+	case endsBefore(previous.EndDate, current.EndDate):
+		events = append(events, event(EventRenewed))
+	}
+
+	return events
+}
+
+func DeletedEvent(s Subscription, at time.Time) Event {
+	return Event{ID: uuid.New(), Type: EventDeleted, OccurredAt: at, Subscription: s}
+}
+
+// endsBefore reports whether the end date a is earlier than b, zero dates being open
+// ends.
+func endsBefore(a, b time.Time) bool {
+	if a.IsZero() {
+		return false
+	}
+
+	return b.IsZero() || a.Before(b)
+}
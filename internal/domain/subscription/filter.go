@@ -0,0 +1,143 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"Subscription_Service/internal/domain/filterexpr"
+)
+
+type MatchMode string
+
+const (
+	MatchExact    MatchMode = "exact"
+	MatchPrefix   MatchMode = "prefix"
+	MatchContains MatchMode = "contains"
+	MatchFuzzy    MatchMode = "fuzzy"
+)
+
+const DefaultSimilarityThreshold = 0.3
+
+// ServiceNameFilter matches subscriptions whose service name matches any of Names.
+// Exact, prefix and contains matching ignore case; fuzzy matching compares trigram
+// similarity against Threshold. A filter without names matches everything.
+type ServiceNameFilter struct {
+	Names     []string
+	Match     MatchMode
+	Threshold float64
+}
+
+func (f ServiceNameFilter) IsEmpty() bool {
+	return len(f.Names) == 0
+}
+
+func (f ServiceNameFilter) Validate() error {
+	switch f.Match {
+	case MatchExact, MatchPrefix, MatchContains, MatchFuzzy:
+	default:
+		return Invalid("match", "unknown match mode %q", f.Match)
+	}
+
+	if f.Threshold < 0 || f.Threshold > 1 {
+		return Invalid("threshold", "similarity threshold must be between 0 and 1")
+	}
+
+	return nil
+}
+
+// SubscriptionFilter selects and orders subscriptions. Unset fields do not restrict the
+// result; date ranges are inclusive.
+type SubscriptionFilter struct {
+	UserID *uuid.UUID
+	// UserIDs keeps the subscriptions of any of the given users.
+	UserIDs     []uuid.UUID
+	ServiceName ServiceNameFilter
+	PriceMin    *int
+	PriceMax    *int
+	StartFrom   *time.Time
+	StartTo     *time.Time
+	EndFrom     *time.Time
+	EndTo       *time.Time
+	// ActiveAt keeps the subscriptions running on the given day.
+	ActiveAt *time.Time
+	// OpenEnded keeps the subscriptions without an end date.
+	OpenEnded bool
+	// Expr is a parsed filter expression the subscriptions must also satisfy.
+	Expr filterexpr.Node
+	// Sort orders the result, newest first when empty. Ties are broken by id.
+	Sort []SortField
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// SortableFields is the allowlist of fields subscriptions can be sorted by.
+var SortableFields = []string{"service_name", "price", "start_date", "end_date", "created_at", "updated_at"}
+
+var DefaultSort = []SortField{{Field: "created_at", Desc: true}}
+
+func (f SubscriptionFilter) Validate() error {
+	if err := f.ServiceName.Validate(); err != nil {
+		return err
+	}
+
+	if f.PriceMin != nil && f.PriceMax != nil && *f.PriceMin > *f.PriceMax {
+		return Invalid("price_min", "price_min cannot be greater than price_max")
+	}
+
+	if f.StartFrom != nil && f.StartTo != nil && f.StartTo.Before(*f.StartFrom) {
+		return Invalid("start_to", "start_to cannot be before start_from")
+	}
+
+	if f.EndFrom != nil && f.EndTo != nil && f.EndTo.Before(*f.EndFrom) {
+		return Invalid("end_to", "end_to cannot be before end_from")
+	}
+
+	if f.OpenEnded && (f.EndFrom != nil || f.EndTo != nil) {
+		return Invalid("open_ended", "open_ended cannot be combined with an end date range")
+	}
+
+	seen := make(map[string]bool, len(f.Sort))
+
+	for _, s := range f.Sort {
+		if !slices.Contains(SortableFields, s.Field) {
+			return Invalid("sort", "cannot sort by %q, expected one of %s", s.Field, strings.Join(SortableFields, ", "))
+		}
+
+		if seen[s.Field] {
+			return Invalid("sort", "duplicate sort field %q", s.Field)
+		}
+
+		seen[s.Field] = true
+	}
+
+	return nil
+}
+
+// SortOrDefault returns the requested sort order or the default one.
+func (f SubscriptionFilter) SortOrDefault() []SortField {
+	if len(f.Sort) == 0 {
+		return DefaultSort
+	}
+
+	return f.Sort
+}
+
+// SortKey renders a sort order canonically, e.g. "price,-created_at".
+func SortKey(sort []SortField) string {
+	parts := make([]string, 0, len(sort))
+	for _, s := range sort {
+		if s.Desc {
+			parts = append(parts, "-"+s.Field)
+		} else {
+			parts = append(parts, s.Field)
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,32 @@
+package models
+
+import "errors"
+
+var (
+	// ErrIdempotencyKeyReused reports a key sent again with a different request.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used with a different request")
+	// ErrIdempotencyKeyInProgress reports a key whose first request has not completed yet.
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is in progress")
+)
+
+// IdempotencyKey identifies a request by the client-chosen key and the endpoint it was
+// sent to.
+type IdempotencyKey struct {
+	Key    string
+	Method string
+	Path   string
+}
+
+// IdempotentResponse is the stored response to the first request with a key.
+type IdempotentResponse struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyRecord is what is stored for a key: the hash of the first request and its
+// response, which is nil while that request is still being processed.
+type IdempotencyRecord struct {
+	RequestHash string
+	Response    *IdempotentResponse
+}
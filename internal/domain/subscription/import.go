@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrImportTooLarge reports an import with more rows than allowed.
+var ErrImportTooLarge = errors.New("import is too large")
+
+type ImportStatus string
+
+const (
+	// ImportCreate marks a valid new row: created on commit, to be created on a dry run.
+	ImportCreate    ImportStatus = "create"
+	ImportDuplicate ImportStatus = "duplicate"
+	ImportRejected  ImportStatus = "rejected"
+)
+
+// ImportRow is a parsed row of an import. Err is set when the row could not be
+// parsed into a subscription.
+type ImportRow struct {
+	Line         int
+	Subscription Subscription
+	Err          error
+}
+
+type ImportRowResult struct {
+	Line         int
+	Status       ImportStatus
+	Subscription Subscription
+	Err          error
+}
+
+// ImportReport is the outcome of an import. Committed is set when the rows with the
+// ImportCreate status were stored.
+type ImportReport struct {
+	Rows      []ImportRowResult
+	Committed bool
+}
+
+// DuplicateKey identifies subscriptions considered the same when importing: the same
+// user subscribed to the same service, ignoring case, from the same day.
+type DuplicateKey struct {
+	UserID      string `db:"user_id"`
+	ServiceName string `db:"service_name"`
+	StartDate   string `db:"start_date"`
+}
+
+func (s Subscription) DuplicateKey() DuplicateKey {
+	return DuplicateKey{
+		UserID:      s.UserID.String(),
+		ServiceName: strings.ToLower(s.ServiceName),
+		StartDate:   s.StartDate.Format("2006-01-02"),
+	}
+}
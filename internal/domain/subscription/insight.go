@@ -0,0 +1,46 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+type InsightKind string
+
+const (
+	InsightDuplicateService InsightKind = "duplicate_service"
+	InsightPriceIncrease    InsightKind = "price_increase"
+	InsightAnnualPlan       InsightKind = "annual_plan"
+)
+
+// Insight is a finding about a user's subscriptions together with the monthly amount
+// the user is estimated to save by acting on it. Savings splits MonthlySaving between
+// the subscriptions it comes from.
+type Insight struct {
+	Kind            InsightKind
+	ServiceName     string
+	SubscriptionIDs []uuid.UUID
+	Message         string
+	MonthlySaving   int
+	Savings         map[uuid.UUID]int
+}
+
+// TotalMonthlySaving is the monthly amount the user saves by acting on the insights.
+// Insights about the same subscription are alternatives, such as cancelling a
+// duplicate or moving it to an annual plan, so only the largest saving of each
+// subscription counts.
+func TotalMonthlySaving(insights []Insight) int {
+	best := make(map[uuid.UUID]int)
+
+	for _, i := range insights {
+		for id, saving := range i.Savings {
+			best[id] = max(best[id], saving)
+		}
+	}
+
+	total := 0
+	for _, saving := range best {
+		total += saving
+	}
+
+	return total
+}
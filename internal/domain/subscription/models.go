@@ -15,4 +15,13 @@ type Subscription struct {
 	EndDate     time.Time `db:"end_date" json:"end_date,omitempty"`
 	CreatedAt   time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	Version     int       `db:"version" json:"version"`
+}
+
+// Change identifies the state of all subscriptions. Seq grows with every write, in
+// commit order, and At is when the last write was made; a zero Seq means that nothing
+// was written yet.
+type Change struct {
+	Seq int64
+	At  time.Time
 }
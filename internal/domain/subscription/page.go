@@ -0,0 +1,66 @@
+package models
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+// Cursor is a position in a sorted subscription list: the values of the sort fields of
+// the last subscription seen, in sort order, followed by its id.
+type Cursor struct {
+	Values []string
+	ID     uuid.UUID
+}
+
+// SubscriptionFields are the fields a page can be restricted to.
+var SubscriptionFields = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "created_at", "updated_at", "version"}
+
+// PageRequest selects a page either by Offset or, when After is set, by keyset
+// continuing right after the given position.
+type PageRequest struct {
+	Limit      int
+	Offset     int
+	After      *Cursor
+	CountTotal bool
+	// Fields restricts the fields read to the given ones and those the page needs
+	// itself, the ID and the sort fields; the others are left zero. Empty reads all.
+	Fields []string
+}
+
+// SubscriptionPage is a page of subscriptions. Next is set when more subscriptions
+// follow, Total when it was requested.
+type SubscriptionPage struct {
+	Subscriptions []Subscription
+	Next          *Cursor
+	Total         *int64
+}
+
+func (p PageRequest) Validate() error {
+	if p.Limit < 0 || p.Limit > MaxPageLimit {
+		return Invalid("limit", "limit must be between 1 and %d", MaxPageLimit)
+	}
+
+	if p.Offset < 0 {
+		return Invalid("offset", "offset cannot be negative")
+	}
+
+	return ValidateFields(p.Fields)
+}
+
+// ValidateFields checks that fields are all SubscriptionFields.
+func ValidateFields(fields []string) error {
+	for _, f := range fields {
+		if !slices.Contains(SubscriptionFields, f) {
+			return Invalid("fields", "unknown field %q, expected one of %s", f, strings.Join(SubscriptionFields, ", "))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	MaxSearchLength    = 100
+)
+
+// SearchQuery ranks subscriptions by how closely a word run of their service name
+// matches Text or its transliteration, keeping those scoring at least Threshold.
+type SearchQuery struct {
+	Text      string
+	UserID    *uuid.UUID
+	Threshold float64
+	Limit     int
+	Offset    int
+}
+
+// SearchHit is a subscription found by a search with its similarity score, from 0 to 1.
+type SearchHit struct {
+	Subscription
+	Score float64
+}
+
+func (q SearchQuery) Validate() error {
+	text := strings.TrimSpace(q.Text)
+	if text == "" {
+		return Invalid("q", "q is required")
+	}
+
+	if utf8.RuneCountInString(text) > MaxSearchLength {
+		return Invalid("q", "q must be at most %d characters", MaxSearchLength)
+	}
+
+	if q.Threshold < 0 || q.Threshold > 1 {
+		return Invalid("threshold", "similarity threshold must be between 0 and 1")
+	}
+
+	if q.Limit < 1 || q.Limit > MaxSearchLimit {
+		return Invalid("limit", "limit must be between 1 and %d", MaxSearchLimit)
+	}
+
+	if q.Offset < 0 {
+		return Invalid("offset", "offset cannot be negative")
+	}
+
+	return nil
+}
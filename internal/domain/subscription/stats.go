@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+type Stats struct {
+	PeriodStart         time.Time
+	PeriodEnd           time.Time
+	ActiveSubscriptions int64
+	DistinctUsers       int64
+	TopBySubscribers    []ServiceStats
+	TopByRevenue        []ServiceStats
+	Services            []ServiceStats
+	Monthly             []MonthlyStats
+}
+
+type ServiceStats struct {
+	ServiceName  string  `db:"service_name"`
+	Subscribers  int64   `db:"subscribers"`
+	Revenue      int64   `db:"revenue"`
+	AveragePrice float64 `db:"average_price"`
+	MedianPrice  float64 `db:"median_price"`
+}
+
+type MonthlyStats struct {
+	Month time.Time `db:"month"`
+	New   int64     `db:"new"`
+	Ended int64     `db:"ended"`
+}
+
+type CohortReport struct {
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Cohorts     []Cohort
+	Lifetimes   []ServiceLifetime
+	Churn       []MonthlyChurn
+}
+
+// Cohort groups the subscriptions started in Month. Retention[k] is the fraction of
+// them still active k months after the start month.
+type Cohort struct {
+	Month     time.Time
+	Size      int64
+	Retention []float64
+}
+
+type ServiceLifetime struct {
+	ServiceName           string  `db:"service_name"`
+	Subscriptions         int64   `db:"subscriptions"`
+	Ended                 int64   `db:"ended"`
+	AverageLifetimeMonths float64 `db:"average_lifetime_months"`
+}
+
+type MonthlyChurn struct {
+	Month     time.Time `db:"month"`
+	Active    int64     `db:"active"`
+	Churned   int64     `db:"churned"`
+	ChurnRate float64   `db:"churn_rate"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrDeliveryNotDead reports a redelivery requested for a delivery that has not
+// exhausted its attempts.
+var ErrDeliveryNotDead = errors.New("only dead deliveries can be redelivered")
+
+// Webhook is an endpoint receiving the events of the given types. Secret signs the
+// deliveries; it is only known to the service and the receiver.
+type Webhook struct {
+	ID        uuid.UUID
+	URL       string
+	Events    []EventType
+	Secret    string
+	CreatedAt time.Time
+}
+
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are attempted at NextAttemptAt.
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryDead deliveries have failed every attempt and wait in the dead-letter list
+	// until they are redelivered.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+func (s DeliveryStatus) Valid() bool {
+	return s == DeliveryPending || s == DeliverySucceeded || s == DeliveryDead
+}
+
+// WebhookDelivery is an event to be sent to a webhook, with the outcome of its last
+// attempt. Attempts counts the attempts since it was enqueued or last redelivered;
+// Log holds every attempt, newest first, when it is read for the delivery log.
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	WebhookID      uuid.UUID
+	EventID        uuid.UUID
+	EventType      EventType
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    time.Time
+	Log            []DeliveryAttempt
+}
+
+// DeliveryAttempt is one request sent for a delivery. StatusCode is zero when no
+// response was received.
+type DeliveryAttempt struct {
+	StatusCode  int
+	Error       string
+	Duration    time.Duration
+	AttemptedAt time.Time
+}
+
+// DeliveryTask is a delivery claimed for an attempt with the target it is sent to.
+type DeliveryTask struct {
+	Delivery WebhookDelivery
+	URL      string
+	Secret   string
+}
@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+type cursorPayload struct {
+	Sort   string    `json:"s"`
+	Values []string  `json:"v"`
+	ID     uuid.UUID `json:"i"`
+}
+
+// EncodeCursor renders a list position as an opaque URL-safe token bound to the sort
+// order it was taken in.
+func EncodeCursor(c model.Cursor, sort string) string {
+	b, _ := json.Marshal(cursorPayload{Sort: sort, Values: c.Values, ID: c.ID})
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s, sort string) (*model.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var p cursorPayload
+	if err := json.Unmarshal(b, &p); err != nil || p.ID == uuid.Nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	if p.Sort != sort {
+		return nil, errors.New("cursor was issued for a different sort order")
+	}
+
+	return &model.Cursor{Values: p.Values, ID: p.ID}, nil
+}
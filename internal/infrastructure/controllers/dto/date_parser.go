@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -33,3 +35,23 @@ func (ct *CustomTime) UnmarshalJSON(b []byte) error {
 func (ct CustomTime) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + ct.Time.Format("2006-01-02") + `"`), nil
 }
+
+var dateFormatTokens = strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02")
+
+// DateLayout converts a date format written with the YYYY, MM and DD placeholders,
+// such as DD.MM.YYYY, to a time layout. Each placeholder must appear once; they may
+// be separated by '-', '.', '/' or spaces.
+func DateLayout(format string) (string, error) {
+	for _, token := range []string{"YYYY", "MM", "DD"} {
+		if strings.Count(format, token) != 1 {
+			return "", fmt.Errorf("date format %q must contain %s exactly once", format, token)
+		}
+	}
+
+	rest := strings.NewReplacer("YYYY", "", "MM", "", "DD", "").Replace(format)
+	if strings.Trim(rest, "-./ ") != "" {
+		return "", fmt.Errorf("date format %q may only separate YYYY, MM and DD with '-', '.', '/' or spaces", format)
+	}
+
+	return dateFormatTokens.Replace(format), nil
+}
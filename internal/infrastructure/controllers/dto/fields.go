@@ -0,0 +1,49 @@
+package dto
+
+import "encoding/json"
+
+// SubscriptionFields are the fields of SubscriptionResponse that can be selected with
+// the fields query parameter.
+var SubscriptionFields = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "created_at", "updated_at"}
+
+// Only restricts the JSON representation of r to the given fields of
+// SubscriptionFields. The ID and the embedded resources are always kept.
+func (r SubscriptionResponse) Only(fields []string) SubscriptionResponse {
+	r.fields = fields
+	return r
+}
+
+func (r SubscriptionResponse) MarshalJSON() ([]byte, error) {
+	type full SubscriptionResponse
+
+	if len(r.fields) == 0 {
+		return json.Marshal(full(r))
+	}
+
+	values := map[string]interface{}{"id": r.ID}
+
+	for _, f := range r.fields {
+		switch f {
+		case "service_name":
+			values[f] = r.ServiceName
+		case "price":
+			values[f] = r.Price
+		case "user_id":
+			values[f] = r.UserID
+		case "start_date":
+			values[f] = r.StartDate
+		case "end_date":
+			values[f] = r.EndDate
+		case "created_at":
+			values[f] = r.CreatedAt
+		case "updated_at":
+			values[f] = r.UpdatedAt
+		}
+	}
+
+	if r.Cost != nil {
+		values["cost"] = r.Cost
+	}
+
+	return json.Marshal(values)
+}
@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -12,9 +14,53 @@ type CreateSubscriptionRequest struct {
 	EndDate     CustomTime `json:"end_date,omitempty" example:"2025-12-31"`
 }
 
+// UpdateSubscriptionRequest replaces every writable field of a subscription. A missing
+// or null end date makes the subscription open-ended.
 type UpdateSubscriptionRequest struct {
-	ServiceName *string     `json:"service_name,omitempty" binding:"omitempty,min=2,max=100"`
-	Price       *int        `json:"price,omitempty" binding:"omitempty,gte=0"`
-	StartDate   *CustomTime `json:"start_date,omitempty"`
-	EndDate     *CustomTime `json:"end_date,omitempty"`
+	ServiceName string      `json:"service_name" binding:"required,min=2,max=100" example:"Yandex Plus"`
+	Price       int         `json:"price" binding:"required,gte=0" example:"400"`
+	UserID      uuid.UUID   `json:"user_id" binding:"required" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	StartDate   CustomTime  `json:"start_date" binding:"required" example:"2025-07-01"`
+	EndDate     *CustomTime `json:"end_date" example:"2025-12-31"`
+}
+
+// SubscriptionDocument is the JSON representation PATCH requests are applied to. Only
+// the fields of the embedded UpdateSubscriptionRequest may be changed.
+type SubscriptionDocument struct {
+	ID uuid.UUID `json:"id"`
+	UpdateSubscriptionRequest
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BatchRequest applies several operations at once. In the atomic mode, the default,
+// either every operation is applied or none; in the best_effort mode each operation
+// succeeds or fails on its own.
+type BatchRequest struct {
+	Mode       string                  `json:"mode" binding:"omitempty,oneof=atomic best_effort" example:"atomic"`
+	Operations []BatchOperationRequest `json:"operations" binding:"required,min=1"`
+}
+
+// BatchOperationRequest is a create, update or delete. Updates and deletes reference
+// the subscription by ID; creates and updates carry the full subscription.
+type BatchOperationRequest struct {
+	Op           string                     `json:"op" example:"create"`
+	ID           *uuid.UUID                 `json:"id,omitempty" example:"a3e7f924-7d11-4f36-91bb-8f69cb1c1a91"`
+	Subscription *UpdateSubscriptionRequest `json:"subscription,omitempty"`
+}
+
+// GraphQLRequest is a GraphQL query with the values of its variables. OperationName
+// selects the operation to run when the query defines several.
+type GraphQLRequest struct {
+	Query         string                 `json:"query" binding:"required" example:"{ subscriptions(limit: 10) { id serviceName price } }"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// CreateWebhookRequest registers a URL receiving the events of the listed types:
+// subscription.created, subscription.updated, subscription.ended, subscription.renewed
+// and subscription.deleted.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required" example:"https://billing.example.com/hooks/subscriptions"`
+	Events []string `json:"events" binding:"required,min=1" example:"subscription.created,subscription.ended"`
 }
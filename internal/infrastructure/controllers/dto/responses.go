@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,8 +16,221 @@ type SubscriptionResponse struct {
 	EndDate     time.Time `json:"end_date,omitempty" example:"2025-12-31"`
 	CreatedAt   time.Time `json:"created_at" example:"2025-07-01T12:00:00Z"`
 	UpdatedAt   time.Time `json:"updated_at" example:"2025-07-02T12:00:00Z"`
+	// Cost is embedded with expand=cost.
+	Cost *MonthCostResponse `json:"cost,omitempty"`
+
+	fields []string
+}
+
+// MonthCostResponse is what a subscription is billed for a month.
+type MonthCostResponse struct {
+	Month  string `json:"month" example:"2025-07"`
+	Amount int64  `json:"amount" example:"400"`
+}
+
+// SearchHitResponse is a subscription found by a search. Highlight is the service name
+// as HTML, escaped, with the matched fragment wrapped in a mark element.
+type SearchHitResponse struct {
+	Score        float64              `json:"score" example:"0.83"`
+	Highlight    string               `json:"highlight" example:"<mark>Yandex</mark> Plus"`
+	Subscription SubscriptionResponse `json:"subscription"`
 }
 
 type CostResponse struct {
 	Total int64 `json:"total" example:"1200"`
 }
+
+type StatsResponse struct {
+	PeriodStart         string                 `json:"period_start" example:"2024-08"`
+	PeriodEnd           string                 `json:"period_end" example:"2025-07"`
+	ActiveSubscriptions int64                  `json:"active_subscriptions" example:"120"`
+	DistinctUsers       int64                  `json:"distinct_users" example:"45"`
+	TopBySubscribers    []ServiceStatsResponse `json:"top_by_subscribers"`
+	TopByRevenue        []ServiceStatsResponse `json:"top_by_revenue"`
+	Services            []ServiceStatsResponse `json:"services"`
+	Monthly             []MonthlyStatsResponse `json:"monthly"`
+}
+
+type ServiceStatsResponse struct {
+	ServiceName  string  `json:"service_name" example:"Yandex Plus"`
+	Subscribers  int64   `json:"subscribers" example:"30"`
+	Revenue      int64   `json:"revenue" example:"144000"`
+	AveragePrice float64 `json:"average_price" example:"399.5"`
+	MedianPrice  float64 `json:"median_price" example:"400"`
+}
+
+type MonthlyStatsResponse struct {
+	Month string `json:"month" example:"2025-07"`
+	New   int64  `json:"new" example:"12"`
+	Ended int64  `json:"ended" example:"3"`
+}
+
+type CohortReportResponse struct {
+	PeriodStart string                    `json:"period_start" example:"2024-08"`
+	PeriodEnd   string                    `json:"period_end" example:"2025-07"`
+	Cohorts     []CohortResponse          `json:"cohorts"`
+	Lifetimes   []ServiceLifetimeResponse `json:"lifetimes"`
+	Churn       []MonthlyChurnResponse    `json:"churn"`
+}
+
+type CohortResponse struct {
+	Month     string    `json:"month" example:"2025-01"`
+	Size      int64     `json:"size" example:"40"`
+	Retention []float64 `json:"retention" example:"1,0.9,0.85"`
+}
+
+type ServiceLifetimeResponse struct {
+	ServiceName           string  `json:"service_name" example:"Yandex Plus"`
+	Subscriptions         int64   `json:"subscriptions" example:"40"`
+	Ended                 int64   `json:"ended" example:"12"`
+	AverageLifetimeMonths float64 `json:"average_lifetime_months" example:"7.5"`
+}
+
+type MonthlyChurnResponse struct {
+	Month     string  `json:"month" example:"2025-07"`
+	Active    int64   `json:"active" example:"100"`
+	Churned   int64   `json:"churned" example:"4"`
+	ChurnRate float64 `json:"churn_rate" example:"0.04"`
+}
+
+type InsightsResponse struct {
+	UserID             uuid.UUID         `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	TotalMonthlySaving int               `json:"total_monthly_saving" example:"460"`
+	Insights           []InsightResponse `json:"insights"`
+}
+
+type InsightResponse struct {
+	Kind            string      `json:"kind" example:"duplicate_service"`
+	ServiceName     string      `json:"service_name" example:"Yandex Plus"`
+	SubscriptionIDs []uuid.UUID `json:"subscription_ids"`
+	Message         string      `json:"message" example:"Yandex Plus is paid for 2 times"`
+	MonthlySaving   int         `json:"monthly_saving" example:"400"`
+}
+
+type SubscriptionListResponse struct {
+	Items      []SubscriptionResponse `json:"items"`
+	NextCursor string                 `json:"next_cursor,omitempty" example:"eyJjIjoiMjAyNS0wNy0wMVQxMjowMDowMFoiLCJpIjoiYTNlN2Y5MjQtN2QxMS00ZjM2LTkxYmItOGY2OWNiMWMxYTkxIn0"`
+	Total      *int64                 `json:"total,omitempty" example:"250"`
+}
+
+type BatchResponse struct {
+	Mode    string              `json:"mode" example:"best_effort"`
+	Applied int                 `json:"applied" example:"2"`
+	Failed  int                 `json:"failed" example:"1"`
+	Results []BatchItemResponse `json:"results"`
+}
+
+// BatchItemResponse is the outcome of one operation: applied, failed, or not_applied
+// when an atomic batch was rolled back because of another operation.
+type BatchItemResponse struct {
+	Index        int                   `json:"index" example:"0"`
+	Op           string                `json:"op" example:"create"`
+	Status       string                `json:"status" example:"applied"`
+	Subscription *SubscriptionResponse `json:"subscription,omitempty"`
+	Code         string                `json:"code,omitempty" example:"not_found"`
+	Error        string                `json:"error,omitempty" example:"subscription with id a3e7f924-7d11-4f36-91bb-8f69cb1c1a91 not found"`
+	Errors       []FieldErrorResponse  `json:"errors,omitempty"`
+}
+
+type ImportResponse struct {
+	Mode      string              `json:"mode" example:"dry_run"`
+	Committed bool                `json:"committed" example:"false"`
+	Summary   ImportSummary       `json:"summary"`
+	Rows      []ImportRowResponse `json:"rows"`
+}
+
+type ImportSummary struct {
+	Create    int `json:"create" example:"12"`
+	Duplicate int `json:"duplicate" example:"2"`
+	Rejected  int `json:"rejected" example:"1"`
+}
+
+// ImportRowResponse is the outcome of one CSV row: create, duplicate or rejected. Line
+// is the line of the row in the uploaded file, counting the header as line 1.
+type ImportRowResponse struct {
+	Line         int                   `json:"line" example:"2"`
+	Status       string                `json:"status" example:"create"`
+	Subscription *SubscriptionResponse `json:"subscription,omitempty"`
+	Code         string                `json:"code,omitempty" example:"validation_failed"`
+	Error        string                `json:"error,omitempty" example:"the request has invalid fields"`
+	Errors       []FieldErrorResponse  `json:"errors,omitempty"`
+}
+
+// Problem is an RFC 7807 problem details response. Code is a stable machine-readable
+// identifier of the problem; Errors lists the invalid fields of a validation failure.
+type Problem struct {
+	Type     string               `json:"type" example:"urn:subscription-service:problem:not_found"`
+	Title    string               `json:"title" example:"Not Found"`
+	Status   int                  `json:"status" example:"404"`
+	Detail   string               `json:"detail,omitempty" example:"subscription with id a3e7f924-7d11-4f36-91bb-8f69cb1c1a91 not found"`
+	Instance string               `json:"instance" example:"/subscriptions/a3e7f924-7d11-4f36-91bb-8f69cb1c1a91"`
+	Code     string               `json:"code" example:"not_found"`
+	Errors   []FieldErrorResponse `json:"errors,omitempty"`
+}
+
+type FieldErrorResponse struct {
+	Field   string `json:"field" example:"price"`
+	Message string `json:"message" example:"price is required"`
+}
+
+// CalendarTokenResponse carries a newly issued calendar feed token. It is shown only
+// once; URL is the feed address to add to a calendar app.
+type CalendarTokenResponse struct {
+	Token string `json:"token" example:"Jx3s0bVd4yJ0m3hQm9tC2m2rZr8lKp1n5d0e7vQw6aQ"`
+	URL   string `json:"url" example:"https://subscriptions.example.com/api/v1/users/60601fee-2bf1-4721-ae6f-7636e79a0cba/calendar.ics?token=Jx3s0bVd4yJ0m3hQm9tC2m2rZr8lKp1n5d0e7vQw6aQ"`
+}
+
+// WebhookResponse is a registered webhook. Secret signs its deliveries and is only
+// shown in the response creating the webhook.
+type WebhookResponse struct {
+	ID        uuid.UUID `json:"id" example:"0f8f5c2e-3b1a-4c8e-9d6f-2a7b1c9e4d30"`
+	URL       string    `json:"url" example:"https://billing.example.com/hooks/subscriptions"`
+	Events    []string  `json:"events" example:"subscription.created,subscription.ended"`
+	Secret    string    `json:"secret,omitempty" example:"whsec_Jx3s0bVd4yJ0m3hQm9tC2m2rZr8lKp1n5d0e7vQw6aQ"`
+	CreatedAt time.Time `json:"created_at" example:"2025-07-01T12:00:00Z"`
+}
+
+// WebhookDeliveryResponse is an event sent or to be sent to a webhook with the log of
+// its attempts, newest first. NextAttemptAt is set while the delivery is pending.
+type WebhookDeliveryResponse struct {
+	ID             uuid.UUID                 `json:"id" example:"5b0c7d8e-1f2a-4b3c-8d9e-0a1b2c3d4e5f"`
+	EventID        uuid.UUID                 `json:"event_id" example:"9c1d2e3f-4a5b-4c6d-8e7f-0a1b2c3d4e5f"`
+	EventType      string                    `json:"event_type" example:"subscription.created"`
+	Status         string                    `json:"status" example:"dead"`
+	Attempts       int                       `json:"attempts" example:"8"`
+	NextAttemptAt  *time.Time                `json:"next_attempt_at,omitempty" example:"2025-07-01T12:05:00Z"`
+	LastStatusCode int                       `json:"last_status_code,omitempty" example:"503"`
+	LastError      string                    `json:"last_error,omitempty" example:"unexpected response status 503 Service Unavailable"`
+	CreatedAt      time.Time                 `json:"created_at" example:"2025-07-01T12:00:00Z"`
+	DeliveredAt    *time.Time                `json:"delivered_at,omitempty" example:"2025-07-01T12:00:01Z"`
+	Payload        json.RawMessage           `json:"payload" swaggertype:"object"`
+	Log            []DeliveryAttemptResponse `json:"log"`
+}
+
+// DeliveryAttemptResponse is one request sent for a delivery. StatusCode is omitted
+// when no response was received.
+type DeliveryAttemptResponse struct {
+	StatusCode  int       `json:"status_code,omitempty" example:"503"`
+	Error       string    `json:"error,omitempty" example:"unexpected response status 503 Service Unavailable"`
+	DurationMS  int64     `json:"duration_ms" example:"118"`
+	AttemptedAt time.Time `json:"attempted_at" example:"2025-07-01T12:00:00Z"`
+}
+
+// GraphQLResponse is the result of a GraphQL request as the API documents it: the data
+// selected by the query and the errors met running it. The handler writes the result
+// of the GraphQL executor, which has this shape.
+type GraphQLResponse struct {
+	Data   map[string]interface{} `json:"data" extensions:"x-nullable"`
+	Errors []GraphQLError         `json:"errors,omitempty"`
+}
+
+type GraphQLError struct {
+	Message   string                 `json:"message" example:"Cannot query field \"name\" on type \"Subscription\"."`
+	Locations []GraphQLErrorLocation `json:"locations"`
+	Path      []interface{}          `json:"path"`
+}
+
+type GraphQLErrorLocation struct {
+	Line   int `json:"line" example:"1"`
+	Column int `json:"column" example:"25"`
+}
@@ -0,0 +1,106 @@
+package graphql
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+// Error codes reported in the extensions of GraphQL errors. They match the problem
+// codes of the REST API so clients can share their handling.
+const (
+	codeValidationFailed = "validation_failed"
+	codeNotFound         = "not_found"
+	codeQueryTooComplex  = "query_too_complex"
+	codeQueryTooDeep     = "query_too_deep"
+	codeInternal         = "internal_error"
+)
+
+// present rewrites the errors raised by the resolvers for the client: domain errors
+// get their code and field in the extensions, and unexpected errors are logged and
+// reported without details. Errors of the query itself, raised by the parser and the
+// validation, are left as they are.
+func present(errs []gqlerrors.FormattedError, logger *slog.Logger) []gqlerrors.FormattedError {
+	for i, fe := range errs {
+		cause := originalError(fe)
+		if cause == nil {
+			continue
+		}
+
+		var validationErr *model.ValidationError
+
+		switch {
+		case errors.As(cause, &validationErr):
+			fe.Message = validationErr.Message
+			fe.Extensions = map[string]interface{}{"code": codeValidationFailed}
+
+			if validationErr.Field != "" {
+				fe.Extensions["field"] = camelCase(validationErr.Field)
+			}
+		case errors.Is(cause, model.ErrNotFound):
+			fe.Message = cause.Error()
+			fe.Extensions = map[string]interface{}{"code": codeNotFound}
+		default:
+			logger.Error("GraphQL resolver failed",
+				slog.Any("path", fe.Path),
+				slog.String("error", cause.Error()),
+			)
+
+			fe.Message = "an unexpected error occurred"
+			fe.Extensions = map[string]interface{}{"code": codeInternal}
+		}
+
+		errs[i] = fe
+	}
+
+	return errs
+}
+
+// originalError returns the error a resolver returned, unwrapping the located errors
+// the executor wraps it in, or nil for errors raised by the executor itself.
+func originalError(err error) error {
+	for {
+		switch e := err.(type) {
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		case resolverError:
+			return e.err
+		default:
+			return nil
+		}
+	}
+}
+
+// camelCase converts the snake_case field names of the domain errors, such as
+// end_date, to the names of the GraphQL schema.
+func camelCase(field string) string {
+	parts := strings.Split(field, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+
+	return strings.Join(parts, "")
+}
+
+// resolverError marks the errors returned by the resolvers, telling them apart from
+// the errors of the executor, which are meant for the client as they are.
+type resolverError struct {
+	err error
+}
+
+func (e resolverError) Error() string {
+	return e.err.Error()
+}
+
+// fail wraps the error of a resolver.
+func fail(err error) error {
+	return resolverError{err: err}
+}
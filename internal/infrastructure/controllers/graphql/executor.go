@@ -0,0 +1,67 @@
+package graphql
+
+import (
+	"context"
+	"log/slog"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"Subscription_Service/internal/application/service"
+)
+
+// Executor runs GraphQL requests against the schema of the subscription service.
+type Executor struct {
+	schema  gql.Schema
+	service service.Service
+	limits  Limits
+	logger  *slog.Logger
+}
+
+func NewExecutor(serv service.Service, limits Limits, logger *slog.Logger) (*Executor, error) {
+	schema, err := NewSchema(serv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Executor{
+		schema:  schema,
+		service: serv,
+		limits:  limits,
+		logger:  logger,
+	}, nil
+}
+
+// Execute parses, validates and runs a request. Queries exceeding the limits are
+// rejected before anything is resolved. Every request gets its own loaders, so nothing
+// loaded is shared between requests.
+func (e *Executor) Execute(ctx context.Context, query, operationName string, variables map[string]interface{}) *gql.Result {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &gql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	if v := gql.ValidateDocument(&e.schema, doc, nil); !v.IsValid {
+		return &gql.Result{Errors: v.Errors}
+	}
+
+	if errs := checkLimits(&e.schema, doc, operationName, variables, e.limits); len(errs) > 0 {
+		return &gql.Result{Errors: errs}
+	}
+
+	result := gql.Execute(gql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           doc,
+		OperationName: operationName,
+		Args:          variables,
+		Context:       withLoaders(ctx, newLoaders(e.service)),
+	})
+
+	result.Errors = present(result.Errors, e.logger)
+
+	return result
+}
@@ -0,0 +1,279 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+// Limits bound the work a single query may ask for.
+type Limits struct {
+	// MaxComplexity bounds the estimated number of fields a query resolves. Every field
+	// counts once, and the fields selected under a list count once per item it may
+	// return, as bounded by its limit argument or the number of IDs it is given.
+	MaxComplexity int
+	// MaxDepth bounds how deeply the fields of a query may be nested.
+	MaxDepth int
+}
+
+// maxEstimate caps the estimated complexity so the products of nested list sizes
+// cannot overflow; any query reaching it is far beyond a sensible limit.
+const maxEstimate = 1 << 40
+
+// cost is the estimated work of a selection: the number of fields it resolves and
+// the depth of its deepest field.
+type cost struct {
+	complexity int
+	depth      int
+}
+
+// analysis estimates the cost of the operation of a validated document. Introspection
+// fields are not counted: they only read the schema.
+type analysis struct {
+	schema    *gql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	defaults  map[string]ast.Value
+}
+
+// checkLimits returns the errors reporting the limits the operation of doc exceeds, if
+// any. Zero limits are not enforced.
+func checkLimits(schema *gql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}, limits Limits) []gqlerrors.FormattedError {
+	op, fragments := operation(doc, operationName)
+	if op == nil {
+		// The executor reports the missing operation.
+		return nil
+	}
+
+	root := schema.QueryType()
+	if op.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+
+	a := analysis{schema: schema, fragments: fragments, variables: variables, defaults: map[string]ast.Value{}}
+	for _, def := range op.VariableDefinitions {
+		if def.DefaultValue != nil {
+			a.defaults[def.Variable.Name.Value] = def.DefaultValue
+		}
+	}
+
+	c := a.selectionSet(root, op.SelectionSet, 0)
+
+	var errs []gqlerrors.FormattedError
+
+	if limits.MaxDepth > 0 && c.depth > limits.MaxDepth {
+		errs = append(errs, limitError(codeQueryTooDeep,
+			fmt.Sprintf("query depth %d exceeds the maximum of %d", c.depth, limits.MaxDepth)))
+	}
+
+	if limits.MaxComplexity > 0 && c.complexity > limits.MaxComplexity {
+		errs = append(errs, limitError(codeQueryTooComplex,
+			fmt.Sprintf("query complexity %d exceeds the maximum of %d; lower the limit arguments of the lists", c.complexity, limits.MaxComplexity)))
+	}
+
+	return errs
+}
+
+// operation finds the operation to execute and the fragments of doc.
+func operation(doc *ast.Document, name string) (*ast.OperationDefinition, map[string]*ast.FragmentDefinition) {
+	var (
+		op        *ast.OperationDefinition
+		count     int
+		fragments = map[string]*ast.FragmentDefinition{}
+	)
+
+	for _, def := range doc.Definitions {
+		switch d := def.(type) {
+		case *ast.OperationDefinition:
+			count++
+
+			if name == "" || (d.Name != nil && d.Name.Value == name) {
+				op = d
+			}
+		case *ast.FragmentDefinition:
+			fragments[d.Name.Value] = d
+		}
+	}
+
+	if name == "" && count > 1 {
+		return nil, fragments
+	}
+
+	return op, fragments
+}
+
+func (a *analysis) selectionSet(parent *gql.Object, set *ast.SelectionSet, depth int) cost {
+	var total cost
+
+	if set == nil || parent == nil {
+		return total
+	}
+
+	for _, sel := range set.Selections {
+		var c cost
+
+		switch s := sel.(type) {
+		case *ast.Field:
+			c = a.field(parent, s, depth)
+		case *ast.InlineFragment:
+			c = a.selectionSet(a.fragmentType(parent, s.TypeCondition), s.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			if frag, ok := a.fragments[s.Name.Value]; ok {
+				c = a.selectionSet(a.fragmentType(parent, frag.TypeCondition), frag.SelectionSet, depth)
+			}
+		}
+
+		total.complexity = min(total.complexity+c.complexity, maxEstimate)
+		total.depth = max(total.depth, c.depth)
+	}
+
+	return total
+}
+
+func (a *analysis) field(parent *gql.Object, f *ast.Field, depth int) cost {
+	if strings.HasPrefix(f.Name.Value, "__") {
+		return cost{}
+	}
+
+	def, ok := parent.Fields()[f.Name.Value]
+	if !ok {
+		return cost{}
+	}
+
+	c := cost{complexity: 1, depth: depth + 1}
+
+	if f.SelectionSet == nil {
+		return c
+	}
+
+	child, _ := gql.GetNamed(def.Type).(*gql.Object)
+	sub := a.selectionSet(child, f.SelectionSet, depth+1)
+
+	c.complexity = min(1+a.items(def, f)*sub.complexity, maxEstimate)
+	c.depth = max(c.depth, sub.depth)
+
+	return c
+}
+
+// items estimates how many items a field returns: one unless it is a list, in which
+// case its limit argument or the number of IDs it is given bounds it.
+func (a *analysis) items(def *gql.FieldDefinition, f *ast.Field) int {
+	if !isList(def.Type) {
+		return 1
+	}
+
+	for _, arg := range def.Args {
+		switch arg.Name() {
+		case "limit":
+			if n, ok := a.intArg(f, "limit"); ok {
+				// Larger limits are rejected by the resolvers.
+				return min(max(n, 1), model.MaxPageLimit)
+			}
+
+			if n, ok := arg.DefaultValue.(int); ok {
+				return n
+			}
+		case "ids":
+			if n, ok := a.listArg(f, "ids"); ok {
+				return max(n, 1)
+			}
+		}
+	}
+
+	return defaultListLimit
+}
+
+func (a *analysis) fragmentType(parent *gql.Object, cond *ast.Named) *gql.Object {
+	if cond == nil {
+		return parent
+	}
+
+	t, _ := a.schema.Type(cond.Name.Value).(*gql.Object)
+
+	return t
+}
+
+// argValue returns the value of an argument of f, resolving variables to the values
+// they are given, or to their default values as AST values.
+func (a *analysis) argValue(f *ast.Field, name string) (interface{}, bool) {
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != name {
+			continue
+		}
+
+		v, ok := arg.Value.(*ast.Variable)
+		if !ok {
+			return arg.Value, true
+		}
+
+		if value, ok := a.variables[v.Name.Value]; ok {
+			return value, true
+		}
+
+		value, ok := a.defaults[v.Name.Value]
+
+		return value, ok
+	}
+
+	return nil, false
+}
+
+func (a *analysis) intArg(f *ast.Field, name string) (int, bool) {
+	v, ok := a.argValue(f, name)
+	if !ok {
+		return 0, false
+	}
+
+	switch v := v.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(v.Value)
+		return n, err == nil
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	}
+
+	return 0, false
+}
+
+func (a *analysis) listArg(f *ast.Field, name string) (int, bool) {
+	v, ok := a.argValue(f, name)
+	if !ok {
+		return 0, false
+	}
+
+	switch v := v.(type) {
+	case *ast.ListValue:
+		return len(v.Values), true
+	case []interface{}:
+		return len(v), true
+	}
+
+	return 0, false
+}
+
+func isList(t gql.Type) bool {
+	if nn, ok := t.(*gql.NonNull); ok {
+		t = nn.OfType
+	}
+
+	_, ok := t.(*gql.List)
+
+	return ok
+}
+
+func limitError(code, message string) gqlerrors.FormattedError {
+	return gqlerrors.FormattedError{
+		Message:    message,
+		Locations:  []location.SourceLocation{},
+		Extensions: map[string]interface{}{"code": code},
+	}
+}
@@ -0,0 +1,93 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"Subscription_Service/internal/application/service"
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/pkg/dataloader"
+)
+
+// subscriptionsKey identifies the first subscriptions of a user, as many as Limit.
+type subscriptionsKey struct {
+	UserID uuid.UUID
+	Limit  int
+}
+
+// costKey identifies the cost of the subscriptions of a user over a period of months.
+type costKey struct {
+	UserID     uuid.UUID
+	Start, End time.Time
+}
+
+// loaders batch the per-user lookups of one request, so that resolving the users of
+// a list of subscriptions reads the subscriptions or costs of all of them at once.
+type loaders struct {
+	subscriptions *dataloader.Loader[subscriptionsKey, []model.Subscription]
+	costs         *dataloader.Loader[costKey, int64]
+}
+
+type loadersKey struct{}
+
+func newLoaders(serv service.Service) *loaders {
+	return &loaders{
+		subscriptions: dataloader.New(func(ctx context.Context, keys []subscriptionsKey) (map[subscriptionsKey][]model.Subscription, error) {
+			users := make(map[int][]uuid.UUID)
+			for _, k := range keys {
+				users[k.Limit] = append(users[k.Limit], k.UserID)
+			}
+
+			result := make(map[subscriptionsKey][]model.Subscription, len(keys))
+
+			// One query per distinct limit, which in practice is one per request.
+			for limit, ids := range users {
+				subs, err := serv.ListPerUser(ctx, ids, limit)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, s := range subs {
+					k := subscriptionsKey{s.UserID, limit}
+					result[k] = append(result[k], s)
+				}
+			}
+
+			return result, nil
+		}),
+		costs: dataloader.New(func(ctx context.Context, keys []costKey) (map[costKey]int64, error) {
+			type period struct{ start, end time.Time }
+
+			users := make(map[period][]uuid.UUID)
+			for _, k := range keys {
+				p := period{k.Start, k.End}
+				users[p] = append(users[p], k.UserID)
+			}
+
+			result := make(map[costKey]int64, len(keys))
+
+			// One query per distinct period, which in practice is one per request.
+			for p, ids := range users {
+				err := serv.ExportCost(ctx, model.SubscriptionFilter{UserIDs: ids}, p.start, p.end, nil, func(line model.CostLine) error {
+					result[costKey{line.UserID, p.start, p.end}] += line.Cost
+					return nil
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			return result, nil
+		}),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
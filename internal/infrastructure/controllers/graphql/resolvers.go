@@ -0,0 +1,453 @@
+package graphql
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	gql "github.com/graphql-go/graphql"
+
+	"Subscription_Service/internal/application/service"
+	"Subscription_Service/internal/domain/filterexpr"
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+type resolver struct {
+	service service.Service
+}
+
+func (r *resolver) subscription(p gql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	sub, err := r.service.Read(p.Context, id, nil, nil)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	return *sub, nil
+}
+
+func (r *resolver) subscriptions(p gql.ResolveParams) (interface{}, error) {
+	filter, err := filterArg(p.Args)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	for _, field := range stringList(p.Args["sort"]) {
+		filter.Sort = append(filter.Sort, model.SortField{Field: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")})
+	}
+
+	limit, err := limitArg(p.Args)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	offset, _ := p.Args["offset"].(int)
+	page := model.PageRequest{Limit: limit, Offset: offset}
+
+	result, err := r.service.List(p.Context, filter, page, nil)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	return result.Subscriptions, nil
+}
+
+func (r *resolver) user(p gql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	return id, nil
+}
+
+func (r *resolver) users(p gql.ResolveParams) (interface{}, error) {
+	raw := stringList(p.Args["ids"])
+
+	ids := make([]uuid.UUID, 0, len(raw))
+	for _, s := range raw {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, fail(model.Invalid("ids", "invalid id %q, expected a UUID", s))
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (r *resolver) cost(p gql.ResolveParams) (interface{}, error) {
+	start, end, err := periodArgs(p.Args)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	filter, err := filterArg(p.Args)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	total, err := r.service.CalculateCost(p.Context, filter, start, end, nil)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	return total, nil
+}
+
+func (r *resolver) costBreakdown(p gql.ResolveParams) (interface{}, error) {
+	start, end, err := periodArgs(p.Args)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	filter, err := filterArg(p.Args)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	limit, err := limitArg(p.Args)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	// The export cannot be stopped early without being reported as failed, so the
+	// lines past the limit are read and dropped.
+	lines := make([]model.CostLine, 0, limit)
+
+	err = r.service.ExportCost(p.Context, filter, start, end, nil, func(line model.CostLine) error {
+		if len(lines) < limit {
+			lines = append(lines, line)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	return lines, nil
+}
+
+// userSubscriptions loads the subscriptions of the user together with those of the
+// other users resolved at the same level of the query.
+func (r *resolver) userSubscriptions(p gql.ResolveParams) (interface{}, error) {
+	limit, err := limitArg(p.Args)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	load := loadersFrom(p.Context).subscriptions.Load(p.Context, subscriptionsKey{UserID: p.Source.(uuid.UUID), Limit: limit})
+
+	return func() (interface{}, error) {
+		subs, err := load()
+		if err != nil {
+			return nil, fail(err)
+		}
+
+		return subs, nil
+	}, nil
+}
+
+// userCost loads the cost of the user together with that of the other users resolved
+// at the same level of the query.
+func (r *resolver) userCost(p gql.ResolveParams) (interface{}, error) {
+	start, end, err := periodArgs(p.Args)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	if end.Before(start) {
+		return nil, fail(model.Invalid("end", "end cannot be before start"))
+	}
+
+	load := loadersFrom(p.Context).costs.Load(p.Context, costKey{UserID: p.Source.(uuid.UUID), Start: start, End: end})
+
+	return func() (interface{}, error) {
+		total, err := load()
+		if err != nil {
+			return nil, fail(err)
+		}
+
+		return total, nil
+	}, nil
+}
+
+func (r *resolver) monthCost(p gql.ResolveParams) (interface{}, error) {
+	month, err := monthArg(p.Args, "month")
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	return p.Source.(model.Subscription).MonthCost(month), nil
+}
+
+func (r *resolver) createSubscription(p gql.ResolveParams) (interface{}, error) {
+	var sub model.Subscription
+
+	if err := applyInput(&sub, p.Args["input"].(map[string]interface{})); err != nil {
+		return nil, fail(err)
+	}
+
+	if err := r.service.Create(p.Context, &sub); err != nil {
+		return nil, fail(err)
+	}
+
+	return sub, nil
+}
+
+func (r *resolver) updateSubscription(p gql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	sub, err := r.service.Read(p.Context, id, nil, nil)
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	if err := applyInput(sub, p.Args["input"].(map[string]interface{})); err != nil {
+		return nil, fail(err)
+	}
+
+	if err := r.service.Update(p.Context, sub); err != nil {
+		return nil, fail(err)
+	}
+
+	return *sub, nil
+}
+
+func (r *resolver) deleteSubscription(p gql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, fail(err)
+	}
+
+	if err := r.service.Delete(p.Context, id); err != nil {
+		return nil, fail(err)
+	}
+
+	return id.String(), nil
+}
+
+// applyInput replaces the writable fields of s with those of a SubscriptionInput,
+// checking them as the REST API checks its request bodies.
+func applyInput(s *model.Subscription, input map[string]interface{}) error {
+	name := input["serviceName"].(string)
+	if n := utf8.RuneCountInString(name); n < 2 || n > 100 {
+		return model.Invalid("serviceName", "serviceName must be between 2 and 100 characters")
+	}
+
+	price := input["price"].(int)
+	if price < 0 {
+		return model.Invalid("price", "price must be greater than or equal to 0")
+	}
+
+	userID, err := idArg(input, "userId")
+	if err != nil {
+		return err
+	}
+
+	start, err := dateArg(input, "startDate")
+	if err != nil {
+		return err
+	}
+
+	end, err := optionalDateArg(input, "endDate")
+	if err != nil {
+		return err
+	}
+
+	s.ServiceName = name
+	s.Price = price
+	s.UserID = userID
+	s.StartDate = start
+	s.EndDate = time.Time{}
+
+	if end != nil {
+		s.EndDate = *end
+	}
+
+	return nil
+}
+
+// filterArg converts the optional filter argument to a subscription filter.
+func filterArg(args map[string]interface{}) (model.SubscriptionFilter, error) {
+	f := model.SubscriptionFilter{
+		ServiceName: model.ServiceNameFilter{Match: model.MatchContains, Threshold: model.DefaultSimilarityThreshold},
+	}
+
+	in, _ := args["filter"].(map[string]interface{})
+	if in == nil {
+		return f, nil
+	}
+
+	if _, ok := in["userId"].(string); ok {
+		id, err := idArg(in, "userId")
+		if err != nil {
+			return f, err
+		}
+
+		f.UserID = &id
+	}
+
+	f.ServiceName.Names = stringList(in["serviceNames"])
+
+	if v, ok := in["match"].(model.MatchMode); ok {
+		f.ServiceName.Match = v
+	}
+
+	if v, ok := in["threshold"].(float64); ok {
+		f.ServiceName.Threshold = v
+	}
+
+	if v, ok := in["priceMin"].(int); ok {
+		f.PriceMin = &v
+	}
+
+	if v, ok := in["priceMax"].(int); ok {
+		f.PriceMax = &v
+	}
+
+	dates := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"startFrom", &f.StartFrom},
+		{"startTo", &f.StartTo},
+		{"endFrom", &f.EndFrom},
+		{"endTo", &f.EndTo},
+		{"activeAt", &f.ActiveAt},
+	}
+
+	for _, d := range dates {
+		date, err := optionalDateArg(in, d.name)
+		if err != nil {
+			return f, err
+		}
+
+		*d.dst = date
+	}
+
+	f.OpenEnded, _ = in["openEnded"].(bool)
+
+	if v, ok := in["expr"].(string); ok && v != "" {
+		expr, err := filterexpr.Parse(v)
+		if err != nil {
+			return f, model.Invalid("expr", "invalid filter: %s", err.Error())
+		}
+
+		f.Expr = expr
+	}
+
+	return f, nil
+}
+
+func idArg(args map[string]interface{}, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(stringArg(args, name))
+	if err != nil {
+		return uuid.UUID{}, model.Invalid(name, "invalid %s, expected a UUID", name)
+	}
+
+	return id, nil
+}
+
+func limitArg(args map[string]interface{}) (int, error) {
+	limit, ok := args["limit"].(int)
+	if !ok {
+		return defaultListLimit, nil
+	}
+
+	if limit < 1 || limit > model.MaxPageLimit {
+		return 0, model.Invalid("limit", "limit must be between 1 and %d", model.MaxPageLimit)
+	}
+
+	return limit, nil
+}
+
+func periodArgs(args map[string]interface{}) (time.Time, time.Time, error) {
+	start, err := monthArg(args, "start")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	end, err := monthArg(args, "end")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return start, end, nil
+}
+
+func monthArg(args map[string]interface{}, name string) (time.Time, error) {
+	t, err := time.Parse("2006-01", stringArg(args, name))
+	if err != nil {
+		return time.Time{}, model.Invalid(name, "invalid %s format, expected YYYY-MM", name)
+	}
+
+	return t, nil
+}
+
+func dateArg(args map[string]interface{}, name string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", stringArg(args, name))
+	if err != nil {
+		return time.Time{}, model.Invalid(name, "invalid %s format, expected YYYY-MM-DD", name)
+	}
+
+	return t, nil
+}
+
+func optionalDateArg(args map[string]interface{}, name string) (*time.Time, error) {
+	if v, ok := args[name].(string); !ok || v == "" {
+		return nil, nil
+	}
+
+	t, err := dateArg(args, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
+
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
+
+func formatDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func formatOptionalDate(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+
+	return formatDate(t)
+}
@@ -0,0 +1,234 @@
+// Package graphql serves the subscription service over GraphQL. The resolvers delegate
+// to service.Service like the REST handlers do; the lookups made per user are batched
+// per request, and queries are rejected up front when their estimated cost or depth
+// exceeds the configured limits.
+package graphql
+
+import (
+	"github.com/google/uuid"
+	gql "github.com/graphql-go/graphql"
+
+	"Subscription_Service/internal/application/service"
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+// defaultListLimit is the number of items a list field returns unless its limit
+// argument asks for another.
+const defaultListLimit = 100
+
+var longType = gql.NewScalar(gql.ScalarConfig{
+	Name:        "Long",
+	Description: "A 64-bit integer, used for amounts of money.",
+	Serialize: func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case int64:
+			return v
+		case int:
+			return int64(v)
+		}
+
+		return nil
+	},
+})
+
+var matchModeType = gql.NewEnum(gql.EnumConfig{
+	Name:        "MatchMode",
+	Description: "How service names are matched.",
+	Values: gql.EnumValueConfigMap{
+		"EXACT":    {Value: model.MatchExact, Description: "The name equals one of the given ones, ignoring case."},
+		"PREFIX":   {Value: model.MatchPrefix, Description: "The name starts with one of the given ones, ignoring case."},
+		"CONTAINS": {Value: model.MatchContains, Description: "The name contains one of the given ones, ignoring case."},
+		"FUZZY":    {Value: model.MatchFuzzy, Description: "The name is similar to one of the given ones."},
+	},
+})
+
+var filterInputType = gql.NewInputObject(gql.InputObjectConfig{
+	Name:        "SubscriptionFilter",
+	Description: "Selects subscriptions. Unset fields do not restrict the result; date ranges are inclusive.",
+	Fields: gql.InputObjectConfigFieldMap{
+		"userId":       {Type: gql.ID},
+		"serviceNames": {Type: gql.NewList(gql.NewNonNull(gql.String))},
+		"match":        {Type: matchModeType, DefaultValue: model.MatchContains},
+		"threshold":    {Type: gql.Float, DefaultValue: model.DefaultSimilarityThreshold, Description: "Similarity threshold of the FUZZY match, between 0 and 1."},
+		"priceMin":     {Type: gql.Int},
+		"priceMax":     {Type: gql.Int},
+		"startFrom":    {Type: gql.String, Description: "YYYY-MM-DD"},
+		"startTo":      {Type: gql.String, Description: "YYYY-MM-DD"},
+		"endFrom":      {Type: gql.String, Description: "YYYY-MM-DD"},
+		"endTo":        {Type: gql.String, Description: "YYYY-MM-DD"},
+		"activeAt":     {Type: gql.String, Description: "YYYY-MM-DD; keeps the subscriptions running on that day."},
+		"openEnded":    {Type: gql.Boolean, Description: "Keeps the subscriptions without an end date."},
+		"expr":         {Type: gql.String, Description: "A filter expression, as the filter parameter of the REST API."},
+	},
+})
+
+var subscriptionInputType = gql.NewInputObject(gql.InputObjectConfig{
+	Name:        "SubscriptionInput",
+	Description: "Every writable field of a subscription. A missing end date makes it open-ended.",
+	Fields: gql.InputObjectConfigFieldMap{
+		"serviceName": {Type: gql.NewNonNull(gql.String)},
+		"price":       {Type: gql.NewNonNull(gql.Int)},
+		"userId":      {Type: gql.NewNonNull(gql.ID)},
+		"startDate":   {Type: gql.NewNonNull(gql.String), Description: "YYYY-MM-DD"},
+		"endDate":     {Type: gql.String, Description: "YYYY-MM-DD"},
+	},
+})
+
+// NewSchema builds the GraphQL schema served over serv.
+func NewSchema(serv service.Service) (gql.Schema, error) {
+	r := &resolver{service: serv}
+
+	var subscriptionType, userType *gql.Object
+
+	limitArg := &gql.ArgumentConfig{Type: gql.Int, DefaultValue: defaultListLimit}
+	periodArgs := func(args gql.FieldConfigArgument) gql.FieldConfigArgument {
+		args["start"] = &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String), Description: "First month of the period, YYYY-MM."}
+		args["end"] = &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String), Description: "Last month of the period, YYYY-MM."}
+
+		return args
+	}
+
+	subscriptionType = gql.NewObject(gql.ObjectConfig{
+		Name: "Subscription",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"id":          {Type: gql.NewNonNull(gql.ID), Resolve: subscriptionField(func(s model.Subscription) interface{} { return s.ID.String() })},
+				"serviceName": {Type: gql.NewNonNull(gql.String), Resolve: subscriptionField(func(s model.Subscription) interface{} { return s.ServiceName })},
+				"price":       {Type: gql.NewNonNull(gql.Int), Resolve: subscriptionField(func(s model.Subscription) interface{} { return s.Price })},
+				"userId":      {Type: gql.NewNonNull(gql.ID), Resolve: subscriptionField(func(s model.Subscription) interface{} { return s.UserID.String() })},
+				"startDate":   {Type: gql.NewNonNull(gql.String), Resolve: subscriptionField(func(s model.Subscription) interface{} { return formatDate(s.StartDate) })},
+				"endDate":     {Type: gql.String, Resolve: subscriptionField(func(s model.Subscription) interface{} { return formatOptionalDate(s.EndDate) })},
+				"createdAt":   {Type: gql.NewNonNull(gql.DateTime), Resolve: subscriptionField(func(s model.Subscription) interface{} { return s.CreatedAt })},
+				"updatedAt":   {Type: gql.NewNonNull(gql.DateTime), Resolve: subscriptionField(func(s model.Subscription) interface{} { return s.UpdatedAt })},
+				"version":     {Type: gql.NewNonNull(gql.Int), Resolve: subscriptionField(func(s model.Subscription) interface{} { return s.Version })},
+				"user":        {Type: gql.NewNonNull(userType), Resolve: subscriptionField(func(s model.Subscription) interface{} { return s.UserID })},
+				"monthCost": {
+					Type:        gql.NewNonNull(longType),
+					Description: "What the subscription is billed for the month, YYYY-MM.",
+					Args:        gql.FieldConfigArgument{"month": {Type: gql.NewNonNull(gql.String)}},
+					Resolve:     r.monthCost,
+				},
+			}
+		}),
+	})
+
+	userType = gql.NewObject(gql.ObjectConfig{
+		Name:        "User",
+		Description: "A user is known by the ID its subscriptions carry; a user without subscriptions has none.",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"id": {Type: gql.NewNonNull(gql.ID), Resolve: userField(func(id uuid.UUID) interface{} { return id.String() })},
+				"subscriptions": {
+					Type:    gql.NewNonNull(gql.NewList(gql.NewNonNull(subscriptionType))),
+					Args:    gql.FieldConfigArgument{"limit": limitArg},
+					Resolve: r.userSubscriptions,
+				},
+				"cost": {
+					Type:        gql.NewNonNull(longType),
+					Description: "What the subscriptions of the user cost over the period.",
+					Args:        periodArgs(gql.FieldConfigArgument{}),
+					Resolve:     r.userCost,
+				},
+			}
+		}),
+	})
+
+	costLineType := gql.NewObject(gql.ObjectConfig{
+		Name:        "CostLine",
+		Description: "A subscription billed in a period with its share of the cost.",
+		Fields: gql.Fields{
+			"subscription": {Type: gql.NewNonNull(subscriptionType), Resolve: costLineField(func(l model.CostLine) interface{} { return l.Subscription })},
+			"months":       {Type: gql.NewNonNull(gql.Int), Resolve: costLineField(func(l model.CostLine) interface{} { return l.Months })},
+			"cost":         {Type: gql.NewNonNull(longType), Resolve: costLineField(func(l model.CostLine) interface{} { return l.Cost })},
+		},
+	})
+
+	query := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"subscription": {
+				Type:    subscriptionType,
+				Args:    gql.FieldConfigArgument{"id": {Type: gql.NewNonNull(gql.ID)}},
+				Resolve: r.subscription,
+			},
+			"subscriptions": {
+				Type:        gql.NewNonNull(gql.NewList(gql.NewNonNull(subscriptionType))),
+				Description: "A page of the subscriptions matching the filter, newest first unless sorted otherwise.",
+				Args: gql.FieldConfigArgument{
+					"filter": {Type: filterInputType},
+					"sort":   {Type: gql.NewList(gql.NewNonNull(gql.String)), Description: "Sort fields, descending when prefixed with -."},
+					"limit":  limitArg,
+					"offset": {Type: gql.Int, DefaultValue: 0},
+				},
+				Resolve: r.subscriptions,
+			},
+			"user": {
+				Type:    gql.NewNonNull(userType),
+				Args:    gql.FieldConfigArgument{"id": {Type: gql.NewNonNull(gql.ID)}},
+				Resolve: r.user,
+			},
+			"users": {
+				Type:    gql.NewNonNull(gql.NewList(gql.NewNonNull(userType))),
+				Args:    gql.FieldConfigArgument{"ids": {Type: gql.NewNonNull(gql.NewList(gql.NewNonNull(gql.ID)))}},
+				Resolve: r.users,
+			},
+			"cost": {
+				Type:        gql.NewNonNull(longType),
+				Description: "What the subscriptions matching the filter cost over the period.",
+				Args:        periodArgs(gql.FieldConfigArgument{"filter": {Type: filterInputType}}),
+				Resolve:     r.cost,
+			},
+			"costBreakdown": {
+				Type:        gql.NewNonNull(gql.NewList(gql.NewNonNull(costLineType))),
+				Description: "The subscriptions matching the filter billed in the period with their share of the cost.",
+				Args:        periodArgs(gql.FieldConfigArgument{"filter": {Type: filterInputType}, "limit": limitArg}),
+				Resolve:     r.costBreakdown,
+			},
+		},
+	})
+
+	mutation := gql.NewObject(gql.ObjectConfig{
+		Name: "Mutation",
+		Fields: gql.Fields{
+			"createSubscription": {
+				Type:    gql.NewNonNull(subscriptionType),
+				Args:    gql.FieldConfigArgument{"input": {Type: gql.NewNonNull(subscriptionInputType)}},
+				Resolve: r.createSubscription,
+			},
+			"updateSubscription": {
+				Type: gql.NewNonNull(subscriptionType),
+				Args: gql.FieldConfigArgument{
+					"id":    {Type: gql.NewNonNull(gql.ID)},
+					"input": {Type: gql.NewNonNull(subscriptionInputType)},
+				},
+				Resolve: r.updateSubscription,
+			},
+			"deleteSubscription": {
+				Type:        gql.NewNonNull(gql.ID),
+				Description: "Deletes the subscription and returns its ID.",
+				Args:        gql.FieldConfigArgument{"id": {Type: gql.NewNonNull(gql.ID)}},
+				Resolve:     r.deleteSubscription,
+			},
+		},
+	})
+
+	return gql.NewSchema(gql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func subscriptionField(get func(model.Subscription) interface{}) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(model.Subscription)), nil
+	}
+}
+
+func userField(get func(uuid.UUID) interface{}) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(uuid.UUID)), nil
+	}
+}
+
+func costLineField(get func(model.CostLine) interface{}) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(model.CostLine)), nil
+	}
+}
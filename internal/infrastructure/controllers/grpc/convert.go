@@ -0,0 +1,210 @@
+package grpc
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	subscriptionv1 "Subscription_Service/api/subscription/v1"
+	"Subscription_Service/internal/domain/filterexpr"
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+var matchModes = map[subscriptionv1.MatchMode]model.MatchMode{
+	subscriptionv1.MatchMode_MATCH_MODE_UNSPECIFIED: model.MatchContains,
+	subscriptionv1.MatchMode_MATCH_MODE_EXACT:       model.MatchExact,
+	subscriptionv1.MatchMode_MATCH_MODE_PREFIX:      model.MatchPrefix,
+	subscriptionv1.MatchMode_MATCH_MODE_CONTAINS:    model.MatchContains,
+	subscriptionv1.MatchMode_MATCH_MODE_FUZZY:       model.MatchFuzzy,
+}
+
+func toMessage(s model.Subscription) *subscriptionv1.Subscription {
+	msg := &subscriptionv1.Subscription{
+		Id:          s.ID.String(),
+		ServiceName: s.ServiceName,
+		Price:       int64(s.Price),
+		UserId:      s.UserID.String(),
+		StartDate:   s.StartDate.Format("2006-01-02"),
+		CreatedAt:   timestamppb.New(s.CreatedAt),
+		UpdatedAt:   timestamppb.New(s.UpdatedAt),
+		Version:     int64(s.Version),
+	}
+
+	if !s.EndDate.IsZero() {
+		end := s.EndDate.Format("2006-01-02")
+		msg.EndDate = &end
+	}
+
+	return msg
+}
+
+// applyInput replaces the writable fields of s with those of in, checking them as the
+// REST API checks its request bodies.
+func applyInput(s *model.Subscription, in *subscriptionv1.SubscriptionInput) error {
+	if in == nil {
+		return model.Invalid("subscription", "subscription is required")
+	}
+
+	if n := utf8.RuneCountInString(in.GetServiceName()); n < 2 || n > 100 {
+		return model.Invalid("subscription.service_name", "service_name must be between 2 and 100 characters")
+	}
+
+	if in.GetPrice() < 0 {
+		return model.Invalid("subscription.price", "price must be greater than or equal to 0")
+	}
+
+	userID, err := parseID("subscription.user_id", in.GetUserId())
+	if err != nil {
+		return err
+	}
+
+	start, err := parseDate("subscription.start_date", in.GetStartDate())
+	if err != nil {
+		return err
+	}
+
+	var end time.Time
+
+	if in.EndDate != nil {
+		if end, err = parseDate("subscription.end_date", in.GetEndDate()); err != nil {
+			return err
+		}
+	}
+
+	s.ServiceName = in.GetServiceName()
+	s.Price = int(in.GetPrice())
+	s.UserID = userID
+	s.StartDate = start
+	s.EndDate = end
+
+	return nil
+}
+
+func toFilter(in *subscriptionv1.SubscriptionFilter) (model.SubscriptionFilter, error) {
+	f := model.SubscriptionFilter{
+		ServiceName: model.ServiceNameFilter{Match: model.MatchContains, Threshold: model.DefaultSimilarityThreshold},
+	}
+
+	if in == nil {
+		return f, nil
+	}
+
+	if in.UserId != nil {
+		id, err := parseID("filter.user_id", in.GetUserId())
+		if err != nil {
+			return f, err
+		}
+
+		f.UserID = &id
+	}
+
+	for _, name := range in.GetServiceNames() {
+		if name = strings.TrimSpace(name); name != "" {
+			f.ServiceName.Names = append(f.ServiceName.Names, name)
+		}
+	}
+
+	match, ok := matchModes[in.GetMatch()]
+	if !ok {
+		return f, model.Invalid("filter.match", "unknown match mode %d", in.GetMatch())
+	}
+
+	f.ServiceName.Match = match
+
+	if in.Threshold != nil {
+		f.ServiceName.Threshold = in.GetThreshold()
+	}
+
+	if in.PriceMin != nil {
+		price := int(in.GetPriceMin())
+		f.PriceMin = &price
+	}
+
+	if in.PriceMax != nil {
+		price := int(in.GetPriceMax())
+		f.PriceMax = &price
+	}
+
+	dates := []struct {
+		name  string
+		value *string
+		dst   **time.Time
+	}{
+		{"filter.start_from", in.StartFrom, &f.StartFrom},
+		{"filter.start_to", in.StartTo, &f.StartTo},
+		{"filter.end_from", in.EndFrom, &f.EndFrom},
+		{"filter.end_to", in.EndTo, &f.EndTo},
+		{"filter.active_at", in.ActiveAt, &f.ActiveAt},
+	}
+
+	for _, d := range dates {
+		if d.value == nil {
+			continue
+		}
+
+		date, err := parseDate(d.name, *d.value)
+		if err != nil {
+			return f, err
+		}
+
+		*d.dst = &date
+	}
+
+	f.OpenEnded = in.GetOpenEnded()
+
+	if in.GetExpr() != "" {
+		expr, err := filterexpr.Parse(in.GetExpr())
+		if err != nil {
+			return f, model.Invalid("filter.expr", "invalid filter: %s", err.Error())
+		}
+
+		f.Expr = expr
+	}
+
+	return f, nil
+}
+
+func parseID(field, s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.UUID{}, model.Invalid(field, "invalid %s, expected a UUID", field)
+	}
+
+	return id, nil
+}
+
+func parseDate(field, s string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, model.Invalid(field, "invalid %s format, expected YYYY-MM-DD", field)
+	}
+
+	return t, nil
+}
+
+func parseMonth(field, s string) (time.Time, error) {
+	t, err := time.Parse("2006-01", s)
+	if err != nil {
+		return time.Time{}, model.Invalid(field, "invalid %s format, expected YYYY-MM", field)
+	}
+
+	return t, nil
+}
+
+// asOf converts the optional as_of timestamp of a request.
+func asOf(ts *timestamppb.Timestamp) (*time.Time, error) {
+	if ts == nil {
+		return nil, nil
+	}
+
+	if err := ts.CheckValid(); err != nil {
+		return nil, model.Invalid("as_of", "invalid as_of: %s", err.Error())
+	}
+
+	t := ts.AsTime()
+
+	return &t, nil
+}
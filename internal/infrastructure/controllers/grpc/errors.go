@@ -0,0 +1,46 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+// toStatus maps err to a gRPC status. Validation failures carry a BadRequest detail
+// naming the offending field; errors not recognized as client errors are reported as
+// internal without details, which are logged by the service layer instead.
+func toStatus(err error) error {
+	var validationErr *model.ValidationError
+
+	switch {
+	case errors.As(err, &validationErr):
+		st := status.New(codes.InvalidArgument, validationErr.Message)
+		if validationErr.Field == "" {
+			return st.Err()
+		}
+
+		detailed, detailErr := st.WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: validationErr.Field, Description: validationErr.Message},
+			},
+		})
+		if detailErr != nil {
+			return st.Err()
+		}
+
+		return detailed.Err()
+	case errors.Is(err, model.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	}
+
+	return status.Error(codes.Internal, "an unexpected error occurred")
+}
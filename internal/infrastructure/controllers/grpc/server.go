@@ -0,0 +1,191 @@
+// Package grpc serves the subscription service over gRPC. The handlers delegate to
+// service.Service like the REST handlers do and only convert the protobuf messages.
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	subscriptionv1 "Subscription_Service/api/subscription/v1"
+	"Subscription_Service/internal/application/service"
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+)
+
+type Server struct {
+	subscriptionv1.UnimplementedSubscriptionServiceServer
+
+	service service.Service
+}
+
+func NewServer(serv service.Service) *Server {
+	return &Server{service: serv}
+}
+
+func (s *Server) Create(ctx context.Context, req *subscriptionv1.CreateRequest) (*subscriptionv1.Subscription, error) {
+	var sub model.Subscription
+
+	if err := applyInput(&sub, req.GetSubscription()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	if err := s.service.Create(ctx, &sub); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toMessage(sub), nil
+}
+
+func (s *Server) Read(ctx context.Context, req *subscriptionv1.ReadRequest) (*subscriptionv1.Subscription, error) {
+	id, err := parseID("id", req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	at, err := asOf(req.GetAsOf())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	sub, err := s.service.Read(ctx, id, at, nil)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toMessage(*sub), nil
+}
+
+func (s *Server) Update(ctx context.Context, req *subscriptionv1.UpdateRequest) (*subscriptionv1.Subscription, error) {
+	id, err := parseID("id", req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	sub, err := s.service.Read(ctx, id, nil, nil)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	if err := applyInput(sub, req.GetSubscription()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	if err := s.service.Update(ctx, sub); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toMessage(*sub), nil
+}
+
+func (s *Server) Delete(ctx context.Context, req *subscriptionv1.DeleteRequest) (*emptypb.Empty, error) {
+	id, err := parseID("id", req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	if err := s.service.Delete(ctx, id); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) List(ctx context.Context, req *subscriptionv1.ListRequest) (*subscriptionv1.ListResponse, error) {
+	filter, _ := toFilter(nil)
+
+	return s.page(ctx, filter, req.GetPage(), req.GetAsOf(), s.service.List)
+}
+
+func (s *Server) FindFiltered(ctx context.Context, req *subscriptionv1.FindFilteredRequest) (*subscriptionv1.ListResponse, error) {
+	filter, err := toFilter(req.GetFilter())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return s.page(ctx, filter, req.GetPage(), req.GetAsOf(), s.service.FindFiltered)
+}
+
+func (s *Server) CalculateCost(ctx context.Context, req *subscriptionv1.CalculateCostRequest) (*subscriptionv1.CalculateCostResponse, error) {
+	start, err := parseMonth("start_month", req.GetStartMonth())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	end, err := parseMonth("end_month", req.GetEndMonth())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	filter, err := toFilter(req.GetFilter())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	at, err := asOf(req.GetAsOf())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	total, err := s.service.CalculateCost(ctx, filter, start, end, at)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &subscriptionv1.CalculateCostResponse{Total: total}, nil
+}
+
+type findFunc func(ctx context.Context, filter model.SubscriptionFilter, page model.PageRequest, asOf *time.Time) (model.SubscriptionPage, error)
+
+// page reads a keyset page of the subscriptions matching filter with find. Page
+// tokens are the cursors of the REST API, bound to the sort order they were issued
+// for.
+func (s *Server) page(ctx context.Context, filter model.SubscriptionFilter, p *subscriptionv1.Page, ts *timestamppb.Timestamp, find findFunc) (*subscriptionv1.ListResponse, error) {
+	for _, field := range p.GetSort() {
+		field = strings.TrimSpace(field)
+		filter.Sort = append(filter.Sort, model.SortField{Field: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")})
+	}
+
+	page := model.PageRequest{Limit: int(p.GetPageSize())}
+	if page.Limit == 0 {
+		page.Limit = model.DefaultPageLimit
+	}
+
+	sortKey := model.SortKey(filter.SortOrDefault())
+
+	if p.GetPageToken() != "" {
+		after, err := dto.DecodeCursor(p.GetPageToken(), sortKey)
+		if err != nil {
+			return nil, toStatus(model.Invalid("page.page_token", "%s", err.Error()))
+		}
+
+		page.After = after
+	}
+
+	at, err := asOf(ts)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	result, err := find(ctx, filter, page, at)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &subscriptionv1.ListResponse{
+		Subscriptions: make([]*subscriptionv1.Subscription, 0, len(result.Subscriptions)),
+	}
+
+	for _, sub := range result.Subscriptions {
+		resp.Subscriptions = append(resp.Subscriptions, toMessage(sub))
+	}
+
+	if result.Next != nil {
+		resp.NextPageToken = dto.EncodeCursor(*result.Next, sortKey)
+	}
+
+	return resp, nil
+}
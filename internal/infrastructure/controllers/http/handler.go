@@ -1,15 +1,34 @@
 package http
 
 import (
+	"log/slog"
+	"time"
+
 	"Subscription_Service/internal/application/service"
+	"Subscription_Service/internal/infrastructure/controllers/graphql"
 )
 
+// Config holds the presentation defaults of the handlers.
+type Config struct {
+	// ExportDateLayout is the time layout of the dates in exports unless the request
+	// asks for another format.
+	ExportDateLayout string
+	// StreamHeartbeat is how often an idle event stream sends a heartbeat.
+	StreamHeartbeat time.Duration
+}
+
 type Handler struct {
 	service service.Service
+	graphql *graphql.Executor
+	cfg     Config
+	logger  *slog.Logger
 }
 
-func NewHandler(serv service.Service) *Handler {
+func NewHandler(serv service.Service, graphQL *graphql.Executor, cfg Config, logger *slog.Logger) *Handler {
 	return &Handler{
 		service: serv,
+		graphql: graphQL,
+		cfg:     cfg,
+		logger:  logger,
 	}
 }
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// secretQueryParams are the query parameters carrying credentials, such as the token
+// authorizing a calendar feed. Their values are left out of the access log.
+var secretQueryParams = []string{"token"}
+
+// AccessLog logs every request the way gin.Logger does, with the values of the secret
+// query parameters replaced.
+func AccessLog() gin.HandlerFunc {
+	return gin.LoggerWithConfig(gin.LoggerConfig{Formatter: accessLogFormatter})
+}
+
+func accessLogFormatter(p gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if p.IsOutputColor() {
+		statusColor = p.StatusCodeColor()
+		methodColor = p.MethodColor()
+		resetColor = p.ResetColor()
+	}
+
+	if p.Latency > time.Minute {
+		p.Latency = p.Latency.Truncate(time.Second)
+	}
+
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, p.StatusCode, resetColor,
+		p.Latency,
+		p.ClientIP,
+		methodColor, p.Method, resetColor,
+		redactQuery(p.Path),
+		p.ErrorMessage,
+	)
+}
+
+// redactQuery replaces the values of the secret query parameters of path. The other
+// parameters are kept as they were sent.
+func redactQuery(path string) string {
+	base, query, ok := strings.Cut(path, "?")
+	if !ok {
+		return path
+	}
+
+	params := strings.Split(query, "&")
+
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+
+		if name, err := url.QueryUnescape(key); err == nil && slices.Contains(secretQueryParams, name) {
+			params[i] = key + "=REDACTED"
+		}
+	}
+
+	return base + "?" + strings.Join(params, "&")
+}
@@ -0,0 +1,143 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+)
+
+const batchModeAtomic = "atomic"
+
+func (h *Handler) Batch(c *gin.Context) {
+	var req dto.BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = batchModeAtomic
+	}
+
+	atomic := req.Mode == batchModeAtomic
+
+	// An oversized batch is refused as a whole, before any operation is looked at.
+	if err := h.service.CheckBatchSize(len(req.Operations)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	results := make([]model.BatchResult, len(req.Operations))
+	ops := make([]model.BatchOperation, 0, len(req.Operations))
+	indexes := make([]int, 0, len(req.Operations))
+
+	for i, o := range req.Operations {
+		op, err := batchOperation(o)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		ops = append(ops, op)
+		indexes = append(indexes, i)
+	}
+
+	if !atomic || len(ops) == len(req.Operations) {
+		applied, err := h.service.Batch(c, ops, atomic)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		for j, r := range applied {
+			results[indexes[j]] = r
+		}
+	}
+
+	resp := toBatchResponse(req, results)
+
+	status := http.StatusOK
+	if atomic && resp.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	c.JSON(status, resp)
+}
+
+// batchOperation checks the shape of an operation and converts it to the domain model.
+func batchOperation(o dto.BatchOperationRequest) (model.BatchOperation, error) {
+	op := model.BatchOperation{Kind: model.BatchOperationKind(o.Op)}
+
+	switch op.Kind {
+	case model.BatchCreate:
+		if o.ID != nil {
+			return op, model.Invalid("id", "id must not be set for create")
+		}
+	case model.BatchUpdate, model.BatchDelete:
+		if o.ID == nil {
+			return op, model.Invalid("id", "id is required for %s", o.Op)
+		}
+
+		op.Subscription.ID = *o.ID
+	default:
+		return op, model.Invalid("op", "unknown op %q, expected create, update or delete", o.Op)
+	}
+
+	if op.Kind == model.BatchDelete {
+		if o.Subscription != nil {
+			return op, model.Invalid("subscription", "subscription must not be set for delete")
+		}
+
+		return op, nil
+	}
+
+	if o.Subscription == nil {
+		return op, model.Invalid("subscription", "subscription is required for %s", o.Op)
+	}
+
+	if err := binding.Validator.ValidateStruct(o.Subscription); err != nil {
+		return op, err
+	}
+
+	replaceFields(&op.Subscription, *o.Subscription)
+
+	return op, nil
+}
+
+func toBatchResponse(req dto.BatchRequest, results []model.BatchResult) dto.BatchResponse {
+	resp := dto.BatchResponse{
+		Mode:    req.Mode,
+		Results: make([]dto.BatchItemResponse, 0, len(results)),
+	}
+
+	for i, r := range results {
+		item := dto.BatchItemResponse{
+			Index:  i,
+			Op:     req.Operations[i].Op,
+			Status: "not_applied",
+		}
+
+		switch {
+		case r.Err != nil:
+			p := classify(r.Err, http.StatusBadRequest)
+			item.Status = "failed"
+			item.Code = p.Code
+			item.Error = p.Detail
+			item.Errors = p.Errors
+			resp.Failed++
+		case r.Applied:
+			sub := toResponse(r.Subscription)
+			item.Status = "applied"
+			item.Subscription = &sub
+			resp.Applied++
+		}
+
+		resp.Results = append(resp.Results, item)
+	}
+
+	return resp
+}
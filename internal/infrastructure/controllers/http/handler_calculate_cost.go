@@ -5,50 +5,59 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 
 	"Subscription_Service/internal/infrastructure/controllers/dto"
 )
 
 func (h *Handler) CalculateCost(c *gin.Context) {
-	startDateStr := c.Query("start_date")
-	endDateStr := c.Query("end_date")
+	ps, pe, ok := costPeriod(c)
+	if !ok {
+		return
+	}
 
-	if startDateStr == "" || endDateStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date required"})
+	filter, err := subscriptionFilter(c)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	ps, err := time.Parse("2006-01", startDateStr)
+	asOf, err := asOfParam(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_date format, expected YYYY-MM"})
+		respondError(c, err)
 		return
 	}
 
-	pe, err := time.Parse("2006-01", endDateStr)
+	total, err := h.service.CalculateCost(c, filter, ps, pe, asOf)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date format, expected YYYY-MM"})
+		respondError(c, err)
 		return
 	}
 
-	var userID *uuid.UUID
-	if v := c.Query("user_id"); v != "" {
-		id, err := uuid.Parse(v)
-		if err == nil {
-			userID = &id
-		}
+	c.JSON(http.StatusOK, dto.CostResponse{Total: total})
+}
+
+// costPeriod parses the YYYY-MM start_date and end_date query parameters, reporting
+// the problem and returning false when they are missing or malformed.
+func costPeriod(c *gin.Context) (time.Time, time.Time, bool) {
+	startDateStr := c.Query("start_date")
+	endDateStr := c.Query("end_date")
+
+	if startDateStr == "" || endDateStr == "" {
+		problem(c, http.StatusBadRequest, codeValidationFailed, "start_date and end_date are required")
+		return time.Time{}, time.Time{}, false
 	}
 
-	var serviceName *string
-	if v := c.Query("service_name"); v != "" {
-		serviceName = &v
+	ps, err := time.Parse("2006-01", startDateStr)
+	if err != nil {
+		invalidParam(c, "start_date", "invalid start_date format, expected YYYY-MM")
+		return time.Time{}, time.Time{}, false
 	}
 
-	total, err := h.service.CalculateCost(c, userID, serviceName, ps, pe)
+	pe, err := time.Parse("2006-01", endDateStr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		invalidParam(c, "end_date", "invalid end_date format, expected YYYY-MM")
+		return time.Time{}, time.Time{}, false
 	}
 
-	c.JSON(http.StatusOK, dto.CostResponse{Total: total})
+	return ps, pe, true
 }
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+	"Subscription_Service/pkg/ical"
+)
+
+const (
+	calendarProdID = "-//Subscription Service//Charges//EN"
+	// calendarUIDDomain makes the event UIDs globally unique, as RFC 5545 asks.
+	calendarUIDDomain = "subscription-service"
+	// calendarRefresh is how often calendar apps are asked to poll the feed.
+	calendarRefresh = 12 * time.Hour
+)
+
+// CalendarToken issues a new feed token for the user, revoking the previous one.
+func (h *Handler) CalendarToken(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		invalidParam(c, "id", "invalid id, expected a UUID")
+		return
+	}
+
+	token, err := h.service.IssueCalendarToken(c, userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, dto.CalendarTokenResponse{
+		Token: token,
+		URL:   calendarURL(c.Request, userID, token),
+	})
+}
+
+// Calendar serves the iCalendar feed of the charges of the user. Calendar apps cannot
+// send credentials in headers, so the feed is authorized by the token in the query.
+func (h *Handler) Calendar(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		invalidParam(c, "id", "invalid id, expected a UUID")
+		return
+	}
+
+	subs, err := h.service.CalendarSubscriptions(c, userID, c.Query("token"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	cal := ical.Calendar{
+		ProdID:          calendarProdID,
+		Name:            "Subscriptions",
+		RefreshInterval: calendarRefresh,
+		Events:          make([]ical.Event, 0, len(subs)*2),
+	}
+
+	for _, s := range subs {
+		cal.Events = append(cal.Events, calendarEvents(s)...)
+	}
+
+	c.Header("Content-Type", ical.ContentType)
+	c.Header("Content-Disposition", `inline; filename="calendar.ics"`)
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Status(http.StatusOK)
+
+	_ = ical.Write(c.Writer, cal)
+}
+
+// calendarEvents returns the monthly charge of s, recurring until its end date, and
+// the end of s when it has one. The UIDs are derived from the subscription id, and the
+// sequence from its version, so that an edited subscription replaces its events.
+func calendarEvents(s model.Subscription) []ical.Event {
+	price := strconv.Itoa(s.Price) + " ₽"
+
+	rrule := "FREQ=MONTHLY;" + monthDayRule(s.StartDate.Day())
+	if !s.EndDate.IsZero() {
+		rrule += ";UNTIL=" + ical.Date(s.EndDate)
+	}
+
+	events := []ical.Event{{
+		UID:         fmt.Sprintf("%s-charge@%s", s.ID, calendarUIDDomain),
+		Sequence:    s.Version,
+		Stamp:       s.UpdatedAt,
+		Date:        s.StartDate,
+		Summary:     fmt.Sprintf("%s — %s", s.ServiceName, price),
+		Description: fmt.Sprintf("Monthly charge of %s for %s.", price, s.ServiceName),
+		RRule:       rrule,
+	}}
+
+	if !s.EndDate.IsZero() {
+		events = append(events, ical.Event{
+			UID:         fmt.Sprintf("%s-end@%s", s.ID, calendarUIDDomain),
+			Sequence:    s.Version,
+			Stamp:       s.UpdatedAt,
+			Date:        s.EndDate,
+			Summary:     fmt.Sprintf("%s ends", s.ServiceName),
+			Description: fmt.Sprintf("The subscription to %s ends.", s.ServiceName),
+		})
+	}
+
+	return events
+}
+
+// monthDayRule recurs on the given day of the month. A day past the 28th falls on the
+// last day of the months too short to have it, as a charge on the 31st does.
+func monthDayRule(day int) string {
+	if day <= 28 {
+		return "BYMONTHDAY=" + strconv.Itoa(day)
+	}
+
+	days := make([]string, 0, 4)
+	for d := 28; d <= day; d++ {
+		days = append(days, strconv.Itoa(d))
+	}
+
+	return "BYMONTHDAY=" + strings.Join(days, ",") + ";BYSETPOS=-1"
+}
+
+// calendarURL returns the absolute address of the feed as the client reached the
+// service, honoring X-Forwarded-Proto set by a TLS-terminating proxy.
+func calendarURL(r *http.Request, userID uuid.UUID, token string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     r.Host,
+		Path:     fmt.Sprintf("%s/users/%s/calendar.ics", currentAPIPrefix, userID),
+		RawQuery: url.Values{"token": {token}}.Encode(),
+	}
+
+	return u.String()
+}
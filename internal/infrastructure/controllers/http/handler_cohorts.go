@@ -0,0 +1,29 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) Cohorts(c *gin.Context) {
+	ps, pe, err := statsPeriod(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	serviceName, err := serviceNameFilter(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	report, err := h.service.Cohorts(c, serviceName, ps, pe)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toCohortReportResponse(*report))
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+// subscriptionETag identifies the representation of a subscription at its version. The
+// update time tells apart a subscription recreated with the same ID.
+func subscriptionETag(s model.Subscription) string {
+	return fmt.Sprintf(`"%d-%x"`, s.Version, s.UpdatedAt.UnixNano())
+}
+
+// collectionETag identifies the state of the subscriptions a listing was computed
+// from. The listing is the same for the same URL as long as nothing changed. The
+// change counter moves in commit order, so unlike a time it cannot miss a write that
+// committed after a later one.
+func collectionETag(change model.Change) string {
+	return fmt.Sprintf(`"%x"`, change.Seq)
+}
+
+// notModified sets the ETag and Last-Modified validators of the response and answers
+// 304 if the conditional headers of the request show that the client has the current
+// representation. If-None-Match takes precedence over If-Modified-Since (RFC 9110,
+// section 13.2.2). A zero modified omits Last-Modified.
+func notModified(c *gin.Context, etag string, modified time.Time) bool {
+	c.Header("ETag", etag)
+
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		if !etagMatches(inm, etag) {
+			return false
+		}
+	} else {
+		ims, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+		if err != nil || modified.IsZero() || modified.Truncate(time.Second).After(ims) {
+			return false
+		}
+	}
+
+	c.Status(http.StatusNotModified)
+
+	return true
+}
+
+// etagMatches reports whether the If-None-Match list contains etag, using the weak
+// comparison.
+func etagMatches(list, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
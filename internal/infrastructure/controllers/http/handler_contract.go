@@ -0,0 +1,140 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+	"Subscription_Service/pkg/openapi"
+)
+
+// ContractConfig enforces the served OpenAPI document on the API.
+type ContractConfig struct {
+	Spec *openapi.Validator
+	// ValidateRequests rejects requests that do not match their documented operation.
+	ValidateRequests bool
+	// ValidateResponses logs the responses that do not match their documented
+	// operation. It buffers every documented response and is meant for development.
+	ValidateResponses bool
+}
+
+// ValidateContract checks the requests, and optionally the responses, of the routes
+// that the OpenAPI document describes. Routes it does not describe pass through
+// unchecked. It runs after the body limit of a route, so that a body it buffers is
+// bounded.
+func ValidateContract(cfg ContractConfig, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := specPath(c.FullPath())
+
+		op := cfg.Spec.Operation(c.Request.Method, path)
+		if op == nil {
+			c.Next()
+			return
+		}
+
+		if cfg.ValidateRequests && !validateRequest(c, op) {
+			return
+		}
+
+		// Streams and files are not buffered; their responses are not JSON anyway.
+		if !cfg.ValidateResponses || !op.ChecksResponses() {
+			c.Next()
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		c.Next()
+
+		if violations := op.ValidateResponse(w.Status(), w.body.Bytes()); len(violations) > 0 {
+			logger.Error("Response does not match the API specification",
+				slog.String("method", c.Request.Method),
+				slog.String("path", path),
+				slog.Int("status", w.Status()),
+				slog.Any("violations", violations),
+			)
+		}
+	}
+}
+
+// validateRequest reports the problem and returns false when the request does not
+// match op.
+func validateRequest(c *gin.Context, op *openapi.Operation) bool {
+	if c.Request.ContentLength != 0 && !op.Consumes(c.ContentType()) {
+		if c.Request.Method == http.MethodPatch {
+			c.Header("Accept-Patch", strings.Join(op.MediaTypes(), ", "))
+		}
+
+		problem(c, http.StatusUnsupportedMediaType, codeUnsupportedMediaType,
+			"unsupported media type, expected "+strings.Join(op.MediaTypes(), " or "))
+		return false
+	}
+
+	// Only a body checked against its schema is buffered, so that an upload reaches
+	// its handler as a stream.
+	var body []byte
+
+	if op.ChecksBody(c.ContentType()) {
+		var err error
+
+		body, err = io.ReadAll(c.Request.Body)
+		if err != nil {
+			readError(c, err)
+			return false
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	pathParams := make(map[string]string, len(c.Params))
+	for _, p := range c.Params {
+		pathParams[p.Key] = p.Value
+	}
+
+	violations, err := op.ValidateRequest(c.Request, pathParams, body)
+	if errors.Is(err, openapi.ErrMalformedBody) {
+		problem(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return false
+	}
+
+	if len(violations) == 0 {
+		return true
+	}
+
+	fieldErrors := make([]dto.FieldErrorResponse, 0, len(violations))
+	for _, v := range violations {
+		field := v.Field
+		if field == "" {
+			field = "body"
+		}
+
+		fieldErrors = append(fieldErrors, dto.FieldErrorResponse{Field: field, Message: v.Message})
+	}
+
+	problem(c, http.StatusBadRequest, codeValidationFailed, "the request does not match the API specification", fieldErrors...)
+
+	return false
+}
+
+// specPath converts a route of the router, such as /api/v1/subscriptions/:id, to the
+// path template of the OpenAPI document, /subscriptions/{id}. The legacy routes at
+// the root map to the same templates as the current version.
+func specPath(route string) string {
+	route = strings.TrimPrefix(route, currentAPIPrefix)
+
+	segments := strings.Split(route, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -12,7 +12,7 @@ import (
 func (h *Handler) Create(c *gin.Context) {
 	var req dto.CreateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		bindError(c, err)
 		return
 	}
 
@@ -28,7 +28,7 @@ func (h *Handler) Create(c *gin.Context) {
 	}
 
 	if err := h.service.Create(c, &sub); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -10,12 +10,12 @@ import (
 func (h *Handler) Delete(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID"})
+		invalidParam(c, "id", "invalid id, expected a UUID")
 		return
 	}
 
 	if err := h.service.Delete(c, id); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -0,0 +1,227 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+	"Subscription_Service/pkg/jsonpatch"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem codes are part of the API contract: clients branch on them, so they must
+// not change once published.
+const (
+	codeInvalidRequest           = "invalid_request"
+	codeValidationFailed         = "validation_failed"
+	codeNotFound                 = "not_found"
+	codeRouteNotFound            = "route_not_found"
+	codeMethodNotAllowed         = "method_not_allowed"
+	codeUnsupportedMediaType     = "unsupported_media_type"
+	codeInvalidPatch             = "invalid_patch"
+	codePatchFailed              = "patch_failed"
+	codePatchTestFailed          = "patch_test_failed"
+	codeBatchTooLarge            = "batch_too_large"
+	codeImportTooLarge           = "import_too_large"
+	codeRequestTooLarge          = "request_too_large"
+	codeInvalidCSV               = "invalid_csv"
+	codeIdempotencyKeyReused     = "idempotency_key_reused"
+	codeIdempotencyKeyInProgress = "idempotency_key_in_progress"
+	codeInvalidCalendarToken     = "invalid_calendar_token"
+	codeDeliveryNotDead          = "delivery_not_dead"
+	codeInternal                 = "internal_error"
+)
+
+func init() {
+	// Report validation failures with the JSON names of the fields.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+
+			if name == "" {
+				return f.Name
+			}
+
+			return name
+		})
+	}
+}
+
+// problem renders an RFC 7807 response and aborts the request.
+func problem(c *gin.Context, status int, code, detail string, fieldErrors ...dto.FieldErrorResponse) {
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(status, dto.Problem{
+		Type:     "urn:subscription-service:problem:" + code,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Code:     code,
+		Errors:   fieldErrors,
+	})
+}
+
+// respondError renders err as a problem. Validation failures are reported with
+// status 400.
+func respondError(c *gin.Context, err error) {
+	respondErrorStatus(c, err, http.StatusBadRequest)
+}
+
+// respondErrorStatus renders err as a problem, reporting validation failures with
+// validationStatus.
+func respondErrorStatus(c *gin.Context, err error, validationStatus int) {
+	p := classify(err, validationStatus)
+	problem(c, p.Status, p.Code, p.Detail, p.Errors...)
+}
+
+// classify maps err to the status, code and detail of its problem. Errors not
+// recognized as client errors are reported as internal without details, which are
+// logged by the service layer instead.
+func classify(err error, validationStatus int) dto.Problem {
+	var (
+		validationErrs validator.ValidationErrors
+		validationErr  *model.ValidationError
+	)
+
+	switch {
+	case errors.As(err, &validationErrs):
+		fieldErrors := make([]dto.FieldErrorResponse, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fieldErrors = append(fieldErrors, toFieldError(fe))
+		}
+
+		return dto.Problem{Status: validationStatus, Code: codeValidationFailed, Detail: "the request has invalid fields", Errors: fieldErrors}
+	case errors.As(err, &validationErr):
+		p := dto.Problem{Status: validationStatus, Code: codeValidationFailed, Detail: validationErr.Message}
+		if validationErr.Field != "" {
+			p.Errors = []dto.FieldErrorResponse{{Field: validationErr.Field, Message: validationErr.Message}}
+		}
+
+		return p
+	case errors.Is(err, model.ErrNotFound):
+		return dto.Problem{Status: http.StatusNotFound, Code: codeNotFound, Detail: err.Error()}
+	case errors.Is(err, model.ErrBatchTooLarge):
+		return dto.Problem{Status: http.StatusRequestEntityTooLarge, Code: codeBatchTooLarge, Detail: err.Error()}
+	case errors.Is(err, model.ErrImportTooLarge):
+		return dto.Problem{Status: http.StatusRequestEntityTooLarge, Code: codeImportTooLarge, Detail: err.Error()}
+	case errors.Is(err, model.ErrIdempotencyKeyReused):
+		return dto.Problem{Status: http.StatusUnprocessableEntity, Code: codeIdempotencyKeyReused, Detail: err.Error()}
+	case errors.Is(err, model.ErrIdempotencyKeyInProgress):
+		return dto.Problem{Status: http.StatusConflict, Code: codeIdempotencyKeyInProgress, Detail: err.Error()}
+	case errors.Is(err, model.ErrInvalidCalendarToken):
+		return dto.Problem{Status: http.StatusForbidden, Code: codeInvalidCalendarToken, Detail: err.Error()}
+	case errors.Is(err, model.ErrDeliveryNotDead):
+		return dto.Problem{Status: http.StatusConflict, Code: codeDeliveryNotDead, Detail: err.Error()}
+	case errors.Is(err, jsonpatch.ErrInvalidPatch):
+		return dto.Problem{Status: http.StatusBadRequest, Code: codeInvalidPatch, Detail: err.Error()}
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return dto.Problem{Status: http.StatusConflict, Code: codePatchTestFailed, Detail: err.Error()}
+	}
+
+	return dto.Problem{Status: http.StatusInternalServerError, Code: codeInternal, Detail: "an unexpected error occurred"}
+}
+
+// bindError reports a request body that could not be bound: a validation failure or
+// a body that is not valid JSON for the request type.
+func bindError(c *gin.Context, err error) {
+	var (
+		validationErrs validator.ValidationErrors
+		typeErr        *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &validationErrs):
+		respondError(c, err)
+	case errors.As(err, &typeErr):
+		problem(c, http.StatusBadRequest, codeInvalidRequest, "malformed request body",
+			dto.FieldErrorResponse{Field: typeErr.Field, Message: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value)})
+	default:
+		problem(c, http.StatusBadRequest, codeInvalidRequest, "malformed request body: "+err.Error())
+	}
+}
+
+// readError reports a request body that could not be read, telling an oversized body
+// apart from a broken connection.
+func readError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		problem(c, http.StatusRequestEntityTooLarge, codeRequestTooLarge,
+			fmt.Sprintf("request body is larger than %d bytes", tooLarge.Limit))
+		return
+	}
+
+	problem(c, http.StatusBadRequest, codeInvalidRequest, "failed to read request body")
+}
+
+// invalidParam reports a malformed query or path parameter.
+func invalidParam(c *gin.Context, name, message string) {
+	problem(c, http.StatusBadRequest, codeValidationFailed, message, dto.FieldErrorResponse{Field: name, Message: message})
+}
+
+func toFieldError(fe validator.FieldError) dto.FieldErrorResponse {
+	// Drop the name of the validated struct from the namespace.
+	field := fe.Namespace()
+	if _, rest, ok := strings.Cut(field, "."); ok {
+		field = rest
+	}
+
+	var message string
+
+	switch fe.Tag() {
+	case "required":
+		message = "is required"
+	case "min":
+		message = "must be at least " + fe.Param() + lengthUnit(fe)
+	case "max":
+		message = "must be at most " + fe.Param() + lengthUnit(fe)
+	case "gte":
+		message = "must be greater than or equal to " + fe.Param()
+	case "lte":
+		message = "must be less than or equal to " + fe.Param()
+	case "oneof":
+		message = "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		message = "failed the " + fe.Tag() + " check"
+	}
+
+	return dto.FieldErrorResponse{Field: field, Message: message}
+}
+
+func lengthUnit(fe validator.FieldError) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	}
+
+	return ""
+}
+
+// NoRoute reports requests to unknown paths.
+func (h *Handler) NoRoute(c *gin.Context) {
+	problem(c, http.StatusNotFound, codeRouteNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path)
+}
+
+// NoMethod reports requests with a method the path does not support.
+func (h *Handler) NoMethod(c *gin.Context) {
+	problem(c, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method "+c.Request.Method+" is not allowed for "+c.Request.URL.Path)
+}
+
+// Recovered reports a panic recovered while handling the request.
+func (h *Handler) Recovered(c *gin.Context, _ any) {
+	problem(c, http.StatusInternalServerError, codeInternal, "an unexpected error occurred")
+}
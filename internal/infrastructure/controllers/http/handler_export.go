@@ -0,0 +1,226 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+	"Subscription_Service/pkg/tabular"
+)
+
+const (
+	exportFormatCSV  = "csv"
+	exportFormatXLSX = "xlsx"
+
+	// exportWriteTimeout replaces the server write timeout for exports, which stream
+	// for as long as the table takes to read.
+	exportWriteTimeout = 10 * time.Minute
+)
+
+// exportCell renders a column of a cost line in the date layout of the export.
+// Subscription exports go through the same columns with an empty cost.
+type exportCell func(l model.CostLine, layout string) interface{}
+
+var exportCells = map[string]exportCell{
+	"id":           func(l model.CostLine, _ string) interface{} { return l.ID.String() },
+	"service_name": func(l model.CostLine, _ string) interface{} { return l.ServiceName },
+	"price":        func(l model.CostLine, _ string) interface{} { return l.Price },
+	"user_id":      func(l model.CostLine, _ string) interface{} { return l.UserID.String() },
+	"start_date":   func(l model.CostLine, layout string) interface{} { return l.StartDate.Format(layout) },
+	"end_date": func(l model.CostLine, layout string) interface{} {
+		if l.EndDate.IsZero() {
+			return nil
+		}
+
+		return l.EndDate.Format(layout)
+	},
+	"created_at": func(l model.CostLine, layout string) interface{} { return l.CreatedAt.Format(layout + " 15:04:05") },
+	"updated_at": func(l model.CostLine, layout string) interface{} { return l.UpdatedAt.Format(layout + " 15:04:05") },
+	"months":     func(l model.CostLine, _ string) interface{} { return l.Months },
+	"cost":       func(l model.CostLine, _ string) interface{} { return l.Cost },
+}
+
+var (
+	subscriptionExportColumns = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "created_at", "updated_at"}
+	costExportColumns         = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "months", "cost"}
+)
+
+// exportParams are the output options shared by the exports.
+type exportParams struct {
+	format  string
+	columns []string
+	layout  string
+}
+
+// Export streams the subscriptions matching the list filters as CSV or XLSX.
+func (h *Handler) Export(c *gin.Context) {
+	params, ok := h.exportParams(c, subscriptionExportColumns)
+	if !ok {
+		return
+	}
+
+	filter, err := subscriptionFilter(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	asOf, err := asOfParam(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	h.export(c, params, "subscriptions", func(write func(model.CostLine) error) error {
+		return h.service.Export(c, filter, asOf, func(s model.Subscription) error {
+			return write(model.CostLine{Subscription: s})
+		})
+	})
+}
+
+// ExportCost streams the cost of every subscription billed in the period, the
+// breakdown of the total returned by CalculateCost.
+func (h *Handler) ExportCost(c *gin.Context) {
+	ps, pe, ok := costPeriod(c)
+	if !ok {
+		return
+	}
+
+	params, ok := h.exportParams(c, costExportColumns)
+	if !ok {
+		return
+	}
+
+	filter, err := subscriptionFilter(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	asOf, err := asOfParam(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	name := fmt.Sprintf("cost_%s_%s", ps.Format("2006-01"), pe.Format("2006-01"))
+
+	h.export(c, params, name, func(write func(model.CostLine) error) error {
+		return h.service.ExportCost(c, filter, ps, pe, asOf, write)
+	})
+}
+
+// exportParams parses the format, columns and date_format query parameters. Columns
+// default to all of the available ones, in their order.
+func (h *Handler) exportParams(c *gin.Context, available []string) (exportParams, bool) {
+	params := exportParams{
+		format:  c.DefaultQuery("format", exportFormatCSV),
+		columns: available,
+		layout:  h.cfg.ExportDateLayout,
+	}
+
+	if params.format != exportFormatCSV && params.format != exportFormatXLSX {
+		invalidParam(c, "format", "format must be csv or xlsx")
+		return params, false
+	}
+
+	if v := c.Query("columns"); v != "" {
+		params.columns = strings.Split(v, ",")
+
+		for i, col := range params.columns {
+			params.columns[i] = strings.TrimSpace(col)
+
+			if !slices.Contains(available, params.columns[i]) {
+				invalidParam(c, "columns", fmt.Sprintf("unknown column %q, expected one of %s", col, strings.Join(available, ", ")))
+				return params, false
+			}
+		}
+	}
+
+	if v := c.Query("date_format"); v != "" {
+		layout, err := dto.DateLayout(v)
+		if err != nil {
+			invalidParam(c, "date_format", err.Error())
+			return params, false
+		}
+
+		params.layout = layout
+	}
+
+	return params, true
+}
+
+// export writes the rows produced by run as a file named name. The response starts
+// with the first row, so a failure before it, such as an invalid filter, is still
+// reported as a problem. A failure after it can only cut the file short: an XLSX
+// file is then left without its closing parts and does not open.
+func (h *Handler) export(c *gin.Context, params exportParams, name string, run func(write func(model.CostLine) error) error) {
+	var w tabular.Writer
+
+	start := func() error {
+		_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, params.format))
+
+		var err error
+
+		if params.format == exportFormatXLSX {
+			c.Header("Content-Type", tabular.XLSXContentType)
+			c.Status(http.StatusOK)
+			w, err = tabular.NewXLSX(c.Writer, name)
+		} else {
+			c.Header("Content-Type", tabular.CSVContentType)
+			c.Status(http.StatusOK)
+			w = tabular.NewCSV(c.Writer)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		header := make([]interface{}, len(params.columns))
+		for i, col := range params.columns {
+			header[i] = col
+		}
+
+		return w.WriteRow(header)
+	}
+
+	row := make([]interface{}, len(params.columns))
+
+	err := run(func(l model.CostLine) error {
+		if w == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		for i, col := range params.columns {
+			row[i] = exportCells[col](l, params.layout)
+		}
+
+		return w.WriteRow(row)
+	})
+
+	if err == nil && w == nil {
+		err = start()
+	}
+
+	if err != nil {
+		if w == nil {
+			respondError(c, err)
+		}
+
+		c.Abort()
+
+		return
+	}
+
+	_ = w.Close()
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+)
+
+// expandCost embeds the cost of the subscription for the current month, or for the
+// month of as_of.
+const expandCost = "cost"
+
+var expansions = []string{expandCost}
+
+// representation is the shape of the subscription responses requested with the fields
+// and expand query parameters.
+type representation struct {
+	fields []string
+	cost   bool
+	month  time.Time
+}
+
+func representationParams(c *gin.Context, asOf *time.Time) (representation, error) {
+	r := representation{month: time.Now().UTC()}
+	if asOf != nil {
+		r.month = asOf.UTC()
+	}
+
+	for _, f := range splitList(c.Query("fields")) {
+		if !slices.Contains(dto.SubscriptionFields, f) {
+			return r, model.Invalid("fields", "unknown field %q, expected one of %s", f, strings.Join(dto.SubscriptionFields, ", "))
+		}
+
+		r.fields = append(r.fields, f)
+	}
+
+	for _, e := range splitList(c.Query("expand")) {
+		if !slices.Contains(expansions, e) {
+			return r, model.Invalid("expand", "cannot expand %q, expected one of %s", e, strings.Join(expansions, ", "))
+		}
+
+		r.cost = true
+	}
+
+	return r, nil
+}
+
+// columns returns the fields to read from the database, nil for all of them.
+func (r representation) columns() []string {
+	if len(r.fields) == 0 {
+		return nil
+	}
+
+	columns := slices.Clone(r.fields)
+	if r.cost {
+		columns = append(columns, "price", "start_date", "end_date")
+	}
+
+	return columns
+}
+
+func (r representation) render(s model.Subscription) dto.SubscriptionResponse {
+	resp := toResponse(s)
+
+	if r.cost {
+		resp.Cost = &dto.MonthCostResponse{
+			Month:  r.month.Format("2006-01"),
+			Amount: s.MonthCost(r.month),
+		}
+	}
+
+	if len(r.fields) > 0 {
+		resp = resp.Only(r.fields)
+	}
+
+	return resp
+}
+
+// validators adjusts the ETag and Last-Modified of the data for the representation:
+// the embedded cost also changes when the month does.
+func (r representation) validators(etag string, modified time.Time) (string, time.Time) {
+	if !r.cost {
+		return etag, modified
+	}
+
+	month := time.Date(r.month.Year(), r.month.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return strings.TrimSuffix(etag, `"`) + "-" + month.Format("200601") + `"`, maxTime(modified, month)
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+// splitList splits a comma-separated query parameter, dropping empty items.
+func splitList(v string) []string {
+	var items []string
+
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+)
+
+// maxGraphQLBytes bounds the size of a GraphQL request.
+const maxGraphQLBytes = 1 << 20
+
+// GraphQL runs a GraphQL query or mutation. As usual for GraphQL over HTTP, a request
+// that could be read is answered with status 200, the errors of the query being
+// reported in its result; only a body that is not a GraphQL request is a problem.
+func (h *Handler) GraphQL(c *gin.Context) {
+	var req dto.GraphQLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			readError(c, err)
+			return
+		}
+
+		bindError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.graphql.Execute(c, req.Query, req.OperationName, req.Variables))
+}
@@ -1,6 +1,14 @@
 package http
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"Subscription_Service/internal/domain/filterexpr"
 	model "Subscription_Service/internal/domain/subscription"
 	"Subscription_Service/internal/infrastructure/controllers/dto"
 )
@@ -17,3 +25,273 @@ func toResponse(s model.Subscription) dto.SubscriptionResponse {
 		UpdatedAt:   s.UpdatedAt,
 	}
 }
+
+// toDocument renders s as the document PATCH requests are applied to.
+func toDocument(s model.Subscription) dto.SubscriptionDocument {
+	doc := dto.SubscriptionDocument{
+		ID: s.ID,
+		UpdateSubscriptionRequest: dto.UpdateSubscriptionRequest{
+			ServiceName: s.ServiceName,
+			Price:       s.Price,
+			UserID:      s.UserID,
+			StartDate:   dto.CustomTime{Time: s.StartDate},
+		},
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+
+	if !s.EndDate.IsZero() {
+		doc.EndDate = &dto.CustomTime{Time: s.EndDate}
+	}
+
+	return doc
+}
+
+// replaceFields overwrites the writable fields of s with those of req.
+func replaceFields(s *model.Subscription, req dto.UpdateSubscriptionRequest) {
+	s.ServiceName = req.ServiceName
+	s.Price = req.Price
+	s.UserID = req.UserID
+	s.StartDate = req.StartDate.Time
+	s.EndDate = time.Time{}
+
+	if req.EndDate != nil {
+		s.EndDate = req.EndDate.Time
+	}
+}
+
+func toStatsResponse(s model.Stats) dto.StatsResponse {
+	resp := dto.StatsResponse{
+		PeriodStart:         s.PeriodStart.Format("2006-01"),
+		PeriodEnd:           s.PeriodEnd.Format("2006-01"),
+		ActiveSubscriptions: s.ActiveSubscriptions,
+		DistinctUsers:       s.DistinctUsers,
+		TopBySubscribers:    toServiceStatsResponses(s.TopBySubscribers),
+		TopByRevenue:        toServiceStatsResponses(s.TopByRevenue),
+		Services:            toServiceStatsResponses(s.Services),
+		Monthly:             make([]dto.MonthlyStatsResponse, 0, len(s.Monthly)),
+	}
+
+	for _, m := range s.Monthly {
+		resp.Monthly = append(resp.Monthly, dto.MonthlyStatsResponse{
+			Month: m.Month.Format("2006-01"),
+			New:   m.New,
+			Ended: m.Ended,
+		})
+	}
+
+	return resp
+}
+
+func toServiceStatsResponses(stats []model.ServiceStats) []dto.ServiceStatsResponse {
+	resp := make([]dto.ServiceStatsResponse, 0, len(stats))
+	for _, s := range stats {
+		resp = append(resp, dto.ServiceStatsResponse{
+			ServiceName:  s.ServiceName,
+			Subscribers:  s.Subscribers,
+			Revenue:      s.Revenue,
+			AveragePrice: s.AveragePrice,
+			MedianPrice:  s.MedianPrice,
+		})
+	}
+
+	return resp
+}
+
+func toCohortReportResponse(r model.CohortReport) dto.CohortReportResponse {
+	resp := dto.CohortReportResponse{
+		PeriodStart: r.PeriodStart.Format("2006-01"),
+		PeriodEnd:   r.PeriodEnd.Format("2006-01"),
+		Cohorts:     make([]dto.CohortResponse, 0, len(r.Cohorts)),
+		Lifetimes:   make([]dto.ServiceLifetimeResponse, 0, len(r.Lifetimes)),
+		Churn:       make([]dto.MonthlyChurnResponse, 0, len(r.Churn)),
+	}
+
+	for _, c := range r.Cohorts {
+		resp.Cohorts = append(resp.Cohorts, dto.CohortResponse{
+			Month:     c.Month.Format("2006-01"),
+			Size:      c.Size,
+			Retention: c.Retention,
+		})
+	}
+
+	for _, l := range r.Lifetimes {
+		resp.Lifetimes = append(resp.Lifetimes, dto.ServiceLifetimeResponse{
+			ServiceName:           l.ServiceName,
+			Subscriptions:         l.Subscriptions,
+			Ended:                 l.Ended,
+			AverageLifetimeMonths: l.AverageLifetimeMonths,
+		})
+	}
+
+	for _, m := range r.Churn {
+		resp.Churn = append(resp.Churn, dto.MonthlyChurnResponse{
+			Month:     m.Month.Format("2006-01"),
+			Active:    m.Active,
+			Churned:   m.Churned,
+			ChurnRate: m.ChurnRate,
+		})
+	}
+
+	return resp
+}
+
+// serviceNameFilter reads the repeatable service_name query parameter together with
+// the match mode and the fuzzy similarity threshold.
+func serviceNameFilter(c *gin.Context) (model.ServiceNameFilter, error) {
+	f := model.ServiceNameFilter{
+		Match:     model.MatchMode(c.DefaultQuery("match", string(model.MatchContains))),
+		Threshold: model.DefaultSimilarityThreshold,
+	}
+
+	for _, name := range c.QueryArray("service_name") {
+		if name = strings.TrimSpace(name); name != "" {
+			f.Names = append(f.Names, name)
+		}
+	}
+
+	if v := c.Query("threshold"); v != "" {
+		threshold, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return f, model.Invalid("threshold", "invalid threshold, expected a number between 0 and 1")
+		}
+
+		f.Threshold = threshold
+	}
+
+	if err := f.Validate(); err != nil {
+		return f, err
+	}
+
+	return f, nil
+}
+
+// asOfParam reads the optional RFC 3339 as_of query parameter selecting the point in
+// time the data is reported for.
+func asOfParam(c *gin.Context) (*time.Time, error) {
+	v := c.Query("as_of")
+	if v == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, model.Invalid("as_of", "invalid as_of format, expected RFC 3339 timestamp")
+	}
+
+	return &t, nil
+}
+
+func toInsightsResponse(userID uuid.UUID, insights []model.Insight) dto.InsightsResponse {
+	resp := dto.InsightsResponse{
+		UserID:             userID,
+		Insights:           make([]dto.InsightResponse, 0, len(insights)),
+		TotalMonthlySaving: model.TotalMonthlySaving(insights),
+	}
+
+	for _, i := range insights {
+		resp.Insights = append(resp.Insights, dto.InsightResponse{
+			Kind:            string(i.Kind),
+			ServiceName:     i.ServiceName,
+			SubscriptionIDs: i.SubscriptionIDs,
+			Message:         i.Message,
+			MonthlySaving:   i.MonthlySaving,
+		})
+	}
+
+	return resp
+}
+
+// subscriptionFilter reads the list filters and the sort order from the query string.
+func subscriptionFilter(c *gin.Context) (model.SubscriptionFilter, error) {
+	var f model.SubscriptionFilter
+
+	if v := c.Query("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return f, model.Invalid("user_id", "invalid user_id, expected a UUID")
+		}
+
+		f.UserID = &id
+	}
+
+	serviceName, err := serviceNameFilter(c)
+	if err != nil {
+		return f, err
+	}
+
+	f.ServiceName = serviceName
+
+	prices := []struct {
+		name string
+		dst  **int
+	}{
+		{"price_min", &f.PriceMin},
+		{"price_max", &f.PriceMax},
+	}
+
+	for _, p := range prices {
+		if v := c.Query(p.name); v != "" {
+			price, err := strconv.Atoi(v)
+			if err != nil {
+				return f, model.Invalid(p.name, "invalid %s, expected an integer", p.name)
+			}
+
+			*p.dst = &price
+		}
+	}
+
+	dates := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"start_from", &f.StartFrom},
+		{"start_to", &f.StartTo},
+		{"end_from", &f.EndFrom},
+		{"end_to", &f.EndTo},
+		{"active_at", &f.ActiveAt},
+	}
+
+	for _, d := range dates {
+		if v := c.Query(d.name); v != "" {
+			date, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				return f, model.Invalid(d.name, "invalid %s format, expected YYYY-MM-DD", d.name)
+			}
+
+			*d.dst = &date
+		}
+	}
+
+	if v := c.Query("open_ended"); v != "" {
+		openEnded, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, model.Invalid("open_ended", "invalid open_ended, expected true or false")
+		}
+
+		f.OpenEnded = openEnded
+	}
+
+	if v := c.Query("filter"); v != "" {
+		expr, err := filterexpr.Parse(v)
+		if err != nil {
+			return f, model.Invalid("filter", "invalid filter: %s", err.Error())
+		}
+
+		f.Expr = expr
+	}
+
+	if v := c.Query("sort"); v != "" {
+		for _, field := range strings.Split(v, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			f.Sort = append(f.Sort, model.SortField{Field: strings.TrimPrefix(field, "-"), Desc: desc})
+		}
+	}
+
+	if err := f.Validate(); err != nil {
+		return f, err
+	}
+
+	return f, nil
+}
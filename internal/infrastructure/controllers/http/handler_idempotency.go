@@ -0,0 +1,131 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// Idempotent makes a POST endpoint honor the Idempotency-Key header. The first request
+// with a key is processed and its response stored; a replay with the same body gets
+// the stored response, while the same key with a different body is rejected with 422.
+// Server errors are not stored so that the request can be retried.
+func (h *Handler) Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k := c.GetHeader("Idempotency-Key")
+		if k == "" {
+			c.Next()
+			return
+		}
+
+		if len(k) > maxIdempotencyKeyLength {
+			invalidParam(c, "Idempotency-Key", fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			readError(c, err)
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := model.IdempotencyKey{Key: k, Method: c.Request.Method, Path: c.Request.URL.Path}
+
+		resp, err := h.service.BeginIdempotent(c, key, requestHash(c.Request, body))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		if resp != nil {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(resp.Status, resp.ContentType, resp.Body)
+			c.Abort()
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		// The outcome is stored even if the client has gone away meanwhile.
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		// A panic is recovered further up the chain, past this middleware, so the key is
+		// released while it unwinds; a server error releases it too, so that the
+		// request can be retried.
+		completed := false
+
+		defer func() {
+			if completed {
+				return
+			}
+
+			if err := h.service.ReleaseIdempotent(ctx, key); err != nil {
+				h.logger.Error("Idempotency key stays claimed until its lease expires",
+					slog.String("key", key.Key),
+					slog.String("error", err.Error()),
+				)
+			}
+		}()
+
+		c.Next()
+
+		if w.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		// A response that cannot be stored keeps the key claimed, rather than letting a
+		// retry repeat the request, until the lease expires.
+		completed = true
+
+		err = h.service.CompleteIdempotent(ctx, key, model.IdempotentResponse{
+			Status:      w.Status(),
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        w.body.Bytes(),
+		})
+		if err != nil {
+			h.logger.Error("Idempotent response not stored, the key stays claimed until its lease expires",
+				slog.String("key", key.Key),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.URL.RawQuery))
+	h.Write([]byte{0})
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body written through it.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,290 @@
+package http
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+)
+
+const (
+	importModeDryRun = "dry_run"
+	importModeCommit = "commit"
+
+	// maxImportBytes bounds the size of an uploaded CSV file.
+	maxImportBytes = 10 << 20
+)
+
+// importColumns are the CSV columns an import understands; all but end_date are
+// required.
+var importColumns = []string{"service_name", "price", "user_id", "start_date", "end_date"}
+
+func (h *Handler) Import(c *gin.Context) {
+	mode := c.DefaultQuery("mode", importModeDryRun)
+	if mode != importModeDryRun && mode != importModeCommit {
+		invalidParam(c, "mode", "mode must be dry_run or commit")
+		return
+	}
+
+	body, err := importBody(c)
+	if err != nil {
+		readError(c, err)
+		return
+	}
+
+	if body == nil {
+		problem(c, http.StatusBadRequest, codeInvalidRequest, "expected a text/csv body or a multipart form with a file field")
+		return
+	}
+	defer body.Close()
+
+	rows, err := readImportRows(body)
+	if err != nil {
+		var validationErr *model.ValidationError
+		if errors.As(err, &validationErr) {
+			respondErrorStatus(c, err, http.StatusBadRequest)
+			return
+		}
+
+		readError(c, err)
+		return
+	}
+
+	report, err := h.service.Import(c, rows, mode == importModeCommit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	resp := toImportResponse(mode, report)
+
+	status := http.StatusOK
+	if mode == importModeCommit && !report.Committed && resp.Summary.Create > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	c.JSON(status, resp)
+}
+
+// limitBody caps the request body at n bytes. It runs before any middleware that
+// buffers the body, so an oversized upload is never read in full.
+func limitBody(n int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, n)
+		c.Next()
+	}
+}
+
+// importBody returns the uploaded CSV: the file field of a multipart form or the
+// request body itself. It returns nil when the request carries neither.
+func importBody(c *gin.Context) (io.ReadCloser, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+
+	switch mediaType {
+	case "multipart/form-data":
+		fh, err := c.FormFile("file")
+		if errors.Is(err, http.ErrMissingFile) {
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return fh.Open()
+	case "text/csv", "":
+		return c.Request.Body, nil
+	}
+
+	return nil, nil
+}
+
+// readImportRows parses the CSV. A row that does not convert to a valid subscription
+// request is kept with its error so that it shows up in the report; a malformed file
+// or header aborts the request.
+func readImportRows(body io.Reader) ([]model.ImportRow, error) {
+	r := csv.NewReader(body)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, model.Invalid("header", "the CSV file is empty")
+	}
+
+	if err != nil {
+		return nil, csvError(err)
+	}
+
+	columns, err := importHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []model.ImportRow
+
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		if err != nil {
+			return nil, csvError(err)
+		}
+
+		line, _ := r.FieldPos(0)
+		row := model.ImportRow{Line: line}
+
+		if len(record) != len(columns) {
+			row.Err = model.Invalid("", "row has %d fields, the header has %d", len(record), len(columns))
+		} else {
+			row.Subscription, row.Err = importSubscription(columns, record)
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+// importHeader maps the header to column names, ignoring case, surrounding spaces
+// and a leading byte order mark.
+func importHeader(header []string) ([]string, error) {
+	columns := make([]string, len(header))
+	seen := make(map[string]bool, len(header))
+
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+
+		if !slices.Contains(importColumns, name) {
+			return nil, model.Invalid("header", "unknown column %q", h)
+		}
+
+		if seen[name] {
+			return nil, model.Invalid("header", "column %q is repeated", name)
+		}
+
+		seen[name] = true
+		columns[i] = name
+	}
+
+	for _, name := range importColumns {
+		if name != "end_date" && !seen[name] {
+			return nil, model.Invalid("header", "required column %q is missing", name)
+		}
+	}
+
+	return columns, nil
+}
+
+// importSubscription converts a record to a subscription, applying the same rules
+// as a create request.
+func importSubscription(columns, record []string) (model.Subscription, error) {
+	var req dto.CreateSubscriptionRequest
+
+	for i, name := range columns {
+		v := strings.TrimSpace(record[i])
+		if v == "" {
+			continue
+		}
+
+		switch name {
+		case "service_name":
+			req.ServiceName = v
+		case "price":
+			price, err := strconv.Atoi(v)
+			if err != nil {
+				return model.Subscription{}, model.Invalid(name, "price must be an integer, got %q", v)
+			}
+
+			req.Price = price
+		case "user_id":
+			id, err := uuid.Parse(v)
+			if err != nil {
+				return model.Subscription{}, model.Invalid(name, "user_id must be a UUID, got %q", v)
+			}
+
+			req.UserID = id
+		case "start_date", "end_date":
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				return model.Subscription{}, model.Invalid(name, "%s must be a date in YYYY-MM-DD format, got %q", name, v)
+			}
+
+			if name == "start_date" {
+				req.StartDate = dto.CustomTime{Time: t}
+			} else {
+				req.EndDate = dto.CustomTime{Time: t}
+			}
+		}
+	}
+
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return model.Subscription{}, err
+	}
+
+	return model.Subscription{
+		ServiceName: req.ServiceName,
+		Price:       req.Price,
+		UserID:      req.UserID,
+		StartDate:   req.StartDate.Time,
+		EndDate:     req.EndDate.Time,
+	}, nil
+}
+
+// csvError reports a file that is not valid CSV. Read errors, such as an oversized
+// body, are passed through.
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return model.Invalid("file", "malformed CSV on line %d: %s", parseErr.Line, parseErr.Err)
+	}
+
+	return err
+}
+
+func toImportResponse(mode string, report *model.ImportReport) dto.ImportResponse {
+	resp := dto.ImportResponse{
+		Mode:      mode,
+		Committed: report.Committed,
+		Rows:      make([]dto.ImportRowResponse, 0, len(report.Rows)),
+	}
+
+	for _, r := range report.Rows {
+		row := dto.ImportRowResponse{Line: r.Line, Status: string(r.Status)}
+
+		switch r.Status {
+		case model.ImportRejected:
+			p := classify(r.Err, http.StatusBadRequest)
+			row.Code = p.Code
+			row.Error = p.Detail
+			row.Errors = p.Errors
+			resp.Summary.Rejected++
+		case model.ImportDuplicate:
+			resp.Summary.Duplicate++
+		case model.ImportCreate:
+			resp.Summary.Create++
+		}
+
+		if r.Status != model.ImportRejected {
+			sub := toResponse(r.Subscription)
+			row.Subscription = &sub
+		}
+
+		resp.Rows = append(resp.Rows, row)
+	}
+
+	return resp
+}
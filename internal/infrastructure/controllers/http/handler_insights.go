@@ -0,0 +1,24 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) Insights(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		invalidParam(c, "id", "invalid id, expected a UUID")
+		return
+	}
+
+	insights, err := h.service.Insights(c, userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toInsightsResponse(userID, insights))
+}
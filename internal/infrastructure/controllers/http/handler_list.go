@@ -1,59 +1,168 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 
 	model "Subscription_Service/internal/domain/subscription"
 	"Subscription_Service/internal/infrastructure/controllers/dto"
 )
 
+// List returns the subscriptions matching the query filters in the requested sort
+// order, newest first by default. Passing the cursor parameter, empty for the
+// first page, switches from offset pagination, which responds with a bare array, to
+// keyset pagination, which responds with an envelope carrying next_cursor.
+//
+// The validators of the listing come from the time of the last change to any
+// subscription, so a poll answered with 304 Not Modified skips the listing query.
 func (h *Handler) List(c *gin.Context) {
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
-	if err != nil {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(model.DefaultPageLimit)))
+	if err != nil || limit <= 0 || limit > model.MaxPageLimit {
+		invalidParam(c, "limit", fmt.Sprintf("invalid limit, expected an integer between 1 and %d", model.MaxPageLimit))
 		return
 	}
 
 	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		invalidParam(c, "offset", "invalid offset, expected a non-negative integer")
+		return
+	}
+
+	page := model.PageRequest{
+		Limit:      limit,
+		Offset:     offset,
+		CountTotal: c.Query("include_total") == "true",
+	}
+
+	filter, err := subscriptionFilter(c)
 	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	var userID *uuid.UUID
-	if v := c.Query("user_id"); v != "" {
-		if id, err := uuid.Parse(v); err == nil {
-			userID = &id
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+	sortKey := model.SortKey(filter.SortOrDefault())
+
+	cursor, keyset := c.GetQuery("cursor")
+	if keyset && cursor != "" {
+		page.After, err = dto.DecodeCursor(cursor, sortKey)
+		if err != nil {
+			invalidParam(c, "cursor", err.Error())
 			return
 		}
 	}
 
-	var serviceName *string
-	if v := c.Query("service_name"); v != "" {
-		serviceName = &v
+	if keyset && offset != 0 {
+		invalidParam(c, "cursor", "cursor and offset cannot be combined")
+		return
 	}
 
-	var subs []model.Subscription
+	asOf, err := asOfParam(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
-	if userID != nil || serviceName != nil {
-		subs, err = h.service.FindFiltered(c, userID, serviceName, limit, offset)
-	} else {
-		subs, err = h.service.List(c, limit, offset)
+	repr, err := representationParams(c, asOf)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
+	page.Fields = repr.columns()
+
+	// The change counter covers the past states too: a write moves it even when the
+	// state at as_of stays the same, which only costs a needless reload.
+	lastChange, err := h.service.LastChange(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	etag, modified := repr.validators(collectionETag(lastChange), lastChange.At)
+	if notModified(c, etag, modified) {
+		return
+	}
+
+	result, err := h.service.List(c, filter, page, asOf)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
+		return
+	}
+
+	resp := make([]dto.SubscriptionResponse, 0, len(result.Subscriptions))
+	for _, s := range result.Subscriptions {
+		resp = append(resp, repr.render(s))
+	}
+
+	if links := pageLinks(c.Request.URL, page, result, keyset, sortKey); len(links) > 0 {
+		c.Writer.Header().Add("Link", strings.Join(links, ", "))
+	}
+
+	if !keyset {
+		if result.Total != nil {
+			c.Header("X-Total-Count", strconv.FormatInt(*result.Total, 10))
+		}
+
+		c.JSON(http.StatusOK, resp)
 		return
 	}
 
-	resp := make([]dto.SubscriptionResponse, 0, len(subs))
-	for _, s := range subs {
-		resp = append(resp, toResponse(s))
+	list := dto.SubscriptionListResponse{
+		Items: resp,
+		Total: result.Total,
+	}
+
+	if result.Next != nil {
+		list.NextCursor = dto.EncodeCursor(*result.Next, sortKey)
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// pageLinks builds the RFC 8288 links to the pages around the current one, keeping
+// every other query parameter of the request.
+func pageLinks(u *url.URL, page model.PageRequest, result model.SubscriptionPage, keyset bool, sortKey string) []string {
+	link := func(rel string, set func(q url.Values)) string {
+		q := u.Query()
+		set(q)
+
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, u.Path, q.Encode(), rel)
+	}
+
+	var links []string
+
+	if keyset {
+		links = append(links, link("first", func(q url.Values) { q.Set("cursor", "") }))
+
+		if result.Next != nil {
+			next := dto.EncodeCursor(*result.Next, sortKey)
+			links = append(links, link("next", func(q url.Values) { q.Set("cursor", next) }))
+		}
+
+		return links
+	}
+
+	links = append(links, link("first", func(q url.Values) { q.Del("offset") }))
+
+	if page.Offset > 0 {
+		prev := max(page.Offset-page.Limit, 0)
+		links = append(links, link("prev", func(q url.Values) { q.Set("offset", strconv.Itoa(prev)) }))
+	}
+
+	if result.Next != nil {
+		next := page.Offset + page.Limit
+		links = append(links, link("next", func(q url.Values) { q.Set("offset", strconv.Itoa(next)) }))
+	}
+
+	if result.Total != nil && *result.Total > 0 {
+		last := int((*result.Total - 1) / int64(page.Limit) * int64(page.Limit))
+		links = append(links, link("last", func(q url.Values) { q.Set("offset", strconv.Itoa(last)) }))
 	}
 
-	c.JSON(http.StatusOK, resp)
+	return links
 }
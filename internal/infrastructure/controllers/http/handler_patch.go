@@ -0,0 +1,103 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+	"Subscription_Service/pkg/jsonpatch"
+)
+
+// Patch applies a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) document, as
+// selected by the Content-Type, to the subscription and validates the result.
+func (h *Handler) Patch(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		invalidParam(c, "id", "invalid id, expected a UUID")
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+
+	var apply func(doc, patch []byte) ([]byte, error)
+
+	switch mediaType {
+	case jsonpatch.MergePatchContentType:
+		apply = jsonpatch.MergePatch
+	case jsonpatch.JSONPatchContentType:
+		apply = jsonpatch.Apply
+	default:
+		c.Header("Accept-Patch", jsonpatch.MergePatchContentType+", "+jsonpatch.JSONPatchContentType)
+		problem(c, http.StatusUnsupportedMediaType, codeUnsupportedMediaType, "unsupported patch format, expected "+
+			jsonpatch.MergePatchContentType+" or "+jsonpatch.JSONPatchContentType)
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		problem(c, http.StatusBadRequest, codeInvalidRequest, "failed to read request body")
+		return
+	}
+
+	sub, err := h.service.Read(c, id, nil, nil)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	original := toDocument(*sub)
+
+	doc, err := json.Marshal(original)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	patched, err := apply(doc, patch)
+	if err != nil {
+		if errors.Is(err, jsonpatch.ErrInvalidPatch) || errors.Is(err, jsonpatch.ErrTestFailed) {
+			respondError(c, err)
+		} else {
+			problem(c, http.StatusUnprocessableEntity, codePatchFailed, err.Error())
+		}
+
+		return
+	}
+
+	var result dto.SubscriptionDocument
+
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&result); err != nil {
+		problem(c, http.StatusUnprocessableEntity, codePatchFailed, "invalid patched subscription: "+err.Error())
+		return
+	}
+
+	if result.ID != original.ID || !result.CreatedAt.Equal(original.CreatedAt) || !result.UpdatedAt.Equal(original.UpdatedAt) {
+		problem(c, http.StatusUnprocessableEntity, codePatchFailed, "id, created_at and updated_at are read-only")
+		return
+	}
+
+	if err := binding.Validator.ValidateStruct(&result.UpdateSubscriptionRequest); err != nil {
+		respondErrorStatus(c, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	replaceFields(sub, result.UpdateSubscriptionRequest)
+
+	if err := h.service.Update(c, sub); err != nil {
+		respondErrorStatus(c, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	c.JSON(http.StatusOK, toResponse(*sub))
+}
@@ -7,18 +7,37 @@ import (
 	"github.com/google/uuid"
 )
 
+// Read returns the subscription, or 304 Not Modified if the client already has its
+// current version.
 func (h *Handler) Read(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID"})
+		invalidParam(c, "id", "invalid id, expected a UUID")
 		return
 	}
 
-	sub, err := h.service.Read(c, id)
+	asOf, err := asOfParam(c)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, toResponse(*sub))
+	repr, err := representationParams(c, asOf)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	sub, err := h.service.Read(c, id, asOf, repr.columns())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	etag, modified := repr.validators(subscriptionETag(*sub), sub.UpdatedAt)
+	if notModified(c, etag, modified) {
+		return
+	}
+
+	c.JSON(http.StatusOK, repr.render(*sub))
 }
@@ -1,14 +1,153 @@
 package http
 
 import (
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
-func (h *Handler) RegisterRoutes(r *gin.Engine) {
-	r.POST("/subscriptions", h.Create)
-	r.GET("/subscriptions/:id", h.Read)
-	r.PUT("/subscriptions/:id", h.Update)
-	r.DELETE("/subscriptions/:id", h.Delete)
-	r.GET("/subscriptions", h.List)
-	r.GET("/subscriptions/cost", h.CalculateCost)
+// RoutesConfig controls how the API versions are mounted.
+type RoutesConfig struct {
+	// LegacyRoutes keeps the unversioned routes at the root as deprecated aliases of
+	// the current version.
+	LegacyRoutes bool
+	// LegacyDeprecatedAt and LegacySunset are announced on the legacy routes; a zero
+	// sunset is not announced.
+	LegacyDeprecatedAt time.Time
+	LegacySunset       time.Time
+}
+
+// Deprecation announces that a route is deprecated (RFC 9745) and, if Sunset is set,
+// when it will stop working (RFC 8594).
+type Deprecation struct {
+	At     time.Time
+	Sunset time.Time
+	// Successor is the path prefix of the version replacing the route.
+	Successor string
+}
+
+type route struct {
+	method string
+	path   string
+	// maxBody bounds the size of the request body; zero leaves it unbounded.
+	maxBody     int64
+	handlers    []gin.HandlerFunc
+	deprecation *Deprecation
+}
+
+// apiVersion is a set of routes mounted under a common prefix.
+type apiVersion struct {
+	prefix string
+	routes []route
+}
+
+const currentAPIPrefix = "/api/v1"
+
+// RegisterRoutes mounts the API versions, checking their requests against contract.
+func (h *Handler) RegisterRoutes(r *gin.Engine, cfg RoutesConfig, contract ContractConfig) {
+	validate := ValidateContract(contract, h.logger)
+
+	for _, v := range h.versions() {
+		mount(r.Group(v.prefix), v.routes, validate)
+	}
+
+	if cfg.LegacyRoutes {
+		legacy := h.v1Routes()
+		for i := range legacy {
+			legacy[i].deprecation = &Deprecation{
+				At:        cfg.LegacyDeprecatedAt,
+				Sunset:    cfg.LegacySunset,
+				Successor: currentAPIPrefix,
+			}
+		}
+
+		mount(r, legacy, validate)
+	}
+}
+
+// versions lists the mounted API versions. A new version is added with its own route
+// list, which reuses the v1 handlers of the resources it does not change, so that
+// every version shares the service layer.
+func (h *Handler) versions() []apiVersion {
+	return []apiVersion{
+		{prefix: currentAPIPrefix, routes: h.v1Routes()},
+	}
+}
+
+func (h *Handler) v1Routes() []route {
+	return []route{
+		{method: http.MethodPost, path: "/subscriptions", handlers: []gin.HandlerFunc{h.Idempotent(), h.Create}},
+		{method: http.MethodPost, path: "/subscriptions/batch", handlers: []gin.HandlerFunc{h.Idempotent(), h.Batch}},
+		{method: http.MethodPost, path: "/subscriptions/import", maxBody: maxImportBytes, handlers: []gin.HandlerFunc{h.Idempotent(), h.Import}},
+		{method: http.MethodGet, path: "/subscriptions/:id", handlers: []gin.HandlerFunc{h.Read}},
+		{method: http.MethodPut, path: "/subscriptions/:id", handlers: []gin.HandlerFunc{h.Update}},
+		{method: http.MethodPatch, path: "/subscriptions/:id", handlers: []gin.HandlerFunc{h.Patch}},
+		{method: http.MethodDelete, path: "/subscriptions/:id", handlers: []gin.HandlerFunc{h.Delete}},
+		{method: http.MethodGet, path: "/subscriptions", handlers: []gin.HandlerFunc{h.List}},
+		{method: http.MethodGet, path: "/subscriptions/cost", handlers: []gin.HandlerFunc{h.CalculateCost}},
+		{method: http.MethodGet, path: "/subscriptions/cost/export", handlers: []gin.HandlerFunc{h.ExportCost}},
+		{method: http.MethodGet, path: "/subscriptions/export", handlers: []gin.HandlerFunc{h.Export}},
+		{method: http.MethodGet, path: "/subscriptions/search", handlers: []gin.HandlerFunc{h.Search}},
+		{method: http.MethodGet, path: "/subscriptions/stream", handlers: []gin.HandlerFunc{h.Stream}},
+		{method: http.MethodPost, path: "/graphql", maxBody: maxGraphQLBytes, handlers: []gin.HandlerFunc{h.GraphQL}},
+		{method: http.MethodGet, path: "/stats", handlers: []gin.HandlerFunc{h.Stats}},
+		{method: http.MethodGet, path: "/stats/cohorts", handlers: []gin.HandlerFunc{h.Cohorts}},
+		{method: http.MethodGet, path: "/users/:id/insights", handlers: []gin.HandlerFunc{h.Insights}},
+		{method: http.MethodPost, path: "/users/:id/calendar-token", handlers: []gin.HandlerFunc{h.CalendarToken}},
+		{method: http.MethodGet, path: "/users/:id/calendar.ics", handlers: []gin.HandlerFunc{h.Calendar}},
+		// Not idempotent: a replay would store the secret of the webhook with the response.
+		{method: http.MethodPost, path: "/webhooks", handlers: []gin.HandlerFunc{h.CreateWebhook}},
+		{method: http.MethodGet, path: "/webhooks", handlers: []gin.HandlerFunc{h.ListWebhooks}},
+		{method: http.MethodGet, path: "/webhooks/:id", handlers: []gin.HandlerFunc{h.ReadWebhook}},
+		{method: http.MethodDelete, path: "/webhooks/:id", handlers: []gin.HandlerFunc{h.DeleteWebhook}},
+		{method: http.MethodGet, path: "/webhooks/:id/deliveries", handlers: []gin.HandlerFunc{h.WebhookDeliveries}},
+		{method: http.MethodGet, path: "/webhooks/:id/dead-letters", handlers: []gin.HandlerFunc{h.DeadLetters}},
+		{method: http.MethodPost, path: "/webhooks/:id/deliveries/:delivery_id/redeliver", handlers: []gin.HandlerFunc{h.RedeliverWebhook}},
+	}
+}
+
+// mount registers routes on r. Every route checks its request with validate, after
+// bounding the body.
+func mount(r gin.IRouter, routes []route, validate gin.HandlerFunc) {
+	for _, rt := range routes {
+		handlers := make([]gin.HandlerFunc, 0, len(rt.handlers)+3)
+
+		if rt.deprecation != nil {
+			handlers = append(handlers, deprecated(*rt.deprecation))
+		}
+
+		if rt.maxBody > 0 {
+			handlers = append(handlers, limitBody(rt.maxBody))
+		}
+
+		handlers = append(handlers, validate)
+		handlers = append(handlers, rt.handlers...)
+
+		r.Handle(rt.method, rt.path, handlers...)
+	}
+}
+
+// deprecated announces the deprecation of a route in the Deprecation, Sunset and Link
+// response headers.
+func deprecated(d Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d.At.IsZero() {
+			// Without a date fall back to the form of the earlier drafts.
+			c.Header("Deprecation", "true")
+		} else {
+			c.Header("Deprecation", "@"+strconv.FormatInt(d.At.Unix(), 10))
+		}
+
+		if !d.Sunset.IsZero() {
+			c.Header("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+
+		if d.Successor != "" {
+			c.Writer.Header().Add("Link", `<`+d.Successor+c.Request.URL.RequestURI()+`>; rel="successor-version"`)
+		}
+
+		c.Next()
+	}
 }
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+	"Subscription_Service/pkg/textsearch"
+)
+
+// Search returns the subscriptions whose service name resembles q, best matches
+// first, optionally limited to the subscriptions of one user.
+func (h *Handler) Search(c *gin.Context) {
+	q := model.SearchQuery{
+		Text:      c.Query("q"),
+		Threshold: model.DefaultSimilarityThreshold,
+	}
+
+	if v := c.Query("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			invalidParam(c, "user_id", "invalid user_id, expected a UUID")
+			return
+		}
+
+		q.UserID = &id
+	}
+
+	if v := c.Query("threshold"); v != "" {
+		threshold, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			invalidParam(c, "threshold", "invalid threshold, expected a number between 0 and 1")
+			return
+		}
+
+		q.Threshold = threshold
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(model.DefaultSearchLimit)))
+	if err != nil {
+		invalidParam(c, "limit", fmt.Sprintf("invalid limit, expected an integer between 1 and %d", model.MaxSearchLimit))
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		invalidParam(c, "offset", "invalid offset, expected a non-negative integer")
+		return
+	}
+
+	q.Limit = limit
+	q.Offset = offset
+
+	hits, err := h.service.Search(c, q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	resp := make([]dto.SearchHitResponse, 0, len(hits))
+	for _, hit := range hits {
+		resp = append(resp, dto.SearchHitResponse{
+			Score:        hit.Score,
+			Highlight:    highlight(hit.ServiceName, q.Text),
+			Subscription: toResponse(hit.Subscription),
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// highlight renders name as escaped HTML with the fragment most similar to term
+// wrapped in a mark element.
+func highlight(name, term string) string {
+	start, end := textsearch.Highlight(name, term)
+	if start < 0 {
+		return html.EscapeString(name)
+	}
+
+	return html.EscapeString(name[:start]) + "<mark>" + html.EscapeString(name[start:end]) + "</mark>" + html.EscapeString(name[end:])
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+const (
+	defaultStatsMonths = 12
+	defaultStatsTop    = 5
+	maxStatsTop        = 100
+)
+
+func (h *Handler) Stats(c *gin.Context) {
+	ps, pe, err := statsPeriod(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", strconv.Itoa(defaultStatsTop)))
+	if err != nil || top <= 0 || top > maxStatsTop {
+		invalidParam(c, "top", fmt.Sprintf("invalid top, expected an integer between 1 and %d", maxStatsTop))
+		return
+	}
+
+	stats, err := h.service.Stats(c, ps, pe, top)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toStatsResponse(*stats))
+}
+
+// statsPeriod reads the optional YYYY-MM start_date and end_date query parameters.
+// The period defaults to the last twelve months including the current one.
+func statsPeriod(c *gin.Context) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	pe := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	if v := c.Query("end_date"); v != "" {
+		t, err := time.Parse("2006-01", v)
+		if err != nil {
+			return time.Time{}, time.Time{}, model.Invalid("end_date", "invalid end_date format, expected YYYY-MM")
+		}
+
+		pe = t
+	}
+
+	ps := pe.AddDate(0, -(defaultStatsMonths - 1), 0)
+
+	if v := c.Query("start_date"); v != "" {
+		t, err := time.Parse("2006-01", v)
+		if err != nil {
+			return time.Time{}, time.Time{}, model.Invalid("start_date", "invalid start_date format, expected YYYY-MM")
+		}
+
+		ps = t
+	}
+
+	if pe.Before(ps) {
+		return time.Time{}, time.Time{}, model.Invalid("end_date", "end_date cannot be before start_date")
+	}
+
+	return ps, pe, nil
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"Subscription_Service/internal/application/service"
+	"Subscription_Service/pkg/sse"
+)
+
+const (
+	// streamRetry is how long clients wait before reconnecting to a stream that ended.
+	streamRetry = 3 * time.Second
+	// streamWriteTimeout bounds each write to a stream, so that a client no longer
+	// reading does not hold the stream open.
+	streamWriteTimeout = 10 * time.Second
+	// streamResetEvent tells a resuming client that the events since its last one are
+	// no longer available and it has to reload the subscriptions.
+	streamResetEvent = "reset"
+)
+
+// Stream pushes the subscription changes as Server-Sent Events, optionally only those
+// of one user or of the matching services. A client reconnecting with Last-Event-ID
+// first receives the changes it missed, or a reset event when they are no longer
+// buffered.
+func (h *Handler) Stream(c *gin.Context) {
+	filter := service.StreamFilter{}
+
+	if v := c.Query("user_id"); v != "" {
+		userID, err := uuid.Parse(v)
+		if err != nil {
+			invalidParam(c, "user_id", "invalid user_id, expected a UUID")
+			return
+		}
+
+		filter.UserID = &userID
+	}
+
+	serviceName, err := serviceNameFilter(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	filter.ServiceName = serviceName
+
+	// EventSource sends Last-Event-ID when it reconnects; the query parameter lets a
+	// client resume on its first connection too.
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	sub, err := h.service.SubscribeEvents(filter, lastEventID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-store")
+	// Keeps reverse proxies such as nginx from buffering the stream.
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	rc := http.NewResponseController(c.Writer)
+
+	write := func(fn func() error) bool {
+		_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+
+		return fn() == nil && rc.Flush() == nil
+	}
+
+	send := func(e service.StreamEvent) bool {
+		return write(func() error {
+			return sse.Write(c.Writer, sse.Event{ID: e.ID, Event: string(e.Type), Data: e.Data})
+		})
+	}
+
+	if !write(func() error { return sse.Retry(c.Writer, streamRetry) }) {
+		return
+	}
+
+	// The reset carries the ID of the newest event, so that a client reconnecting after
+	// reloading resumes from there instead of being reset again. With no event buffered
+	// it clears the ID, and the client reconnects without one.
+	if sub.Missed && !write(func() error {
+		return sse.Write(c.Writer, sse.Event{ID: sub.Latest, ClearID: true, Event: streamResetEvent, Data: []byte("{}")})
+	}) {
+		return
+	}
+
+	for _, e := range sub.Replay {
+		if !send(e) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(h.cfg.StreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-sub.Events:
+			if !ok || !send(e) {
+				return
+			}
+		case <-heartbeat.C:
+			if !write(func() error { return sse.Comment(c.Writer, "heartbeat") }) {
+				return
+			}
+		}
+	}
+}
@@ -2,7 +2,6 @@ package http
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -13,41 +12,26 @@ import (
 func (h *Handler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID"})
+		invalidParam(c, "id", "invalid id, expected a UUID")
 		return
 	}
 
 	var req dto.UpdateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		bindError(c, err)
 		return
 	}
 
-	sub, err := h.service.Read(c, id)
+	sub, err := h.service.Read(c, id, nil, nil)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	if req.ServiceName != nil {
-		sub.ServiceName = *req.ServiceName
-	}
-	if req.Price != nil {
-		sub.Price = *req.Price
-	}
-	if req.StartDate != nil {
-		sub.StartDate = req.StartDate.Time
-	}
-	if req.EndDate != nil {
-		if !req.EndDate.Time.IsZero() {
-			sub.EndDate = req.EndDate.Time
-		} else {
-			sub.EndDate = time.Time{}
-		}
-	}
+	replaceFields(sub, req)
 
 	if err := h.service.Update(c, sub); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/internal/infrastructure/controllers/dto"
+)
+
+// defaultDeliveryLimit is the number of deliveries listed unless limit asks for
+// another.
+const defaultDeliveryLimit = 50
+
+// CreateWebhook registers a webhook. Its secret is returned only here.
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var req dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bindError(c, err)
+		return
+	}
+
+	w := model.Webhook{URL: req.URL}
+	for _, e := range req.Events {
+		w.Events = append(w.Events, model.EventType(e))
+	}
+
+	if err := h.service.CreateWebhook(c, &w); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, toWebhookResponse(w, true))
+}
+
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.service.ListWebhooks(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	resp := make([]dto.WebhookResponse, 0, len(webhooks))
+	for _, w := range webhooks {
+		resp = append(resp, toWebhookResponse(w, false))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) ReadWebhook(c *gin.Context) {
+	id, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	w, err := h.service.ReadWebhook(c, id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookResponse(*w, false))
+}
+
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	id, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteWebhook(c, id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// WebhookDeliveries serves the delivery log of a webhook, newest first, optionally
+// only the deliveries with the status given in the query.
+func (h *Handler) WebhookDeliveries(c *gin.Context) {
+	h.webhookDeliveries(c, model.DeliveryStatus(c.Query("status")))
+}
+
+// DeadLetters lists the deliveries of a webhook that failed every attempt.
+func (h *Handler) DeadLetters(c *gin.Context) {
+	h.webhookDeliveries(c, model.DeliveryDead)
+}
+
+func (h *Handler) webhookDeliveries(c *gin.Context, status model.DeliveryStatus) {
+	id, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultDeliveryLimit)))
+	if err != nil {
+		invalidParam(c, "limit", fmt.Sprintf("invalid limit, expected an integer between 1 and %d", model.MaxPageLimit))
+		return
+	}
+
+	deliveries, err := h.service.WebhookDeliveries(c, id, status, limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	resp := make([]dto.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		resp = append(resp, toDeliveryResponse(d))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RedeliverWebhook queues a dead delivery again.
+func (h *Handler) RedeliverWebhook(c *gin.Context) {
+	id, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	deliveryID, ok := uuidParam(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	d, err := h.service.RedeliverWebhook(c, id, deliveryID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toDeliveryResponse(*d))
+}
+
+func uuidParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		invalidParam(c, name, "invalid "+name+", expected a UUID")
+		return uuid.UUID{}, false
+	}
+
+	return id, true
+}
+
+func toWebhookResponse(w model.Webhook, withSecret bool) dto.WebhookResponse {
+	resp := dto.WebhookResponse{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    make([]string, 0, len(w.Events)),
+		CreatedAt: w.CreatedAt,
+	}
+
+	for _, e := range w.Events {
+		resp.Events = append(resp.Events, string(e))
+	}
+
+	if withSecret {
+		resp.Secret = w.Secret
+	}
+
+	return resp
+}
+
+func toDeliveryResponse(d model.WebhookDelivery) dto.WebhookDeliveryResponse {
+	resp := dto.WebhookDeliveryResponse{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		EventType:      string(d.EventType),
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		Payload:        d.Payload,
+		Log:            make([]dto.DeliveryAttemptResponse, 0, len(d.Log)),
+	}
+
+	if d.Status == model.DeliveryPending {
+		resp.NextAttemptAt = &d.NextAttemptAt
+	}
+
+	if !d.DeliveredAt.IsZero() {
+		resp.DeliveredAt = &d.DeliveredAt
+	}
+
+	for _, a := range d.Log {
+		resp.Log = append(resp.Log, dto.DeliveryAttemptResponse{
+			StatusCode:  a.StatusCode,
+			Error:       a.Error,
+			DurationMS:  a.Duration.Milliseconds(),
+			AttemptedAt: a.AttemptedAt,
+		})
+	}
+
+	return resp
+}
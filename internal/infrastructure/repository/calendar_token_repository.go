@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+// CalendarTokenRepository stores the hashes of the tokens giving access to the calendar
+// feeds of users.
+type CalendarTokenRepository interface {
+	// Save stores the token hash of the user, replacing any previous one.
+	Save(ctx context.Context, userID uuid.UUID, tokenHash string, createdAt time.Time) error
+	FindHash(ctx context.Context, userID uuid.UUID) (string, error)
+}
+
+type calendarTokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewCalendarTokenRepository(db *sqlx.DB) CalendarTokenRepository {
+	return &calendarTokenRepository{db: db}
+}
+
+func (cr *calendarTokenRepository) Save(ctx context.Context, userID uuid.UUID, tokenHash string, createdAt time.Time) error {
+	_, err := cr.db.ExecContext(ctx, `
+	INSERT INTO calendar_token (user_id, token_hash, created_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE
+	SET token_hash = EXCLUDED.token_hash, created_at = EXCLUDED.created_at`,
+		userID, tokenHash, createdAt)
+	if err != nil {
+		return fmt.Errorf("save calendar token: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (cr *calendarTokenRepository) FindHash(ctx context.Context, userID uuid.UUID) (string, error) {
+	var hash string
+
+	err := cr.db.GetContext(ctx, &hash, `SELECT token_hash FROM calendar_token WHERE user_id = $1`, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("calendar token of user %s %w", userID, model.ErrNotFound)
+		}
+
+		return "", fmt.Errorf("find calendar token: %s", err.Error())
+	}
+
+	return hash, nil
+}
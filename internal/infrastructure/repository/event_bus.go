@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	// eventChannel is the notification channel carrying the subscription events.
+	eventChannel = "subscription_events"
+	// The listener connection is reestablished after a delay growing from the minimum
+	// to the maximum.
+	listenerMinReconnect = time.Second
+	listenerMaxReconnect = time.Minute
+	// listenerPingInterval is how often an idle listener connection is checked, as a
+	// connection that died silently would otherwise go unnoticed.
+	listenerPingInterval = 90 * time.Second
+)
+
+// EventBus passes payloads between the instances of the service over Postgres
+// LISTEN/NOTIFY.
+type EventBus interface {
+	// Notify sends payload to the listeners of every instance, this one included.
+	// Postgres limits payloads to 8000 bytes.
+	Notify(ctx context.Context, payload []byte) error
+	// Listen passes the payloads notified by every instance to receive, in the order
+	// they were committed, until ctx is done. After the connection to the database is
+	// lost and restored it calls receive with nil: the payloads notified meanwhile are
+	// lost.
+	Listen(ctx context.Context, receive func(payload []byte)) error
+}
+
+type eventBus struct {
+	db  *sqlx.DB
+	dsn string
+}
+
+// NewEventBus returns a bus notifying through db. Listening needs a connection of its
+// own, which is opened with dsn.
+func NewEventBus(db *sqlx.DB, dsn string) EventBus {
+	return &eventBus{
+		db:  db,
+		dsn: dsn,
+	}
+}
+
+func (eb *eventBus) Notify(ctx context.Context, payload []byte) error {
+	_, err := eb.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, eventChannel, string(payload))
+	if err != nil {
+		return fmt.Errorf("notify %s: %s", eventChannel, err.Error())
+	}
+
+	return nil
+}
+
+func (eb *eventBus) Listen(ctx context.Context, receive func(payload []byte)) error {
+	listener := pq.NewListener(eb.dsn, listenerMinReconnect, listenerMaxReconnect, nil)
+
+	// Closing the listener also interrupts Listen waiting for a connection.
+	stop := context.AfterFunc(ctx, func() { _ = listener.Close() })
+	defer func() {
+		if stop() {
+			_ = listener.Close()
+		}
+	}()
+
+	if err := listener.Listen(eventChannel); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return fmt.Errorf("listen on %s: %s", eventChannel, err.Error())
+	}
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n, ok := <-listener.Notify:
+			if !ok || ctx.Err() != nil {
+				return nil
+			}
+
+			if n == nil {
+				receive(nil)
+				continue
+			}
+
+			receive([]byte(n.Extra))
+		case <-ticker.C:
+			// A failed ping makes the listener reconnect.
+			_ = listener.Ping()
+		}
+	}
+}
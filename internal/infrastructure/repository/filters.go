@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"Subscription_Service/internal/domain/filterexpr"
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// serviceNameCond renders f as a condition on the service_name column and appends its
+// arguments to args. It returns an empty condition for an empty filter.
+func serviceNameCond(f model.ServiceNameFilter, args []interface{}) (string, []interface{}) {
+	if f.IsEmpty() {
+		return "", args
+	}
+
+	conds := make([]string, 0, len(f.Names))
+
+	for _, name := range f.Names {
+		op := "ILIKE"
+		arg := likeEscaper.Replace(name)
+
+		switch f.Match {
+		case model.MatchExact:
+		case model.MatchPrefix:
+			arg = arg + "%"
+		case model.MatchFuzzy:
+			// The % operator is backed by the trigram index and compares against
+			// pg_trgm.similarity_threshold, see withSimilarityThreshold.
+			op = "%"
+			arg = name
+		default:
+			arg = "%" + arg + "%"
+		}
+
+		conds = append(conds, fmt.Sprintf("service_name %s $%d", op, len(args)+1))
+		args = append(args, arg)
+	}
+
+	return "(" + strings.Join(conds, " OR ") + ")", args
+}
+
+// withSimilarityThreshold runs fn on a read-only transaction with the fuzzy matching
+// threshold of f applied. Other match modes run fn directly on db.
+func withSimilarityThreshold(ctx context.Context, db *sqlx.DB, f model.ServiceNameFilter, fn func(q sqlx.QueryerContext) error) error {
+	if f.IsEmpty() || f.Match != model.MatchFuzzy {
+		return fn(db)
+	}
+
+	tx, err := db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	if err := setSimilarityThreshold(ctx, tx, f); err != nil {
+		return err
+	}
+
+	return fn(tx)
+}
+
+func setSimilarityThreshold(ctx context.Context, tx *sqlx.Tx, f model.ServiceNameFilter) error {
+	if f.IsEmpty() || f.Match != model.MatchFuzzy {
+		return nil
+	}
+
+	threshold := strconv.FormatFloat(f.Threshold, 'f', -1, 64)
+
+	_, err := tx.ExecContext(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, threshold)
+	if err != nil {
+		return fmt.Errorf("set similarity threshold: %s", err.Error())
+	}
+
+	return nil
+}
+
+// subscriptionConds renders f as conditions on the subscription table and appends their
+// arguments to args.
+func subscriptionConds(f model.SubscriptionFilter, args []interface{}) ([]string, []interface{}) {
+	conds := make([]string, 0, 8)
+
+	add := func(format string, arg interface{}) {
+		conds = append(conds, fmt.Sprintf(format, len(args)+1))
+		args = append(args, arg)
+	}
+
+	if f.UserID != nil {
+		add("user_id = $%d", *f.UserID)
+	}
+
+	if len(f.UserIDs) > 0 {
+		ids := make([]string, 0, len(f.UserIDs))
+		for _, id := range f.UserIDs {
+			ids = append(ids, id.String())
+		}
+
+		add("user_id = ANY($%d::uuid[])", pq.Array(ids))
+	}
+
+	if cond, condArgs := serviceNameCond(f.ServiceName, args); cond != "" {
+		conds = append(conds, cond)
+		args = condArgs
+	}
+
+	if f.PriceMin != nil {
+		add("price >= $%d", *f.PriceMin)
+	}
+
+	if f.PriceMax != nil {
+		add("price <= $%d", *f.PriceMax)
+	}
+
+	if f.StartFrom != nil {
+		add("start_date >= $%d", *f.StartFrom)
+	}
+
+	if f.StartTo != nil {
+		add("start_date <= $%d", *f.StartTo)
+	}
+
+	if f.EndFrom != nil {
+		add("end_date >= $%d", *f.EndFrom)
+	}
+
+	if f.EndTo != nil {
+		add("end_date <= $%d", *f.EndTo)
+	}
+
+	if f.ActiveAt != nil {
+		add("start_date <= $%d", *f.ActiveAt)
+		add("(end_date IS NULL OR end_date >= $%d)", *f.ActiveAt)
+	}
+
+	if f.OpenEnded {
+		conds = append(conds, "end_date IS NULL")
+	}
+
+	if f.Expr != nil {
+		var cond string
+		cond, args = exprCond(f.Expr, args)
+		conds = append(conds, cond)
+	}
+
+	return conds, args
+}
+
+// exprCond compiles a filter expression into a condition and appends its arguments to
+// args. Field names come from the parser allowlist, so they are safe to render as is.
+func exprCond(n filterexpr.Node, args []interface{}) (string, []interface{}) {
+	switch n := n.(type) {
+	case filterexpr.And:
+		left, args := exprCond(n.Left, args)
+		right, args := exprCond(n.Right, args)
+
+		return "(" + left + " AND " + right + ")", args
+	case filterexpr.Or:
+		left, args := exprCond(n.Left, args)
+		right, args := exprCond(n.Right, args)
+
+		return "(" + left + " OR " + right + ")", args
+	case filterexpr.Not:
+		expr, args := exprCond(n.Expr, args)
+
+		return "NOT " + expr, args
+	case filterexpr.Compare:
+		op := strings.ToUpper(n.Op)
+		if op == "!=" {
+			op = "<>"
+		}
+
+		return fmt.Sprintf("(%s %s $%d)", n.Field, op, len(args)+1), append(args, n.Value)
+	case filterexpr.In:
+		params := make([]string, 0, len(n.Values))
+		for _, v := range n.Values {
+			args = append(args, v)
+			params = append(params, fmt.Sprintf("$%d", len(args)))
+		}
+
+		op := "IN"
+		if n.Negated {
+			op = "NOT IN"
+		}
+
+		return fmt.Sprintf("(%s %s (%s))", n.Field, op, strings.Join(params, ", ")), args
+	case filterexpr.IsNull:
+		if n.Negated {
+			return "(" + n.Field + " IS NOT NULL)", args
+		}
+
+		return "(" + n.Field + " IS NULL)", args
+	}
+
+	panic(fmt.Sprintf("unexpected filter expression node %T", n))
+}
+
+type sortColumn struct {
+	// expr is the sort expression, never NULL so that keyset comparisons hold.
+	expr string
+	// cast is the type cursor values are cast to when compared against expr.
+	cast string
+	// value renders the sort value of a subscription for a cursor.
+	value func(s model.Subscription) string
+}
+
+var sortColumns = map[string]sortColumn{
+	"service_name": {
+		expr:  "service_name",
+		cast:  "text",
+		value: func(s model.Subscription) string { return s.ServiceName },
+	},
+	"price": {
+		expr:  "price",
+		cast:  "integer",
+		value: func(s model.Subscription) string { return strconv.Itoa(s.Price) },
+	},
+	"start_date": {
+		expr:  "start_date",
+		cast:  "date",
+		value: func(s model.Subscription) string { return s.StartDate.Format("2006-01-02") },
+	},
+	"end_date": {
+		// Open-ended subscriptions sort after every ended one.
+		expr: "COALESCE(end_date, 'infinity'::date)",
+		cast: "date",
+		value: func(s model.Subscription) string {
+			if s.EndDate.IsZero() {
+				return "infinity"
+			}
+
+			return s.EndDate.Format("2006-01-02")
+		},
+	},
+	"created_at": {
+		expr:  "created_at",
+		cast:  "timestamptz",
+		value: func(s model.Subscription) string { return s.CreatedAt.Format(time.RFC3339Nano) },
+	},
+	"updated_at": {
+		expr:  "updated_at",
+		cast:  "timestamptz",
+		value: func(s model.Subscription) string { return s.UpdatedAt.Format(time.RFC3339Nano) },
+	},
+}
+
+// orderBy renders sort as an ORDER BY clause, breaking ties by id in the direction of
+// the last sort field.
+func orderBy(sort []model.SortField) string {
+	parts := make([]string, 0, len(sort)+1)
+	for _, s := range sort {
+		parts = append(parts, sortColumns[s.Field].expr+direction(s.Desc))
+	}
+
+	parts = append(parts, "id"+direction(sort[len(sort)-1].Desc))
+
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// keysetCond renders the condition selecting the rows that follow after in the given
+// sort order and appends its arguments to args.
+func keysetCond(sort []model.SortField, after model.Cursor, args []interface{}) (string, []interface{}, error) {
+	if len(after.Values) != len(sort) {
+		return "", args, fmt.Errorf("cursor does not match the sort order")
+	}
+
+	exprs := make([]string, 0, len(sort)+1)
+	params := make([]string, 0, len(sort)+1)
+	ops := make([]string, 0, len(sort)+1)
+
+	for i, s := range sort {
+		col := sortColumns[s.Field]
+		exprs = append(exprs, col.expr)
+		params = append(params, fmt.Sprintf("$%d::%s", len(args)+1, col.cast))
+		ops = append(ops, comparison(s.Desc))
+		args = append(args, after.Values[i])
+	}
+
+	exprs = append(exprs, "id")
+	params = append(params, fmt.Sprintf("$%d", len(args)+1))
+	ops = append(ops, comparison(sort[len(sort)-1].Desc))
+	args = append(args, after.ID)
+
+	// A uniform direction compares whole rows, which an index on the sort columns serves.
+	if !slices.ContainsFunc(sort, func(s model.SortField) bool { return s.Desc != sort[0].Desc }) {
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(exprs, ", "), ops[0], strings.Join(params, ", ")), args, nil
+	}
+
+	alternatives := make([]string, 0, len(exprs))
+
+	for i := range exprs {
+		terms := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			terms = append(terms, fmt.Sprintf("%s = %s", exprs[j], params[j]))
+		}
+
+		terms = append(terms, fmt.Sprintf("%s %s %s", exprs[i], ops[i], params[i]))
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args, nil
+}
+
+// cursorAt returns the position of s in the given sort order.
+func cursorAt(sort []model.SortField, s model.Subscription) *model.Cursor {
+	values := make([]string, 0, len(sort))
+	for _, f := range sort {
+		values = append(values, sortColumns[f.Field].value(s))
+	}
+
+	return &model.Cursor{Values: values, ID: s.ID}
+}
+
+func direction(desc bool) string {
+	if desc {
+		return " DESC"
+	}
+
+	return " ASC"
+}
+
+func comparison(desc bool) string {
+	if desc {
+		return "<"
+	}
+
+	return ">"
+}
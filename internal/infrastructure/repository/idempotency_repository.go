@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+// IdempotencyRepository stores the responses of requests sent with an idempotency key.
+type IdempotencyRepository interface {
+	// Acquire claims the key for a request with the given hash until expiresAt. It
+	// returns nil when the key was free or expired, otherwise the record stored for it.
+	Acquire(ctx context.Context, key model.IdempotencyKey, requestHash string, now, expiresAt time.Time) (*model.IdempotencyRecord, error)
+	// Complete stores the response of the key and keeps it until expiresAt.
+	Complete(ctx context.Context, key model.IdempotencyKey, resp model.IdempotentResponse, expiresAt time.Time) error
+	Release(ctx context.Context, key model.IdempotencyKey) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type idempotencyRow struct {
+	RequestHash string         `db:"request_hash"`
+	Status      sql.NullInt64  `db:"status"`
+	ContentType sql.NullString `db:"content_type"`
+	Body        []byte         `db:"body"`
+}
+
+func (r idempotencyRow) toModel() *model.IdempotencyRecord {
+	rec := &model.IdempotencyRecord{RequestHash: r.RequestHash}
+
+	if r.Status.Valid {
+		rec.Response = &model.IdempotentResponse{
+			Status:      int(r.Status.Int64),
+			ContentType: r.ContentType.String,
+			Body:        r.Body,
+		}
+	}
+
+	return rec
+}
+
+type idempotencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (ir *idempotencyRepository) Acquire(ctx context.Context, key model.IdempotencyKey, requestHash string, now, expiresAt time.Time) (*model.IdempotencyRecord, error) {
+	// An expired key is taken over as if it did not exist.
+	query := `
+	INSERT INTO idempotency_key (key, method, path, request_hash, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (key, method, path) DO UPDATE
+	SET request_hash = EXCLUDED.request_hash, status = NULL, content_type = NULL, body = NULL,
+	created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+	WHERE idempotency_key.expires_at <= EXCLUDED.created_at`
+
+	res, err := ir.db.ExecContext(ctx, query, key.Key, key.Method, key.Path, requestHash, now, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("acquire idempotency key: %s", err.Error())
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 1 {
+		return nil, nil
+	}
+
+	var row idempotencyRow
+
+	err = ir.db.GetContext(ctx, &row, `
+	SELECT request_hash, status, content_type, body FROM idempotency_key
+	WHERE key = $1 AND method = $2 AND path = $3`, key.Key, key.Method, key.Path)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Released by a failed first request in the meantime; report it as in
+			// progress so that the client retries.
+			return &model.IdempotencyRecord{RequestHash: requestHash}, nil
+		}
+
+		return nil, fmt.Errorf("get idempotency key: %s", err.Error())
+	}
+
+	return row.toModel(), nil
+}
+
+func (ir *idempotencyRepository) Complete(ctx context.Context, key model.IdempotencyKey, resp model.IdempotentResponse, expiresAt time.Time) error {
+	_, err := ir.db.ExecContext(ctx, `
+	UPDATE idempotency_key SET status = $4, content_type = $5, body = $6, expires_at = $7
+	WHERE key = $1 AND method = $2 AND path = $3`,
+		key.Key, key.Method, key.Path, resp.Status, resp.ContentType, resp.Body, expiresAt)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (ir *idempotencyRepository) Release(ctx context.Context, key model.IdempotencyKey) error {
+	_, err := ir.db.ExecContext(ctx, `
+	DELETE FROM idempotency_key
+	WHERE key = $1 AND method = $2 AND path = $3 AND status IS NULL`, key.Key, key.Method, key.Path)
+	if err != nil {
+		return fmt.Errorf("release idempotency key: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (ir *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res, err := ir.db.ExecContext(ctx, `DELETE FROM idempotency_key WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired idempotency keys: %s", err.Error())
+	}
+
+	return res.RowsAffected()
+}
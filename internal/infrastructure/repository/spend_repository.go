@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+// SpendRepository maintains monthly_spend_delta, the pre-aggregated monthly spend per
+// user and service. Subscription writes keep it up to date in the same transaction;
+// Rebuild recomputes it from scratch.
+type SpendRepository interface {
+	Rebuild(ctx context.Context) (int64, error)
+}
+
+type spendRepository struct {
+	db *sqlx.DB
+}
+
+func NewSpendRepository(db *sqlx.DB) SpendRepository {
+	return &spendRepository{db: db}
+}
+
+func (sr *spendRepository) Rebuild(ctx context.Context) (int64, error) {
+	tx, err := sr.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("rebuild monthly spend: begin transaction: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	// Block subscription writes so the rebuilt aggregates match the table exactly.
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE subscription IN SHARE MODE`); err != nil {
+		return 0, fmt.Errorf("rebuild monthly spend: lock subscription: %s", err.Error())
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM monthly_spend_delta`); err != nil {
+		return 0, fmt.Errorf("rebuild monthly spend: clear: %s", err.Error())
+	}
+
+	result, err := tx.ExecContext(ctx, `
+	INSERT INTO monthly_spend_delta (user_id, service_name, month, delta)
+	SELECT user_id, service_name, month, SUM(delta)
+	FROM (
+	SELECT user_id, service_name, date_trunc('month', start_date)::date AS month, price::bigint AS delta
+	FROM subscription
+	UNION ALL
+	SELECT user_id, service_name, (date_trunc('month', end_date) + interval '1 month')::date AS month, -price::bigint AS delta
+	FROM subscription
+	WHERE end_date IS NOT NULL
+	) d
+	GROUP BY user_id, service_name, month
+	HAVING SUM(delta) <> 0`)
+	if err != nil {
+		return 0, fmt.Errorf("rebuild monthly spend: populate: %s", err.Error())
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rebuild monthly spend: rows affected: %s", err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("rebuild monthly spend: commit: %s", err.Error())
+	}
+
+	return rows, nil
+}
+
+// applySpend adds sign times the monthly spend of s to the aggregates.
+func applySpend(ctx context.Context, tx *sqlx.Tx, s model.Subscription, sign int64) error {
+	if err := addSpendDelta(ctx, tx, s, monthStart(s.StartDate), sign*int64(s.Price)); err != nil {
+		return err
+	}
+
+	if s.EndDate.IsZero() {
+		return nil
+	}
+
+	return addSpendDelta(ctx, tx, s, monthStart(s.EndDate).AddDate(0, 1, 0), -sign*int64(s.Price))
+}
+
+func addSpendDelta(ctx context.Context, tx *sqlx.Tx, s model.Subscription, month time.Time, delta int64) error {
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO monthly_spend_delta (user_id, service_name, month, delta)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (user_id, service_name, month)
+	DO UPDATE SET delta = monthly_spend_delta.delta + EXCLUDED.delta`,
+		s.UserID, s.ServiceName, month, delta)
+	if err != nil {
+		return fmt.Errorf("update monthly spend: %s", err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM monthly_spend_delta WHERE user_id=$1 AND service_name=$2 AND month=$3 AND delta=0`,
+		s.UserID, s.ServiceName, month)
+	if err != nil {
+		return fmt.Errorf("update monthly spend: %s", err.Error())
+	}
+
+	return nil
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+type StatsRepository interface {
+	Stats(ctx context.Context, startDate, endDate time.Time, top int) (*model.Stats, error)
+	Cohorts(ctx context.Context, serviceName model.ServiceNameFilter, startDate, endDate time.Time) (*model.CohortReport, error)
+}
+
+type statsRepository struct {
+	db *sqlx.DB
+}
+
+func NewStatsRepository(db *sqlx.DB) StatsRepository {
+	return &statsRepository{db: db}
+}
+
+type rankedServiceStats struct {
+	model.ServiceStats
+	SubscribersRank int `db:"subscribers_rank"`
+	RevenueRank     int `db:"revenue_rank"`
+}
+
+// Stats reports the state of the dataset over the months from startDate to endDate.
+// Active subscriptions, distinct users and prices are measured in the last month of
+// the period, revenue and the new/ended counts cover the whole period.
+func (sr *statsRepository) Stats(ctx context.Context, startDate, endDate time.Time, top int) (*model.Stats, error) {
+	ps := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	pe := time.Date(endDate.Year(), endDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	tx, err := sr.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("stats: begin transaction: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	stats := &model.Stats{
+		PeriodStart: ps,
+		PeriodEnd:   pe,
+	}
+
+	totalsQuery := `
+	SELECT COUNT(*) AS active_subscriptions, COUNT(DISTINCT user_id) AS distinct_users
+	FROM subscription
+	WHERE start_date < ($1::date + interval '1 month') AND (end_date IS NULL OR end_date >= $1::date)`
+
+	err = tx.QueryRowxContext(ctx, totalsQuery, pe).Scan(&stats.ActiveSubscriptions, &stats.DistinctUsers)
+	if err != nil {
+		return nil, fmt.Errorf("stats: totals: %s", err.Error())
+	}
+
+	servicesQuery := fmt.Sprintf(`
+	WITH windowed AS (
+	SELECT service_name, user_id, price,
+	start_date < ($2::date + interval '1 month') AND (end_date IS NULL OR end_date >= $2::date) AS active,
+	GREATEST(start_date, $1::date) AS s,
+	LEAST(COALESCE(end_date, $2::date), $2::date) AS e
+	FROM subscription
+	WHERE start_date < ($2::date + interval '1 month') AND (end_date IS NULL OR end_date >= $1::date)
+	),
+	per_service AS (
+	SELECT service_name,
+	COUNT(DISTINCT user_id) FILTER (WHERE active) AS subscribers,
+	COALESCE(SUM(price * %s), 0)::bigint AS revenue,
+	COALESCE(AVG(price) FILTER (WHERE active), 0)::float8 AS average_price,
+	COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY price) FILTER (WHERE active), 0)::float8 AS median_price
+	FROM windowed
+	GROUP BY service_name
+	)
+	SELECT *,
+	ROW_NUMBER() OVER (ORDER BY subscribers DESC, service_name) AS subscribers_rank,
+	ROW_NUMBER() OVER (ORDER BY revenue DESC, service_name) AS revenue_rank
+	FROM per_service
+	ORDER BY service_name`, billedMonthsSQL)
+
+	services := []rankedServiceStats{}
+	if err := tx.SelectContext(ctx, &services, servicesQuery, ps, pe); err != nil {
+		return nil, fmt.Errorf("stats: services: %s", err.Error())
+	}
+
+	stats.Services = make([]model.ServiceStats, 0, len(services))
+	stats.TopBySubscribers = topServices(services, top, func(s rankedServiceStats) int { return s.SubscribersRank })
+	stats.TopByRevenue = topServices(services, top, func(s rankedServiceStats) int { return s.RevenueRank })
+
+	for _, s := range services {
+		stats.Services = append(stats.Services, s.ServiceStats)
+	}
+
+	monthlyQuery := `
+	SELECT m::date AS month,
+	COUNT(ev.kind) FILTER (WHERE ev.kind = 'new') AS new,
+	COUNT(ev.kind) FILTER (WHERE ev.kind = 'ended') AS ended
+	FROM generate_series($1::date, $2::date, interval '1 month') AS m
+	LEFT JOIN (
+	SELECT date_trunc('month', start_date) AS month, 'new' AS kind
+	FROM subscription
+	WHERE start_date >= $1::date AND start_date < ($2::date + interval '1 month')
+	UNION ALL
+	SELECT date_trunc('month', end_date) AS month, 'ended' AS kind
+	FROM subscription
+	WHERE end_date >= $1::date AND end_date < ($2::date + interval '1 month')
+	) ev ON ev.month = m
+	GROUP BY m
+	ORDER BY m`
+
+	stats.Monthly = []model.MonthlyStats{}
+	if err := tx.SelectContext(ctx, &stats.Monthly, monthlyQuery, ps, pe); err != nil {
+		return nil, fmt.Errorf("stats: monthly: %s", err.Error())
+	}
+
+	return stats, nil
+}
+
+// Cohorts groups the subscriptions started between startDate and endDate by start
+// month and follows them up to endDate. Open-ended subscriptions count as active
+// through endDate when computing lifetimes.
+func (sr *statsRepository) Cohorts(ctx context.Context, serviceName model.ServiceNameFilter, startDate, endDate time.Time) (*model.CohortReport, error) {
+	ps := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	pe := time.Date(endDate.Year(), endDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	serviceCond, args := serviceNameCond(serviceName, []interface{}{ps, pe})
+	if serviceCond == "" {
+		serviceCond = "TRUE"
+	}
+
+	tx, err := sr.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("cohorts: begin transaction: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	if err := setSimilarityThreshold(ctx, tx, serviceName); err != nil {
+		return nil, fmt.Errorf("cohorts: %s", err.Error())
+	}
+
+	report := &model.CohortReport{
+		PeriodStart: ps,
+		PeriodEnd:   pe,
+	}
+
+	retentionQuery := fmt.Sprintf(`
+	WITH cohort AS (
+	SELECT date_trunc('month', start_date)::date AS cohort, end_date
+	FROM subscription
+	WHERE start_date >= $1::date AND start_date < ($2::date + interval '1 month') AND %s
+	),
+	offsets AS (
+	SELECT c.cohort, o.month_offset
+	FROM (SELECT DISTINCT cohort FROM cohort) c
+	CROSS JOIN LATERAL generate_series(0, (
+	(date_part('year', $2::date) - date_part('year', c.cohort)) * 12
+	+ date_part('month', $2::date) - date_part('month', c.cohort)
+	)::int) AS o(month_offset)
+	)
+	SELECT o.cohort, o.month_offset,
+	COUNT(*) AS size,
+	COUNT(*) FILTER (WHERE c.end_date IS NULL OR c.end_date >= o.cohort + make_interval(months => o.month_offset)) AS retained
+	FROM offsets o
+	JOIN cohort c ON c.cohort = o.cohort
+	GROUP BY o.cohort, o.month_offset
+	ORDER BY o.cohort, o.month_offset`, serviceCond)
+
+	var cells []struct {
+		Cohort      time.Time `db:"cohort"`
+		MonthOffset int       `db:"month_offset"`
+		Size        int64     `db:"size"`
+		Retained    int64     `db:"retained"`
+	}
+
+	if err := tx.SelectContext(ctx, &cells, retentionQuery, args...); err != nil {
+		return nil, fmt.Errorf("cohorts: retention: %s", err.Error())
+	}
+
+	report.Cohorts = []model.Cohort{}
+	for _, cell := range cells {
+		if cell.MonthOffset == 0 {
+			report.Cohorts = append(report.Cohorts, model.Cohort{Month: cell.Cohort, Size: cell.Size})
+		}
+
+		cohort := &report.Cohorts[len(report.Cohorts)-1]
+		cohort.Retention = append(cohort.Retention, float64(cell.Retained)/float64(cell.Size))
+	}
+
+	lifetimeQuery := fmt.Sprintf(`
+	WITH lifetimes AS (
+	SELECT service_name, end_date, start_date AS s, LEAST(COALESCE(end_date, $2::date), $2::date) AS e
+	FROM subscription
+	WHERE start_date >= $1::date AND start_date < ($2::date + interval '1 month') AND %s
+	)
+	SELECT service_name,
+	COUNT(*) AS subscriptions,
+	COUNT(end_date) FILTER (WHERE end_date < ($2::date + interval '1 month')) AS ended,
+	AVG(GREATEST(%s, 1))::float8 AS average_lifetime_months
+	FROM lifetimes
+	GROUP BY service_name
+	ORDER BY service_name`, serviceCond, billedMonthsSQL)
+
+	report.Lifetimes = []model.ServiceLifetime{}
+	if err := tx.SelectContext(ctx, &report.Lifetimes, lifetimeQuery, args...); err != nil {
+		return nil, fmt.Errorf("cohorts: lifetimes: %s", err.Error())
+	}
+
+	churnQuery := fmt.Sprintf(`
+	SELECT m::date AS month,
+	COUNT(sub.id) AS active,
+	COUNT(sub.id) FILTER (WHERE sub.end_date < m + interval '1 month') AS churned,
+	COALESCE(
+	(COUNT(sub.id) FILTER (WHERE sub.end_date < m + interval '1 month'))::float8 / NULLIF(COUNT(sub.id), 0),
+	0) AS churn_rate
+	FROM generate_series($1::date, $2::date, interval '1 month') AS m
+	LEFT JOIN (SELECT id, start_date, end_date FROM subscription WHERE %s) sub
+	ON sub.start_date < m AND (sub.end_date IS NULL OR sub.end_date >= m)
+	GROUP BY m
+	ORDER BY m`, serviceCond)
+
+	report.Churn = []model.MonthlyChurn{}
+	if err := tx.SelectContext(ctx, &report.Churn, churnQuery, args...); err != nil {
+		return nil, fmt.Errorf("cohorts: churn: %s", err.Error())
+	}
+
+	return report, nil
+}
+
+func topServices(services []rankedServiceStats, top int, rank func(rankedServiceStats) int) []model.ServiceStats {
+	ranked := make([]rankedServiceStats, 0, top)
+	for _, s := range services {
+		if rank(s) <= top {
+			ranked = append(ranked, s)
+		}
+	}
+
+	sort.Slice(ranked, func(i, j int) bool { return rank(ranked[i]) < rank(ranked[j]) })
+
+	result := make([]model.ServiceStats, 0, len(ranked))
+	for _, s := range ranked {
+		result = append(result, s.ServiceStats)
+	}
+
+	return result
+}
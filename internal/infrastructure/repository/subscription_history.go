@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+// recordVersion appends the state of s to subscription_version. Deleted versions are
+// tombstones hiding the subscription from point-in-time queries after recordedAt.
+func recordVersion(ctx context.Context, tx *sqlx.Tx, s model.Subscription, deleted bool, recordedAt time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO subscription_version (id, version, service_name, price, user_id, start_date, end_date, created_at, updated_at, deleted, recorded_at)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`,
+		s.ID, s.Version, s.ServiceName, s.Price, s.UserID, s.StartDate, nullableDate(s.EndDate), s.CreatedAt, s.UpdatedAt, deleted, recordedAt)
+	if err != nil {
+		return fmt.Errorf("record subscription %s version %d: %s", s.ID, s.Version, err.Error())
+	}
+
+	return nil
+}
+
+// snapshotCTE returns a WITH clause that shadows the subscription table with the state
+// its rows had at asOf, so queries written against the table run unchanged on the past
+// state. The asOf argument is appended to args.
+func snapshotCTE(asOf time.Time, args []interface{}) (string, []interface{}) {
+	cte := fmt.Sprintf(`WITH subscription AS (
+	SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at, version
+	FROM (
+	SELECT DISTINCT ON (id) *
+	FROM subscription_version
+	WHERE recorded_at <= $%d
+	ORDER BY id, version DESC
+	) v
+	WHERE NOT deleted
+	)
+	`, len(args)+1)
+
+	return cte, append(args, asOf)
+}
+
+// advanceChange increments the change counter. Every write transaction calls it before
+// touching a subscription: its row lock, held until the commit, orders the writes by
+// commit, and taking it first keeps writers from deadlocking on it. Reading the new
+// value back makes a missing counter row an error rather than an unordered write.
+func advanceChange(ctx context.Context, tx *sqlx.Tx) error {
+	var seq int64
+
+	err := tx.GetContext(ctx, &seq, `UPDATE subscription_change SET seq = seq + 1, changed_at = clock_timestamp() RETURNING seq`)
+	if err != nil {
+		return fmt.Errorf("advance change counter: %s", err.Error())
+	}
+
+	return nil
+}
+
+// LastChange returns the change counter, which identifies the committed state of the
+// subscriptions.
+func (sr *subscriptionRepository) LastChange(ctx context.Context) (model.Change, error) {
+	var row struct {
+		Seq       int64        `db:"seq"`
+		ChangedAt sql.NullTime `db:"changed_at"`
+	}
+
+	if err := sr.db.GetContext(ctx, &row, `SELECT seq, changed_at FROM subscription_change`); err != nil {
+		return model.Change{}, fmt.Errorf("find last change: %s", err.Error())
+	}
+
+	change := model.Change{Seq: row.Seq}
+	if row.ChangedAt.Valid {
+		change.At = row.ChangedAt.Time.UTC()
+	}
+
+	return change, nil
+}
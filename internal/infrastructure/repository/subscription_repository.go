@@ -4,216 +4,761 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
+	"Subscription_Service/internal/domain/filterexpr"
 	model "Subscription_Service/internal/domain/subscription"
 )
 
+// SubscriptionRepository stores the subscriptions. Every write returns the events of
+// the changes it made, whose webhook deliveries it stores in the same transaction: a
+// committed change is always delivered and a rolled back one never is.
 type SubscriptionRepository interface {
-	Create(ctx context.Context, s *model.Subscription) error
-	Read(ctx context.Context, id uuid.UUID) (*model.Subscription, error)
-	Update(ctx context.Context, s *model.Subscription) error
-	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, limit, offset int) ([]model.Subscription, error)
-	FindFiltered(ctx context.Context, userID *uuid.UUID, serviceName *string, limit, offset int) ([]model.Subscription, error)
-	CalculateCost(ctx context.Context, userID *uuid.UUID, serviceName *string, startDate, endDate time.Time) (int64, error)
+	// Create stores s and returns its created event.
+	Create(ctx context.Context, s *model.Subscription) ([]model.Event, error)
+	// Read returns the subscription, as of asOf when it is set. Fields restricts the
+	// columns read to the given ones, the ID and those the ETag needs, the version and
+	// update time; the others are left zero. Empty reads all.
+	Read(ctx context.Context, id uuid.UUID, asOf *time.Time, fields []string) (*model.Subscription, error)
+	// Update stores s and returns the events of the update.
+	Update(ctx context.Context, s *model.Subscription) ([]model.Event, error)
+	// Delete removes the subscription and returns its deleted event.
+	Delete(ctx context.Context, id uuid.UUID) ([]model.Event, error)
+	Batch(ctx context.Context, ops []model.BatchOperation, atomic bool) ([]model.BatchResult, error)
+	FindDuplicates(ctx context.Context, subs []model.Subscription) (map[model.DuplicateKey]bool, error)
+	List(ctx context.Context, filter model.SubscriptionFilter, page model.PageRequest, asOf *time.Time) (model.SubscriptionPage, error)
+	// ListPerUser returns at most limit subscriptions of each of the users, newest
+	// first.
+	ListPerUser(ctx context.Context, userIDs []uuid.UUID, limit int) ([]model.Subscription, error)
+	FindFiltered(ctx context.Context, filter model.SubscriptionFilter, page model.PageRequest, asOf *time.Time) (model.SubscriptionPage, error)
+	CalculateCost(ctx context.Context, filter model.SubscriptionFilter, startDate, endDate time.Time, asOf *time.Time) (int64, error)
+	Stream(ctx context.Context, filter model.SubscriptionFilter, asOf *time.Time, fn func(model.Subscription) error) error
+	StreamCostLines(ctx context.Context, filter model.SubscriptionFilter, startDate, endDate time.Time, asOf *time.Time, fn func(model.CostLine) error) error
+	// LastChange returns the change counter, which moves in commit order with every
+	// write transaction.
+	LastChange(ctx context.Context) (model.Change, error)
+	Search(ctx context.Context, q model.SearchQuery) ([]model.SearchHit, error)
 }
 
+// subscriptionColumns reads every column of a subscription.
+const subscriptionColumns = "id, service_name, price, user_id, start_date, end_date, created_at, updated_at, version"
+
+// billedMonthsSQL counts the calendar months between the bounds s and e, both inclusive.
+const billedMonthsSQL = `(
+	(date_part('year', e)::int - date_part('year', s)::int) * 12
+	+ (date_part('month', e)::int - date_part('month', s)::int) + 1
+	)`
+
+// EventEncoder encodes an event as the payload delivered to the webhooks.
+type EventEncoder func(e model.Event) ([]byte, error)
+
 type subscriptionRepository struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	encode EventEncoder
 }
 
-func NewSubscriptionRepository(db *sqlx.DB) SubscriptionRepository {
-	return &subscriptionRepository{db: db}
+// subscriptionRow mirrors the subscription table. The end date is nullable in the
+// database while the domain model represents an open-ended subscription with a
+// zero time.
+type subscriptionRow struct {
+	model.Subscription
+	EndDate sql.NullTime `db:"end_date"`
 }
 
-func (sr *subscriptionRepository) Create(ctx context.Context, s *model.Subscription) error {
-	query := `
-	INSERT INTO subscription (id, service_name, price, user_id, start_date, end_date, created_at, updated_at)
-	VALUES ($1,$2,$3,$4,$5,$6,$7,$8) 
-	`
-	now := time.Now().UTC()
+func (r subscriptionRow) toModel() model.Subscription {
+	s := r.Subscription
+	s.EndDate = time.Time{}
 
-	s.ID = uuid.New()
-	s.CreatedAt = now
-	s.UpdatedAt = now
+	if r.EndDate.Valid {
+		s.EndDate = r.EndDate.Time
+	}
 
-	_, err := sr.db.ExecContext(ctx, query, s.ID, s.ServiceName, s.Price, s.UserID, s.StartDate, s.EndDate, s.CreatedAt, s.UpdatedAt)
-	if err != nil {
-		return fmt.Errorf("failed to create subscription")
+	return s
+}
+
+func toModels(rows []subscriptionRow) []model.Subscription {
+	subs := make([]model.Subscription, 0, len(rows))
+	for _, r := range rows {
+		subs = append(subs, r.toModel())
 	}
 
-	return nil
+	return subs
+}
+
+func nullableDate(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// NewSubscriptionRepository returns the subscription repository. The events of the
+// writes are stored as webhook deliveries with the payload made by encode.
+func NewSubscriptionRepository(db *sqlx.DB, encode EventEncoder) SubscriptionRepository {
+	return &subscriptionRepository{
+		db:     db,
+		encode: encode,
+	}
+}
+
+func (sr *subscriptionRepository) Create(ctx context.Context, s *model.Subscription) ([]model.Event, error) {
+	return sr.write(ctx, model.BatchOperation{Kind: model.BatchCreate, Subscription: *s}, s)
 }
 
-func (sr *subscriptionRepository) Read(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
-	var s model.Subscription
+func (sr *subscriptionRepository) Read(ctx context.Context, id uuid.UUID, asOf *time.Time, fields []string) (*model.Subscription, error) {
+	var row subscriptionRow
 
-	err := sr.db.GetContext(ctx, &s, `SELECT * FROM subscription WHERE id=$1`, id)
+	columns := subscriptionColumns
+	if len(fields) > 0 {
+		columns = pageColumns(append(slices.Clone(fields), "version", "updated_at"), nil)
+	}
+
+	query := `SELECT ` + columns + ` FROM subscription WHERE id=$1`
+	args := []interface{}{id}
+
+	if asOf != nil {
+		var cte string
+		cte, args = snapshotCTE(*asOf, args)
+		query = cte + query
+	}
+
+	err := sr.db.GetContext(ctx, &row, query, args...)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("subscription with id %s not found", id)
+			return nil, fmt.Errorf("subscription with id %s %w", id, model.ErrNotFound)
 		}
 
 		return nil, fmt.Errorf("failed to get subscription %s: %s", id, err.Error())
 	}
 
-	return &s, err
+	s := row.toModel()
+
+	return &s, nil
 }
 
-func (sr *subscriptionRepository) Update(ctx context.Context, s *model.Subscription) error {
-	s.UpdatedAt = time.Now().UTC()
+func (sr *subscriptionRepository) Update(ctx context.Context, s *model.Subscription) ([]model.Event, error) {
+	return sr.write(ctx, model.BatchOperation{Kind: model.BatchUpdate, Subscription: *s}, s)
+}
 
-	result, err := sr.db.ExecContext(ctx, `UPDATE subscription SET service_name=$1, price=$2, user_id=$3, start_date=$4, end_date=$5, updated_at=$6 WHERE id=$7`,
-		s.ServiceName, s.Price, s.UserID, s.StartDate, s.EndDate, s.UpdatedAt, s.ID)
+func (sr *subscriptionRepository) Delete(ctx context.Context, id uuid.UUID) ([]model.Event, error) {
+	var last model.Subscription
 
+	return sr.write(ctx, model.BatchOperation{Kind: model.BatchDelete, Subscription: model.Subscription{ID: id}}, &last)
+}
+
+// write applies op in a transaction of its own, storing the resulting state of the
+// subscription in s.
+func (sr *subscriptionRepository) write(ctx context.Context, op model.BatchOperation, s *model.Subscription) ([]model.Event, error) {
+	result := sr.applyBatchOperation(ctx, op)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	*s = result.Subscription
+
+	return result.Events, nil
+}
+
+// pageColumns returns the column list reading the requested fields along with the ID
+// and the sort fields, which the page needs for its cursor.
+func pageColumns(fields []string, sort []model.SortField) string {
+	if len(fields) == 0 {
+		return subscriptionColumns
+	}
+
+	columns := []string{"id"}
+
+	add := func(column string) {
+		if !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+
+	for _, f := range fields {
+		add(f)
+	}
+
+	for _, f := range sort {
+		add(f.Field)
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+// Batch applies the operations in order and reports the outcome of each one. In atomic
+// mode they share one transaction that is rolled back on the first failure, leaving
+// the remaining operations unapplied; otherwise each runs in its own transaction.
+func (sr *subscriptionRepository) Batch(ctx context.Context, ops []model.BatchOperation, atomic bool) ([]model.BatchResult, error) {
+	results := make([]model.BatchResult, len(ops))
+
+	if !atomic {
+		for i, op := range ops {
+			results[i] = sr.applyBatchOperation(ctx, op)
+		}
+
+		return results, nil
+	}
+
+	tx, err := sr.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to update subscription %s: %s", s.ID, err.Error())
+		return nil, fmt.Errorf("begin transaction: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	if err := advanceChange(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		results[i] = sr.applyInTx(ctx, tx, op)
+		if results[i].Err != nil {
+			return model.RolledBack(results), nil
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %s", err.Error())
+	}
+
+	return results, nil
+}
+
+// FindDuplicates returns the duplicate keys of subs that existing subscriptions have.
+func (sr *subscriptionRepository) FindDuplicates(ctx context.Context, subs []model.Subscription) (map[model.DuplicateKey]bool, error) {
+	userIDs := make([]string, 0, len(subs))
+	names := make([]string, 0, len(subs))
+	starts := make([]string, 0, len(subs))
+
+	for _, s := range subs {
+		k := s.DuplicateKey()
+		userIDs = append(userIDs, k.UserID)
+		names = append(names, k.ServiceName)
+		starts = append(starts, k.StartDate)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	query := `
+	SELECT DISTINCT user_id::text AS user_id, lower(service_name) AS service_name, to_char(start_date, 'YYYY-MM-DD') AS start_date
+	FROM subscription
+	WHERE (user_id, lower(service_name), start_date) IN (
+	SELECT * FROM unnest($1::uuid[], $2::text[], $3::date[])
+	)`
+
+	var keys []model.DuplicateKey
+
+	err := sr.db.SelectContext(ctx, &keys, query, pq.Array(userIDs), pq.Array(names), pq.Array(starts))
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected for subscription %s: %s", s.ID, err.Error())
+		return nil, fmt.Errorf("find duplicate subscriptions: %s", err.Error())
+	}
+
+	found := make(map[model.DuplicateKey]bool, len(keys))
+	for _, k := range keys {
+		found[k] = true
+	}
+
+	return found, nil
+}
+
+func (sr *subscriptionRepository) applyBatchOperation(ctx context.Context, op model.BatchOperation) model.BatchResult {
+	var result model.BatchResult
+
+	err := sr.inTx(ctx, func(tx *sqlx.Tx) error {
+		result = sr.applyInTx(ctx, tx, op)
+		return result.Err
+	})
+
+	// The transaction itself failed, before the operation ran or when committing it.
+	if err != nil && result.Err == nil {
+		result = model.BatchResult{Subscription: op.Subscription, Err: err}
+	}
+
+	return result
+}
+
+// applyInTx applies op in tx and stores the webhook deliveries of its events there.
+func (sr *subscriptionRepository) applyInTx(ctx context.Context, tx *sqlx.Tx, op model.BatchOperation) model.BatchResult {
+	result := model.BatchResult{Subscription: op.Subscription}
+
+	switch op.Kind {
+	case model.BatchCreate:
+		if result.Err = create(ctx, tx, &result.Subscription); result.Err == nil {
+			result.Events = []model.Event{model.CreatedEvent(result.Subscription)}
+		}
+	case model.BatchUpdate:
+		var previous model.Subscription
+		if previous, result.Err = update(ctx, tx, &result.Subscription); result.Err == nil {
+			result.Events = model.UpdatedEvents(previous, result.Subscription)
+		}
+	case model.BatchDelete:
+		if result.Subscription, result.Err = remove(ctx, tx, op.Subscription.ID); result.Err == nil {
+			result.Events = []model.Event{model.DeletedEvent(result.Subscription, time.Now().UTC())}
+		}
+	default:
+		result.Err = fmt.Errorf("unknown batch operation %q", op.Kind)
+	}
+
+	if result.Err == nil {
+		result.Err = sr.enqueueEvents(ctx, tx, result.Events)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("subscription with id %s not found", s.ID)
+	result.Applied = result.Err == nil
+
+	return result
+}
+
+// enqueueEvents stores the webhook deliveries of the events in tx.
+func (sr *subscriptionRepository) enqueueEvents(ctx context.Context, tx *sqlx.Tx, events []model.Event) error {
+	now := time.Now().UTC()
+
+	for _, e := range events {
+		payload, err := sr.encode(e)
+		if err != nil {
+			return fmt.Errorf("encode event %s: %s", e.ID, err.Error())
+		}
+
+		if err := enqueueDeliveries(ctx, tx, e, payload, now); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (sr *subscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	result, err := sr.db.ExecContext(ctx, `DELETE FROM subscription WHERE id=$1`, id)
+func create(ctx context.Context, tx *sqlx.Tx, s *model.Subscription) error {
+	query := `
+	INSERT INTO subscription (id, service_name, price, user_id, start_date, end_date, created_at, updated_at, version)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) 
+	`
+	now := time.Now().UTC()
+
+	s.ID = uuid.New()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	s.Version = 1
+
+	_, err := tx.ExecContext(ctx, query, s.ID, s.ServiceName, s.Price, s.UserID, s.StartDate, nullableDate(s.EndDate), s.CreatedAt, s.UpdatedAt, s.Version)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription")
+	}
+
+	if err := recordVersion(ctx, tx, *s, false, now); err != nil {
+		return err
+	}
+
+	return applySpend(ctx, tx, *s, 1)
+}
+
+// update stores s and returns the state it replaced.
+func update(ctx context.Context, tx *sqlx.Tx, s *model.Subscription) (model.Subscription, error) {
+	s.UpdatedAt = time.Now().UTC()
+
+	var old subscriptionRow
+
+	err := tx.GetContext(ctx, &old, `SELECT * FROM subscription WHERE id=$1 FOR UPDATE`, s.ID)
 	if err != nil {
-		return fmt.Errorf("failed to delete subscription %s: %s", id, err.Error())
+		if err == sql.ErrNoRows {
+			return model.Subscription{}, fmt.Errorf("subscription with id %s %w", s.ID, model.ErrNotFound)
+		}
+
+		return model.Subscription{}, fmt.Errorf("failed to update subscription %s: %s", s.ID, err.Error())
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	s.CreatedAt = old.CreatedAt
+	s.Version = old.Version + 1
+
+	_, err = tx.ExecContext(ctx, `UPDATE subscription SET service_name=$1, price=$2, user_id=$3, start_date=$4, end_date=$5, updated_at=$6, version=$7 WHERE id=$8`,
+		s.ServiceName, s.Price, s.UserID, s.StartDate, nullableDate(s.EndDate), s.UpdatedAt, s.Version, s.ID)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected for subscription %s: %s", id, err.Error())
+		return model.Subscription{}, fmt.Errorf("failed to update subscription %s: %s", s.ID, err.Error())
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("subscription with id %s not found", id)
+	if err := recordVersion(ctx, tx, *s, false, s.UpdatedAt); err != nil {
+		return model.Subscription{}, err
 	}
 
-	return nil
+	if err := applySpend(ctx, tx, old.toModel(), -1); err != nil {
+		return model.Subscription{}, err
+	}
+
+	return old.toModel(), applySpend(ctx, tx, *s, 1)
 }
 
-func (sr *subscriptionRepository) List(ctx context.Context, limit, offset int) (subs []model.Subscription, err error) {
-	if limit <= 0 || limit > 1000 {
-		limit = 100
+// remove deletes the subscription and returns its last state.
+func remove(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (model.Subscription, error) {
+	var old subscriptionRow
+
+	err := tx.GetContext(ctx, &old, `DELETE FROM subscription WHERE id=$1 RETURNING *`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.Subscription{}, fmt.Errorf("subscription with id %s %w", id, model.ErrNotFound)
+		}
+
+		return model.Subscription{}, fmt.Errorf("failed to delete subscription %s: %s", id, err.Error())
 	}
 
-	if offset < 0 {
-		offset = 0
+	tombstone := old.toModel()
+	tombstone.Version++
+
+	if err := recordVersion(ctx, tx, tombstone, true, time.Now().UTC()); err != nil {
+		return model.Subscription{}, err
 	}
 
-	subs = []model.Subscription{}
-	err = sr.db.SelectContext(ctx, &subs, `SELECT * FROM subscription ORDER BY created_at DESC LIMIT $1 OFFSET $2`, limit, offset)
+	return old.toModel(), applySpend(ctx, tx, old.toModel(), -1)
+}
 
+func (sr *subscriptionRepository) List(ctx context.Context, filter model.SubscriptionFilter, page model.PageRequest, asOf *time.Time) (model.SubscriptionPage, error) {
+	result, err := sr.findPage(ctx, filter, page, asOf)
 	if err != nil {
-		return nil, fmt.Errorf("list subscription: %s", err.Error())
+		return model.SubscriptionPage{}, fmt.Errorf("list subscription: %s", err.Error())
 	}
 
-	return subs, err
+	return result, nil
 }
 
-func (sr *subscriptionRepository) FindFiltered(ctx context.Context, userID *uuid.UUID, serviceName *string, limit, offset int) (subs []model.Subscription, err error) {
-	if limit <= 0 || limit > 1000 {
-		limit = 100
+func (sr *subscriptionRepository) ListPerUser(ctx context.Context, userIDs []uuid.UUID, limit int) ([]model.Subscription, error) {
+	order := orderBy(model.DefaultSort)
+
+	// The limit applies to each user rather than to the whole result, so the rows are
+	// ranked within the subscriptions of their user.
+	query := `
+	SELECT ` + subscriptionColumns + `
+	FROM (
+	SELECT *, row_number() OVER (PARTITION BY user_id` + order + `) AS rank
+	FROM subscription
+	WHERE user_id = ANY($1)
+	) s
+	WHERE rank <= $2` + order
+
+	ids := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		ids = append(ids, id.String())
 	}
 
-	if offset < 0 {
-		offset = 0
+	var rows []subscriptionRow
+	if err := sr.db.SelectContext(ctx, &rows, query, pq.Array(ids), limit); err != nil {
+		return nil, fmt.Errorf("list subscriptions per user: %s", err.Error())
 	}
 
-	conds := make([]string, 0, 4)
-	args := make([]interface{}, 0, 4)
+	return toModels(rows), nil
+}
 
-	if userID != nil {
-		conds = append(conds, fmt.Sprintf("user_id = $%d", len(args)+1))
-		args = append(args, *userID)
+func (sr *subscriptionRepository) FindFiltered(ctx context.Context, filter model.SubscriptionFilter, page model.PageRequest, asOf *time.Time) (model.SubscriptionPage, error) {
+	result, err := sr.findPage(ctx, filter, page, asOf)
+	if err != nil {
+		return model.SubscriptionPage{}, fmt.Errorf("find filtered subscription: %s", err.Error())
 	}
 
-	if serviceName != nil && strings.TrimSpace(*serviceName) != "" {
-		conds = append(conds, fmt.Sprintf("service_name ILIKE $%d", len(args)+1))
-		args = append(args, "%"+strings.TrimSpace(*serviceName)+"%")
+	return result, nil
+}
+
+// findPage returns the page of subscriptions matching filter in its sort order. One row
+// more than requested is fetched to find out whether a next page exists.
+func (sr *subscriptionRepository) findPage(ctx context.Context, filter model.SubscriptionFilter, page model.PageRequest, asOf *time.Time) (model.SubscriptionPage, error) {
+	limit := page.Limit
+	if limit <= 0 {
+		limit = model.DefaultPageLimit
 	}
 
-	query := `SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at FROM subscription`
-	if len(conds) > 0 {
-		query += " WHERE " + strings.Join(conds, " AND ")
+	limit = min(limit, model.MaxPageLimit)
+	offset := max(page.Offset, 0)
+	sort := filter.SortOrDefault()
+
+	prefix := ""
+	args := make([]interface{}, 0, 12)
+
+	if asOf != nil {
+		prefix, args = snapshotCTE(*asOf, args)
 	}
 
-	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
-	args = append(args, limit, offset)
-	subs = []model.Subscription{}
+	conds, args := subscriptionConds(filter, args)
+
+	where := func(conds []string) string {
+		if len(conds) == 0 {
+			return ""
+		}
+
+		return " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	countQuery := prefix + `SELECT COUNT(*) FROM subscription` + where(conds)
+	countArgs := args
+
+	if page.After != nil {
+		cond, keysetArgs, err := keysetCond(sort, *page.After, args)
+		if err != nil {
+			return model.SubscriptionPage{}, err
+		}
+
+		conds = append(conds, cond)
+		args = keysetArgs
+		offset = 0
+	}
+
+	query := prefix + `SELECT ` + pageColumns(page.Fields, sort) + ` FROM subscription` + where(conds)
+	query += orderBy(sort) + fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, limit+1, offset)
+
+	rows := []subscriptionRow{}
+	result := model.SubscriptionPage{}
+
+	err := withSimilarityThreshold(ctx, sr.db, filter.ServiceName, func(q sqlx.QueryerContext) error {
+		if err := sqlx.SelectContext(ctx, q, &rows, query, args...); err != nil {
+			return err
+		}
+
+		if !page.CountTotal {
+			return nil
+		}
+
+		var total int64
+		if err := sqlx.GetContext(ctx, q, &total, countQuery, countArgs...); err != nil {
+			return err
+		}
+
+		result.Total = &total
 
-	err = sr.db.SelectContext(ctx, &subs, query, args...)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("find filtered subscription: %s", err.Error())
+		return model.SubscriptionPage{}, err
+	}
+
+	result.Subscriptions = toModels(rows)
+
+	if len(result.Subscriptions) > limit {
+		result.Subscriptions = result.Subscriptions[:limit]
+		result.Next = cursorAt(sort, result.Subscriptions[limit-1])
 	}
 
-	return subs, nil
+	return result, nil
 }
 
-func (sr *subscriptionRepository) CalculateCost(ctx context.Context, userID *uuid.UUID, serviceName *string, startDate, endDate time.Time) (int64, error) {
+// CalculateCost sums the monthly spend over the months from startDate to endDate. The
+// current state is read from the pre-aggregated monthly_spend_delta table when the filter
+// only restricts columns that table has; otherwise the subscriptions are scanned, past
+// states being recomputed from the subscription versions.
+func (sr *subscriptionRepository) CalculateCost(ctx context.Context, filter model.SubscriptionFilter, startDate, endDate time.Time, asOf *time.Time) (int64, error) {
 	ps := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, time.UTC)
 	pe := time.Date(endDate.Year(), endDate.Month(), 1, 0, 0, 0, 0, time.UTC)
 
-	conds := make([]string, 0, 4)
-	args := make([]interface{}, 0, 6)
+	conds, args := subscriptionConds(filter, make([]interface{}, 0, 8))
 
-	if userID != nil {
-		conds = append(conds, fmt.Sprintf("user_id = $%d", len(args)+1))
-		args = append(args, *userID)
-	}
+	var query string
 
-	if serviceName != nil && strings.TrimSpace(*serviceName) != "" {
-		conds = append(conds, fmt.Sprintf("service_name ILIKE $%d", len(args)+1))
-		args = append(args, "%"+strings.TrimSpace(*serviceName)+"%")
+	if asOf == nil && aggregatable(filter) {
+		query, args = aggregatedCostQuery(conds, args, ps, pe)
+	} else {
+		query, args = scannedCostQuery(conds, args, ps, pe, asOf)
 	}
 
-	conds = append(conds, fmt.Sprintf("start_date <= $%d", len(args)+1))
-	args = append(args, pe)
-	conds = append(conds, fmt.Sprintf("(end_date IS NULL OR end_date >= $%d)", len(args)+1))
-	args = append(args, ps)
+	var total sql.NullInt64
 
-	where := ""
-	if len(conds) > 0 {
-		where = "WHERE " + strings.Join(conds, " AND ")
+	err := withSimilarityThreshold(ctx, sr.db, filter.ServiceName, func(q sqlx.QueryerContext) error {
+		return sqlx.GetContext(ctx, q, &total, query, args...)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("calculate cost: %s", err.Error())
+	}
+
+	if !total.Valid {
+		return 0, nil
 	}
 
+	return total.Int64, nil
+}
+
+// aggregatedCostQuery sums monthly_spend_delta: a delta recorded in a month counts once
+// for every month of the period from that month on.
+func aggregatedCostQuery(conds []string, args []interface{}, ps, pe time.Time) (string, []interface{}) {
+	conds = append(conds, fmt.Sprintf("month <= $%d", len(args)+1))
+	args = append(args, pe)
+
 	query := fmt.Sprintf(`
 	WITH filtered AS (
-	SELECT price,
+	SELECT delta,
+	GREATEST(month, $%d::date) AS s,
+	$%d::date AS e
+	FROM monthly_spend_delta
+	WHERE %s
+	)
+	SELECT COALESCE(SUM(delta * %s), 0)::bigint AS total
+	FROM filtered`, len(args)+1, len(args)+2, strings.Join(conds, " AND "), billedMonthsSQL)
+
+	return query, append(args, ps, pe)
+}
+
+// aggregatable reports whether f only restricts the user and service columns that
+// monthly_spend_delta is keyed by.
+func aggregatable(f model.SubscriptionFilter) bool {
+	if f.PriceMin != nil || f.PriceMax != nil || f.StartFrom != nil || f.StartTo != nil ||
+		f.EndFrom != nil || f.EndTo != nil || f.ActiveAt != nil || f.OpenEnded {
+		return false
+	}
+
+	if f.Expr == nil {
+		return true
+	}
+
+	for _, field := range filterexpr.Fields(f.Expr) {
+		if field != "user_id" && field != "service_name" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// scannedCostQuery clamps every subscription active in the period to the period and
+// sums its price over the remaining months, using the state of the data at asOf when
+// given.
+func scannedCostQuery(conds []string, args []interface{}, ps, pe time.Time, asOf *time.Time) (string, []interface{}) {
+	cte, args := periodCTE(conds, args, ps, pe, asOf, "price")
+
+	return cte + fmt.Sprintf(`
+	SELECT COALESCE(SUM(price * %s), 0)::bigint AS total
+	FROM filtered`, billedMonthsSQL), args
+}
+
+// costLinesQuery is scannedCostQuery broken down by subscription.
+func costLinesQuery(conds []string, args []interface{}, ps, pe time.Time, asOf *time.Time) (string, []interface{}) {
+	cte, args := periodCTE(conds, args, ps, pe, asOf, "id, service_name, price, user_id, start_date, end_date, created_at, updated_at, version")
+
+	return cte + fmt.Sprintf(`
+	SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at, version,
+	%[1]s AS months,
+	(price * %[1]s)::bigint AS cost
+	FROM filtered
+	ORDER BY user_id, service_name, start_date, id`, billedMonthsSQL), args
+}
+
+// periodCTE returns a WITH clause defining filtered as the given columns of the
+// subscriptions active in the period, with s and e holding the first and last month
+// of the subscription clamped to the period.
+func periodCTE(conds []string, args []interface{}, ps, pe time.Time, asOf *time.Time, columns string) (string, []interface{}) {
+	cte := "WITH "
+	if asOf != nil {
+		cte, args = snapshotCTE(*asOf, args)
+		cte += ","
+	}
+
+	conds = append(conds, fmt.Sprintf("start_date < ($%d::date + interval '1 month')", len(args)+1))
+	args = append(args, pe)
+	conds = append(conds, fmt.Sprintf("(end_date IS NULL OR end_date >= $%d)", len(args)+1))
+	args = append(args, ps)
+
+	cte += fmt.Sprintf(`
+	filtered AS (
+	SELECT %s,
 	GREATEST(start_date, $%d::date) AS s,
 	LEAST(COALESCE(end_date, $%d::date), $%d::date) AS e
 	FROM subscription
-	%s
-	)
-	SELECT COALESCE(SUM(price * (
-	(date_part('year', e)::int - date_part('year', s)::int) * 12
-	+ (date_part('month', e)::int - date_part('month', s)::int) + 1
-	)), 0) AS total
-	FROM filtered`, len(args)+1, len(args)+2, len(args)+2, where)
+	WHERE %s
+	)`, columns, len(args)+1, len(args)+2, len(args)+2, strings.Join(conds, " AND "))
+
+	return cte, append(args, ps, pe)
+}
 
-	args = append(args, ps, pe)
+// Stream calls fn for every subscription matching filter in its sort order, reading the
+// rows from the database cursor one at a time instead of loading them all. An error
+// returned by fn stops the iteration.
+func (sr *subscriptionRepository) Stream(ctx context.Context, filter model.SubscriptionFilter, asOf *time.Time, fn func(model.Subscription) error) error {
+	prefix := ""
+	args := make([]interface{}, 0, 8)
 
-	var total sql.NullInt64
-	if err := sr.db.GetContext(ctx, &total, query, args...); err != nil {
-		return 0, fmt.Errorf("calculate cost: %s", err.Error())
+	if asOf != nil {
+		prefix, args = snapshotCTE(*asOf, args)
 	}
 
-	if !total.Valid {
-		return 0, nil
+	conds, args := subscriptionConds(filter, args)
+
+	query := prefix + `SELECT ` + subscriptionColumns + ` FROM subscription`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
 	}
 
-	return total.Int64, nil
+	query += orderBy(filter.SortOrDefault())
+
+	return streamRows(ctx, sr.db, filter, query, args, func(rows *sqlx.Rows) error {
+		var row subscriptionRow
+		if err := rows.StructScan(&row); err != nil {
+			return err
+		}
+
+		return fn(row.toModel())
+	})
+}
+
+// StreamCostLines calls fn for every subscription billed in the months from startDate
+// to endDate with its share of the cost, as CalculateCost sums it over the scanned
+// subscriptions.
+func (sr *subscriptionRepository) StreamCostLines(ctx context.Context, filter model.SubscriptionFilter, startDate, endDate time.Time, asOf *time.Time, fn func(model.CostLine) error) error {
+	ps := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	pe := time.Date(endDate.Year(), endDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	conds, args := subscriptionConds(filter, make([]interface{}, 0, 8))
+	query, args := costLinesQuery(conds, args, ps, pe, asOf)
+
+	return streamRows(ctx, sr.db, filter, query, args, func(rows *sqlx.Rows) error {
+		var row struct {
+			subscriptionRow
+			Months int   `db:"months"`
+			Cost   int64 `db:"cost"`
+		}
+
+		if err := rows.StructScan(&row); err != nil {
+			return err
+		}
+
+		return fn(model.CostLine{Subscription: row.toModel(), Months: row.Months, Cost: row.Cost})
+	})
+}
+
+// streamRows runs query and calls scan for each row while the cursor is open.
+func streamRows(ctx context.Context, db *sqlx.DB, filter model.SubscriptionFilter, query string, args []interface{}, scan func(rows *sqlx.Rows) error) error {
+	err := withSimilarityThreshold(ctx, db, filter.ServiceName, func(q sqlx.QueryerContext) error {
+		rows, err := q.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			if err := scan(rows); err != nil {
+				return err
+			}
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return fmt.Errorf("stream subscriptions: %s", err.Error())
+	}
+
+	return nil
+}
+
+// inTx runs the writes of fn in a transaction, which advances the change counter.
+func (sr *subscriptionRepository) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := sr.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %s", err.Error())
+	}
+
+	if err := advanceChange(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %s", err.Error())
+	}
+
+	return nil
 }
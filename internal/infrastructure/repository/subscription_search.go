@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	model "Subscription_Service/internal/domain/subscription"
+	"Subscription_Service/pkg/textsearch"
+)
+
+// Search ranks the subscriptions by the word similarity of their service name to the
+// search text and its transliterations. The <% operator is backed by the trigram index
+// and compares against pg_trgm.word_similarity_threshold, set for the transaction.
+func (sr *subscriptionRepository) Search(ctx context.Context, q model.SearchQuery) ([]model.SearchHit, error) {
+	conds, args := subscriptionConds(model.SubscriptionFilter{UserID: q.UserID}, make([]interface{}, 0, 6))
+
+	terms := textsearch.Variants(q.Text)
+	matches := make([]string, 0, len(terms))
+	scores := make([]string, 0, len(terms))
+
+	for _, term := range terms {
+		args = append(args, term)
+		matches = append(matches, fmt.Sprintf("$%d <%% service_name", len(args)))
+		scores = append(scores, fmt.Sprintf("word_similarity($%d, service_name)", len(args)))
+	}
+
+	conds = append(conds, "("+strings.Join(matches, " OR ")+")")
+
+	query := `SELECT ` + subscriptionColumns + `, GREATEST(` + strings.Join(scores, ", ") + `) AS score
+	FROM subscription
+	WHERE ` + strings.Join(conds, " AND ") + fmt.Sprintf(`
+	ORDER BY score DESC, service_name, id
+	LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, q.Limit, q.Offset)
+
+	var rows []struct {
+		subscriptionRow
+		Score float64 `db:"score"`
+	}
+
+	tx, err := sr.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	threshold := strconv.FormatFloat(q.Threshold, 'f', -1, 64)
+
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)`, threshold); err != nil {
+		return nil, fmt.Errorf("set word similarity threshold: %s", err.Error())
+	}
+
+	if err := sqlx.SelectContext(ctx, tx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("search subscriptions: %s", err.Error())
+	}
+
+	hits := make([]model.SearchHit, 0, len(rows))
+	for _, r := range rows {
+		hits = append(hits, model.SearchHit{Subscription: r.toModel(), Score: r.Score})
+	}
+
+	return hits, nil
+}
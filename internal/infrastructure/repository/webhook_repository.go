@@ -0,0 +1,343 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	model "Subscription_Service/internal/domain/subscription"
+)
+
+// WebhookRepository stores the webhooks, the queue of their deliveries and the log of
+// the attempts made. The deliveries are enqueued by the subscription writes.
+type WebhookRepository interface {
+	Create(ctx context.Context, w *model.Webhook) error
+	Read(ctx context.Context, id uuid.UUID) (*model.Webhook, error)
+	List(ctx context.Context) ([]model.Webhook, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Claim takes up to limit pending deliveries due at now and hides them from other
+	// claims until leaseUntil, so that a delivery whose attempt is never recorded is
+	// retried after the lease.
+	Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.DeliveryTask, error)
+	// RecordAttempt logs an attempt of a claimed delivery and stores the status, attempt
+	// count, next attempt and last outcome of d.
+	RecordAttempt(ctx context.Context, d model.WebhookDelivery, attempt model.DeliveryAttempt) error
+	// Deliveries returns the newest deliveries of the webhook with their attempts,
+	// optionally only those with the given status.
+	Deliveries(ctx context.Context, webhookID uuid.UUID, status model.DeliveryStatus, limit int) ([]model.WebhookDelivery, error)
+	// Redeliver makes a dead delivery pending again, due at now, with its attempts
+	// counted anew.
+	Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID, now time.Time) (*model.WebhookDelivery, error)
+}
+
+const deliveryColumns = "id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at"
+
+type webhookRow struct {
+	ID        uuid.UUID      `db:"id"`
+	URL       string         `db:"url"`
+	Events    pq.StringArray `db:"events"`
+	Secret    string         `db:"secret"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+func (r webhookRow) toModel() model.Webhook {
+	w := model.Webhook{ID: r.ID, URL: r.URL, Secret: r.Secret, CreatedAt: r.CreatedAt}
+
+	for _, e := range r.Events {
+		w.Events = append(w.Events, model.EventType(e))
+	}
+
+	return w
+}
+
+type deliveryRow struct {
+	ID             uuid.UUID      `db:"id"`
+	WebhookID      uuid.UUID      `db:"webhook_id"`
+	EventID        uuid.UUID      `db:"event_id"`
+	EventType      string         `db:"event_type"`
+	Payload        string         `db:"payload"`
+	Status         string         `db:"status"`
+	Attempts       int            `db:"attempts"`
+	NextAttemptAt  time.Time      `db:"next_attempt_at"`
+	LastStatusCode sql.NullInt64  `db:"last_status_code"`
+	LastError      sql.NullString `db:"last_error"`
+	CreatedAt      time.Time      `db:"created_at"`
+	DeliveredAt    sql.NullTime   `db:"delivered_at"`
+}
+
+func (r deliveryRow) toModel() model.WebhookDelivery {
+	return model.WebhookDelivery{
+		ID:             r.ID,
+		WebhookID:      r.WebhookID,
+		EventID:        r.EventID,
+		EventType:      model.EventType(r.EventType),
+		Payload:        []byte(r.Payload),
+		Status:         model.DeliveryStatus(r.Status),
+		Attempts:       r.Attempts,
+		NextAttemptAt:  r.NextAttemptAt,
+		LastStatusCode: int(r.LastStatusCode.Int64),
+		LastError:      r.LastError.String,
+		CreatedAt:      r.CreatedAt,
+		DeliveredAt:    r.DeliveredAt.Time,
+	}
+}
+
+type attemptRow struct {
+	DeliveryID  uuid.UUID      `db:"delivery_id"`
+	StatusCode  sql.NullInt64  `db:"status_code"`
+	Error       sql.NullString `db:"error"`
+	DurationMS  int64          `db:"duration_ms"`
+	AttemptedAt time.Time      `db:"attempted_at"`
+}
+
+func (r attemptRow) toModel() model.DeliveryAttempt {
+	return model.DeliveryAttempt{
+		StatusCode:  int(r.StatusCode.Int64),
+		Error:       r.Error.String,
+		Duration:    time.Duration(r.DurationMS) * time.Millisecond,
+		AttemptedAt: r.AttemptedAt,
+	}
+}
+
+type webhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (wr *webhookRepository) Create(ctx context.Context, w *model.Webhook) error {
+	events := make([]string, 0, len(w.Events))
+	for _, e := range w.Events {
+		events = append(events, string(e))
+	}
+
+	_, err := wr.db.ExecContext(ctx, `
+	INSERT INTO webhook (id, url, events, secret, created_at)
+	VALUES ($1, $2, $3, $4, $5)`,
+		w.ID, w.URL, pq.Array(events), w.Secret, w.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create webhook: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (wr *webhookRepository) Read(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	var row webhookRow
+
+	err := wr.db.GetContext(ctx, &row, `SELECT id, url, events, secret, created_at FROM webhook WHERE id = $1`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook with id %s %w", id, model.ErrNotFound)
+		}
+
+		return nil, fmt.Errorf("get webhook %s: %s", id, err.Error())
+	}
+
+	w := row.toModel()
+
+	return &w, nil
+}
+
+func (wr *webhookRepository) List(ctx context.Context) ([]model.Webhook, error) {
+	var rows []webhookRow
+
+	err := wr.db.SelectContext(ctx, &rows, `SELECT id, url, events, secret, created_at FROM webhook ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %s", err.Error())
+	}
+
+	webhooks := make([]model.Webhook, 0, len(rows))
+	for _, r := range rows {
+		webhooks = append(webhooks, r.toModel())
+	}
+
+	return webhooks, nil
+}
+
+func (wr *webhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := wr.db.ExecContext(ctx, `DELETE FROM webhook WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook %s: %s", id, err.Error())
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("webhook with id %s %w", id, model.ErrNotFound)
+	}
+
+	return nil
+}
+
+// enqueueDeliveries adds a pending delivery of the event, due at now, for every webhook
+// receiving its type. It runs in the transaction of the change the event reports.
+func enqueueDeliveries(ctx context.Context, tx *sqlx.Tx, event model.Event, payload []byte, now time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO webhook_delivery (id, webhook_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+	SELECT gen_random_uuid(), id, $1, $2, $3, $4, $5, $5
+	FROM webhook
+	WHERE $2 = ANY(events)`,
+		event.ID, string(event.Type), string(payload), string(model.DeliveryPending), now)
+	if err != nil {
+		return fmt.Errorf("enqueue event %s: %s", event.ID, err.Error())
+	}
+
+	return nil
+}
+
+func (wr *webhookRepository) Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.DeliveryTask, error) {
+	var rows []struct {
+		deliveryRow
+		URL    string `db:"url"`
+		Secret string `db:"secret"`
+	}
+
+	// SKIP LOCKED lets several instances claim concurrently without waiting on each
+	// other or taking the same delivery.
+	err := wr.db.SelectContext(ctx, &rows, `
+	WITH due AS (
+	SELECT id FROM webhook_delivery
+	WHERE status = $3 AND next_attempt_at <= $1
+	ORDER BY next_attempt_at
+	LIMIT $4
+	FOR UPDATE SKIP LOCKED
+	)
+	UPDATE webhook_delivery d SET next_attempt_at = $2
+	FROM due, webhook w
+	WHERE d.id = due.id AND w.id = d.webhook_id
+	RETURNING d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+	d.last_status_code, d.last_error, d.created_at, d.delivered_at, w.url, w.secret`,
+		now, leaseUntil, string(model.DeliveryPending), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %s", err.Error())
+	}
+
+	tasks := make([]model.DeliveryTask, 0, len(rows))
+	for _, r := range rows {
+		tasks = append(tasks, model.DeliveryTask{Delivery: r.toModel(), URL: r.URL, Secret: r.Secret})
+	}
+
+	return tasks, nil
+}
+
+func (wr *webhookRepository) RecordAttempt(ctx context.Context, d model.WebhookDelivery, attempt model.DeliveryAttempt) error {
+	tx, err := wr.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO webhook_attempt (delivery_id, status_code, error, duration_ms, attempted_at)
+	VALUES ($1, $2, $3, $4, $5)`,
+		d.ID, nullableInt(attempt.StatusCode), nullableString(attempt.Error), attempt.Duration.Milliseconds(), attempt.AttemptedAt)
+	if err != nil {
+		return fmt.Errorf("record attempt of delivery %s: %s", d.ID, err.Error())
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE webhook_delivery
+	SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6, delivered_at = $7
+	WHERE id = $1`,
+		d.ID, string(d.Status), d.Attempts, d.NextAttemptAt, nullableInt(d.LastStatusCode), nullableString(d.LastError), nullableDate(d.DeliveredAt))
+	if err != nil {
+		return fmt.Errorf("update delivery %s: %s", d.ID, err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (wr *webhookRepository) Deliveries(ctx context.Context, webhookID uuid.UUID, status model.DeliveryStatus, limit int) ([]model.WebhookDelivery, error) {
+	var rows []deliveryRow
+
+	err := wr.db.SelectContext(ctx, &rows, `
+	SELECT `+deliveryColumns+` FROM webhook_delivery
+	WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+	ORDER BY created_at DESC, id
+	LIMIT $3`,
+		webhookID, string(status), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries of webhook %s: %s", webhookID, err.Error())
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0, len(rows))
+	index := make(map[uuid.UUID]int, len(rows))
+	ids := make([]string, 0, len(rows))
+
+	for i, r := range rows {
+		deliveries = append(deliveries, r.toModel())
+		index[r.ID] = i
+		ids = append(ids, r.ID.String())
+	}
+
+	if len(ids) == 0 {
+		return deliveries, nil
+	}
+
+	var attempts []attemptRow
+
+	err = wr.db.SelectContext(ctx, &attempts, `
+	SELECT delivery_id, status_code, error, duration_ms, attempted_at FROM webhook_attempt
+	WHERE delivery_id = ANY($1::uuid[])
+	ORDER BY attempted_at DESC, id DESC`,
+		pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("list delivery attempts of webhook %s: %s", webhookID, err.Error())
+	}
+
+	for _, a := range attempts {
+		d := &deliveries[index[a.DeliveryID]]
+		d.Log = append(d.Log, a.toModel())
+	}
+
+	return deliveries, nil
+}
+
+func (wr *webhookRepository) Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID, now time.Time) (*model.WebhookDelivery, error) {
+	var row deliveryRow
+
+	err := wr.db.GetContext(ctx, &row, `
+	UPDATE webhook_delivery SET status = $3, attempts = 0, next_attempt_at = $4
+	WHERE id = $1 AND webhook_id = $2 AND status = $5
+	RETURNING `+deliveryColumns,
+		deliveryID, webhookID, string(model.DeliveryPending), now, string(model.DeliveryDead))
+	if err == nil {
+		d := row.toModel()
+		return &d, nil
+	}
+
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("redeliver delivery %s: %s", deliveryID, err.Error())
+	}
+
+	var exists bool
+
+	err = wr.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM webhook_delivery WHERE id = $1 AND webhook_id = $2)`, deliveryID, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("redeliver delivery %s: %s", deliveryID, err.Error())
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("delivery with id %s %w", deliveryID, model.ErrNotFound)
+	}
+
+	return nil, model.ErrDeliveryNotDead
+}
+
+func nullableInt(n int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0}
+}
+
+func nullableString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,8 @@
+--liquibase formatted sql
+
+--changeset matvey:0002_open_ended_end_date
+UPDATE subscription SET end_date = NULL WHERE end_date = '0001-01-01';
+
+--changeset matvey:0002_stats_indexes
+CREATE INDEX IF NOT EXISTS idx_subscription_start_date ON subscription(start_date);
+CREATE INDEX IF NOT EXISTS idx_subscription_end_date ON subscription(end_date);
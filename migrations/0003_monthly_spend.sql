@@ -0,0 +1,31 @@
+--liquibase formatted sql
+
+--changeset matvey:0003_create_monthly_spend_delta_table
+-- Monthly spend of a user on a service is the running sum of delta over the months
+-- up to and including the month in question: a subscription adds its price in the
+-- month it starts and subtracts it in the month after it ends.
+CREATE TABLE IF NOT EXISTS monthly_spend_delta (
+    user_id UUID NOT NULL,
+    service_name TEXT NOT NULL,
+    month DATE NOT NULL,
+    delta BIGINT NOT NULL,
+    PRIMARY KEY (user_id, service_name, month)
+);
+
+--changeset matvey:0003_monthly_spend_delta_indexes
+CREATE INDEX IF NOT EXISTS idx_monthly_spend_delta_month ON monthly_spend_delta(month);
+CREATE INDEX IF NOT EXISTS idx_monthly_spend_delta_service_trgm ON monthly_spend_delta USING gin (service_name gin_trgm_ops);
+
+--changeset matvey:0003_populate_monthly_spend_delta
+INSERT INTO monthly_spend_delta (user_id, service_name, month, delta)
+SELECT user_id, service_name, month, SUM(delta)
+FROM (
+    SELECT user_id, service_name, date_trunc('month', start_date)::date AS month, price::bigint AS delta
+    FROM subscription
+    UNION ALL
+    SELECT user_id, service_name, (date_trunc('month', end_date) + interval '1 month')::date AS month, -price::bigint AS delta
+    FROM subscription
+    WHERE end_date IS NOT NULL
+) d
+GROUP BY user_id, service_name, month
+HAVING SUM(delta) <> 0;
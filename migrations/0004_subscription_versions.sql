@@ -0,0 +1,31 @@
+--liquibase formatted sql
+
+--changeset matvey:0004_subscription_version_column
+ALTER TABLE subscription ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+--changeset matvey:0004_create_subscription_version_table
+-- Every write to subscription appends the resulting state of the row here, deletes
+-- append a tombstone. recorded_at is the time the state became current.
+CREATE TABLE IF NOT EXISTS subscription_version (
+    id UUID NOT NULL,
+    version INTEGER NOT NULL,
+    service_name TEXT NOT NULL,
+    price INTEGER NOT NULL,
+    user_id UUID NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deleted BOOLEAN NOT NULL DEFAULT false,
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_version_recorded_at ON subscription_version(recorded_at);
+
+--changeset matvey:0004_populate_subscription_version
+-- Earlier states of existing rows are unknown, their history starts at the last update.
+INSERT INTO subscription_version (id, version, service_name, price, user_id, start_date, end_date, created_at, updated_at, deleted, recorded_at)
+SELECT id, version, service_name, price, user_id, start_date, end_date, created_at, updated_at, false, updated_at
+FROM subscription
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+--liquibase formatted sql
+
+--changeset matvey:0005_subscription_keyset_index
+CREATE INDEX IF NOT EXISTS idx_subscription_created_at_id ON subscription(created_at DESC, id DESC);
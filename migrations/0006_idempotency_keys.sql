@@ -0,0 +1,18 @@
+--liquibase formatted sql
+
+--changeset matvey:0006_idempotency_keys
+CREATE TABLE IF NOT EXISTS idempotency_key (
+    key          TEXT        NOT NULL,
+    method       TEXT        NOT NULL,
+    path         TEXT        NOT NULL,
+    request_hash TEXT        NOT NULL,
+    -- status is NULL while the first request with the key is being processed.
+    status       INTEGER,
+    content_type TEXT,
+    body         BYTEA,
+    created_at   TIMESTAMPTZ NOT NULL,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (key, method, path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_key_expires_at ON idempotency_key(expires_at);
@@ -0,0 +1,10 @@
+--liquibase formatted sql
+
+--changeset matvey:0007_calendar_tokens
+-- A user has at most one calendar feed token; issuing a new one revokes the old.
+-- Only a SHA-256 hash of the token is stored.
+CREATE TABLE IF NOT EXISTS calendar_token (
+    user_id    UUID        PRIMARY KEY,
+    token_hash TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
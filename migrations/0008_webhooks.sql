@@ -0,0 +1,46 @@
+--liquibase formatted sql
+
+--changeset matvey:0008_webhooks
+-- Webhooks receive the events of the listed types. The secret signs the deliveries and
+-- is kept in clear because the signatures are computed from it.
+CREATE TABLE IF NOT EXISTS webhook (
+    id         UUID        PRIMARY KEY,
+    url        TEXT        NOT NULL,
+    events     TEXT[]      NOT NULL,
+    secret     TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- One row per event and webhook. Pending rows are attempted at next_attempt_at; a
+-- claimed row has next_attempt_at pushed past the attempt so no other instance takes
+-- it. The payload is stored as sent, so text rather than jsonb.
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    id               UUID        PRIMARY KEY,
+    webhook_id       UUID        NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+    event_id         UUID        NOT NULL,
+    event_type       TEXT        NOT NULL,
+    payload          TEXT        NOT NULL,
+    status           TEXT        NOT NULL,
+    attempts         INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL,
+    last_status_code INTEGER,
+    last_error       TEXT,
+    created_at       TIMESTAMPTZ NOT NULL,
+    delivered_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_due ON webhook_delivery(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_webhook ON webhook_delivery(webhook_id, created_at DESC);
+
+-- The delivery log: every request sent, including those of earlier rounds of a
+-- redelivered delivery.
+CREATE TABLE IF NOT EXISTS webhook_attempt (
+    id           BIGINT      GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    delivery_id  UUID        NOT NULL REFERENCES webhook_delivery(id) ON DELETE CASCADE,
+    status_code  INTEGER,
+    error        TEXT,
+    duration_ms  INTEGER     NOT NULL,
+    attempted_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_attempt_delivery ON webhook_attempt(delivery_id);
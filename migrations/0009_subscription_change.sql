@@ -0,0 +1,17 @@
+--liquibase formatted sql
+
+--changeset matvey:0009_subscription_change
+-- A single row counting the changes to the subscriptions. Every write transaction
+-- increments it first and holds its lock until it commits, so the counter grows in
+-- commit order: a reader seeing the same seq sees the same subscriptions. It validates
+-- the cached listings, which a wall-clock time could not, as transactions commit in
+-- another order than their clocks read.
+CREATE TABLE IF NOT EXISTS subscription_change (
+    id         BOOLEAN     PRIMARY KEY DEFAULT TRUE CHECK (id),
+    seq        BIGINT      NOT NULL,
+    changed_at TIMESTAMPTZ
+);
+
+INSERT INTO subscription_change (id, seq, changed_at)
+SELECT TRUE, count(*), max(recorded_at) FROM subscription_version
+ON CONFLICT (id) DO NOTHING;
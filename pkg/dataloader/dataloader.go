@@ -0,0 +1,91 @@
+// Package dataloader batches the loads made while resolving one level of a GraphQL
+// query into a single call.
+//
+// Resolvers call Load, which only records the key, and return the thunk it gives
+// back. The executor resolves every field of a level before calling the thunks, so the
+// first thunk called loads all the keys recorded by then in one batch. Loaded values
+// are cached for the lifetime of the loader, which is meant to be one request.
+package dataloader
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchFunc loads the values of keys. Keys missing from the result load as the zero
+// value.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+type Loader[K comparable, V any] struct {
+	batch BatchFunc[K, V]
+
+	mu      sync.Mutex
+	pending []K
+	loaded  map[K]V
+	errs    map[K]error
+}
+
+func New[K comparable, V any](batch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		batch:  batch,
+		loaded: make(map[K]V),
+		errs:   make(map[K]error),
+	}
+}
+
+// Load schedules key for the next batch and returns a thunk yielding its value.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	if !l.done(key) && !l.isPending(key) {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if !l.done(key) {
+			l.dispatch(ctx)
+		}
+
+		return l.loaded[key], l.errs[key]
+	}
+}
+
+// dispatch loads the pending keys. The caller holds l.mu.
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+
+	values, err := l.batch(ctx, keys)
+
+	for _, k := range keys {
+		if err != nil {
+			l.errs[k] = err
+			continue
+		}
+
+		l.loaded[k] = values[k]
+	}
+}
+
+func (l *Loader[K, V]) done(key K) bool {
+	if _, ok := l.loaded[key]; ok {
+		return true
+	}
+
+	_, failed := l.errs[key]
+
+	return failed
+}
+
+func (l *Loader[K, V]) isPending(key K) bool {
+	for _, k := range l.pending {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,136 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+type GRPCServer struct {
+	logger *slog.Logger
+	server *grpc.Server
+	health *health.Server
+	config *Config
+}
+
+type Config struct {
+	Host            string
+	Port            string
+	StartMsg        string
+	ShutdownTimeout time.Duration
+}
+
+// NewServer creates a gRPC server with the services added by register, the standard
+// health service and server reflection. The health service reports the server and
+// each registered service as serving until shutdown begins.
+func NewServer(logger *slog.Logger, config *Config, register func(*grpc.Server)) *GRPCServer {
+	s := &GRPCServer{
+		logger: logger,
+		health: health.NewServer(),
+		config: config,
+	}
+
+	s.server = grpc.NewServer(grpc.ChainUnaryInterceptor(s.recover, s.log))
+
+	register(s.server)
+
+	for name := range s.server.GetServiceInfo() {
+		s.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	healthpb.RegisterHealthServer(s.server, s.health)
+	reflection.Register(s.server)
+
+	return s
+}
+
+func (s *GRPCServer) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.config.Host+":"+s.config.Port)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info(s.config.StartMsg)
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Context cancelled, shutting down gRPC server gracefully...")
+		case <-sigCh:
+			s.logger.Info("Shutdown signal received, shutting down gRPC server gracefully...")
+		}
+
+		s.health.Shutdown()
+
+		stopped := make(chan struct{})
+		go func() {
+			s.server.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(s.config.ShutdownTimeout):
+			s.logger.Error("Graceful shutdown of the gRPC server timed out, closing open calls")
+			s.server.Stop()
+		}
+
+		s.logger.Info("gRPC server is shutdown!")
+
+		return nil
+	})
+
+	g.Go(func() error {
+		return s.server.Serve(listener)
+	})
+
+	return g.Wait()
+}
+
+// recover turns a panicking call into an Internal status, as the HTTP server's
+// recovery middleware does for requests.
+func (s *GRPCServer) recover(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("Panic in gRPC call",
+				slog.String("method", info.FullMethod),
+				slog.Any("panic", r),
+				slog.String("stack", string(debug.Stack())))
+
+			err = status.Error(codes.Internal, "an unexpected error occurred")
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+func (s *GRPCServer) log(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	resp, err := handler(ctx, req)
+
+	s.logger.Info("gRPC call",
+		slog.String("method", info.FullMethod),
+		slog.String("code", status.Code(err).String()),
+		slog.Duration("duration", time.Since(start)))
+
+	return resp, err
+}
@@ -48,6 +48,12 @@ func NewServer(logger *slog.Logger, config *Config) *HTTPServer {
 	return s
 }
 
+// RegisterOnShutdown registers f to be called when the server starts shutting down.
+// Shutdown does not interrupt active requests, so f has to end the long-lived ones.
+func (s *HTTPServer) RegisterOnShutdown(f func()) {
+	s.server.RegisterOnShutdown(f)
+}
+
 func (s *HTTPServer) Start(ctx context.Context) error {
 	s.logger.Info(s.config.StartMsg)
 
@@ -0,0 +1,135 @@
+// Package ical writes iCalendar (RFC 5545) feeds of all-day events.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLineOctets is the longest content line allowed before folding, CRLF excluded.
+const maxLineOctets = 75
+
+type Calendar struct {
+	// ProdID identifies the product that created the feed, such as
+	// -//Example Corp//Product//EN.
+	ProdID string
+	Name   string
+	// RefreshInterval suggests how often clients should poll the feed; zero omits it.
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Event is an all-day event. Clients match events across updates of the feed by UID
+// and keep the one with the highest Sequence.
+type Event struct {
+	UID      string
+	Sequence int
+	// Stamp is when the event was last modified.
+	Stamp       time.Time
+	Date        time.Time
+	Summary     string
+	Description string
+	// RRule is the recurrence rule of a recurring event, such as FREQ=MONTHLY.
+	RRule string
+}
+
+// Write encodes cal as an iCalendar stream.
+func Write(w io.Writer, cal Calendar) error {
+	bw := bufio.NewWriter(w)
+
+	line := func(name, value string) {
+		writeLine(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", cal.ProdID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+
+	if cal.Name != "" {
+		line("X-WR-CALNAME", escape(cal.Name))
+	}
+
+	if cal.RefreshInterval > 0 {
+		d := duration(cal.RefreshInterval)
+		line("REFRESH-INTERVAL;VALUE=DURATION", d)
+		line("X-PUBLISHED-TTL", d)
+	}
+
+	for _, e := range cal.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("SEQUENCE", strconv.Itoa(e.Sequence))
+		line("DTSTAMP", e.Stamp.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE", Date(e.Date))
+		line("DTEND;VALUE=DATE", Date(e.Date.AddDate(0, 0, 1)))
+
+		if e.RRule != "" {
+			line("RRULE", e.RRule)
+		}
+
+		line("SUMMARY", escape(e.Summary))
+
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+
+		line("TRANSP", "TRANSPARENT")
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+
+	return bw.Flush()
+}
+
+// Date formats t as an iCalendar DATE value, as used by DTSTART and by the UNTIL part
+// of a recurrence rule of an all-day event.
+func Date(t time.Time) string {
+	return t.Format("20060102")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func escape(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeLine writes a content line folded into lines of at most 75 octets, never
+// splitting a UTF-8 sequence. Continuation lines start with a space.
+func writeLine(w *bufio.Writer, s string) {
+	limit := maxLineOctets
+
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+
+		s = s[cut:]
+		limit = maxLineOctets - 1
+	}
+
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+// duration formats d as an iCalendar duration in whole minutes, such as PT12H.
+func duration(d time.Duration) string {
+	minutes := int(d.Minutes())
+	if minutes%60 == 0 {
+		return "PT" + strconv.Itoa(minutes/60) + "H"
+	}
+
+	return "PT" + strconv.Itoa(minutes) + "M"
+}
@@ -0,0 +1,446 @@
+// Package jsonpatch applies JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902)
+// documents to JSON values.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch reports a malformed patch document.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrTestFailed reports a JSON Patch test operation whose value did not match.
+	ErrTestFailed = errors.New("test operation failed")
+)
+
+// MergePatch applies the merge patch to doc as described in RFC 7396: members of the
+// patch replace those of doc, objects are merged recursively and null removes a member.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var p interface{}
+	if err := decode(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err.Error())
+	}
+
+	var d interface{}
+	if err := decode(doc, &d); err != nil {
+		return nil, fmt.Errorf("decode document: %s", err.Error())
+	}
+
+	return json.Marshal(merge(d, p))
+}
+
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = merge(t[k], v)
+		}
+	}
+
+	return t
+}
+
+type operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"-"`
+	// hasValue tells a null value, which Value cannot, from a missing one.
+	hasValue bool
+}
+
+func (op *operation) UnmarshalJSON(data []byte) error {
+	type fields operation
+	if err := json.Unmarshal(data, (*fields)(op)); err != nil {
+		return err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	op.Value, op.hasValue = members["value"]
+
+	return nil
+}
+
+// Apply applies the operations of the JSON Patch to doc in order. The patch is applied
+// as a whole or not at all.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var ops []operation
+	if err := decode(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: expected an array of operations: %s", ErrInvalidPatch, err.Error())
+	}
+
+	var d interface{}
+	if err := decode(doc, &d); err != nil {
+		return nil, fmt.Errorf("decode document: %s", err.Error())
+	}
+
+	for i, op := range ops {
+		var err error
+
+		d, err = op.apply(d)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
+		}
+	}
+
+	return json.Marshal(d)
+}
+
+func (op operation) apply(doc interface{}) (interface{}, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("%w: missing path", ErrInvalidPatch)
+	}
+
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if !op.hasValue {
+			return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+		}
+
+		var value interface{}
+		if err := decode(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err.Error())
+		}
+
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			// Replacing the root swaps the whole document.
+			if len(path) == 0 {
+				return value, nil
+			}
+
+			if doc, _, err = remove(doc, path); err != nil {
+				return nil, err
+			}
+
+			return add(doc, path, value)
+		}
+
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !equal(current, value) {
+			return nil, fmt.Errorf("%w: value at %q differs", ErrTestFailed, *op.Path)
+		}
+
+		return doc, nil
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: missing from", ErrInvalidPatch)
+		}
+
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+
+		if op.Op == "copy" {
+			if value, err = get(doc, from); err != nil {
+				return nil, err
+			}
+
+			value = clone(value)
+		} else {
+			if *op.Path != *op.From && strings.HasPrefix(*op.Path, *op.From+"/") {
+				return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+			}
+
+			if doc, value, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		}
+
+		return add(doc, path, value)
+	}
+
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, p)
+	}
+
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, t := range path {
+		switch n := doc.(type) {
+		case map[string]interface{}:
+			v, ok := n[t]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", t)
+			}
+
+			doc = v
+		case []interface{}:
+			i, err := index(t, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+
+			doc = n[i]
+		default:
+			return nil, fmt.Errorf("cannot reference %q in a scalar", t)
+		}
+	}
+
+	return doc, nil
+}
+
+// add sets the value at path, inserting it into arrays, and returns the new document.
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	t, rest := path[0], path[1:]
+
+	switch n := doc.(type) {
+	case map[string]interface{}:
+		if len(rest) == 0 {
+			n[t] = value
+			return n, nil
+		}
+
+		child, ok := n[t]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", t)
+		}
+
+		child, err := add(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+
+		n[t] = child
+
+		return n, nil
+	case []interface{}:
+		if len(rest) == 0 {
+			i := len(n)
+			if t != "-" {
+				var err error
+				if i, err = index(t, len(n)); err != nil {
+					return nil, err
+				}
+			}
+
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+
+			return n, nil
+		}
+
+		i, err := index(t, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		if n[i], err = add(n[i], rest, value); err != nil {
+			return nil, err
+		}
+
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("cannot reference %q in a scalar", t)
+}
+
+// remove deletes the value at path and returns the new document and the removed value.
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+
+	t, rest := path[0], path[1:]
+
+	switch n := doc.(type) {
+	case map[string]interface{}:
+		child, ok := n[t]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q not found", t)
+		}
+
+		if len(rest) == 0 {
+			delete(n, t)
+			return n, child, nil
+		}
+
+		child, removed, err := remove(child, rest)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		n[t] = child
+
+		return n, removed, nil
+	case []interface{}:
+		i, err := index(t, len(n)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(rest) == 0 {
+			removed := n[i]
+			return append(n[:i], n[i+1:]...), removed, nil
+		}
+
+		child, removed, err := remove(n[i], rest)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		n[i] = child
+
+		return n, removed, nil
+	}
+
+	return nil, nil, fmt.Errorf("cannot reference %q in a scalar", t)
+}
+
+func index(t string, max int) (int, error) {
+	i, err := strconv.Atoi(t)
+	if err != nil || i < 0 || (t != "0" && strings.HasPrefix(t, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", t)
+	}
+
+	if i > max {
+		return 0, fmt.Errorf("array index %d out of bounds", i)
+	}
+
+	return i, nil
+}
+
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		m, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(m) {
+			return false
+		}
+
+		for k, v := range a {
+			w, ok := m[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+
+		return true
+	case []interface{}:
+		s, ok := b.([]interface{})
+		if !ok || len(a) != len(s) {
+			return false
+		}
+
+		for i := range a {
+			if !equal(a[i], s[i]) {
+				return false
+			}
+		}
+
+		return true
+	case json.Number:
+		n, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+
+		x, errA := a.Float64()
+		y, errB := n.Float64()
+
+		return errA == nil && errB == nil && x == y
+	}
+
+	return a == b
+}
+
+func clone(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = clone(e)
+		}
+
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = clone(e)
+		}
+
+		return s
+	}
+
+	return v
+}
+
+// decode unmarshals data keeping numbers as json.Number so that they survive a round
+// trip unchanged.
+func decode(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	if dec.More() {
+		return errors.New("unexpected data after the JSON value")
+	}
+
+	return nil
+}
@@ -0,0 +1,346 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// sameJSON reports whether a and b encode the same JSON value.
+func sameJSON(t *testing.T, a, b []byte) bool {
+	t.Helper()
+
+	var x, y interface{}
+
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatalf("decode %s: %v", a, err)
+	}
+
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatalf("decode %s: %v", b, err)
+	}
+
+	return reflect.DeepEqual(x, y)
+}
+
+// The cases of RFC 6902, Appendix A, followed by those of the edges the package handles
+// itself. A case with fails set expects an error, matching is when it is set.
+func TestApply(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		fails bool
+		is    error
+	}{
+		{
+			name:  "A.1 adding an object member",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			want:  `{"baz": "qux", "foo": "bar"}`,
+		},
+		{
+			name:  "A.2 adding an array element",
+			doc:   `{"foo": ["bar", "baz"]}`,
+			patch: `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			want:  `{"foo": ["bar", "qux", "baz"]}`,
+		},
+		{
+			name:  "A.3 removing an object member",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "remove", "path": "/baz"}]`,
+			want:  `{"foo": "bar"}`,
+		},
+		{
+			name:  "A.4 removing an array element",
+			doc:   `{"foo": ["bar", "qux", "baz"]}`,
+			patch: `[{"op": "remove", "path": "/foo/1"}]`,
+			want:  `{"foo": ["bar", "baz"]}`,
+		},
+		{
+			name:  "A.5 replacing a value",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			want:  `{"baz": "boo", "foo": "bar"}`,
+		},
+		{
+			name:  "A.6 moving a value",
+			doc:   `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch: `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			want:  `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{
+			name:  "A.7 moving an array element",
+			doc:   `{"foo": ["all", "grass", "cows", "eat"]}`,
+			patch: `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			want:  `{"foo": ["all", "cows", "eat", "grass"]}`,
+		},
+		{
+			name:  "A.8 testing a value: success",
+			doc:   `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			want:  `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{
+			name:  "A.9 testing a value: error",
+			doc:   `{"baz": "qux"}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			fails: true,
+			is:    ErrTestFailed,
+		},
+		{
+			name:  "A.10 adding a nested member object",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			want:  `{"foo": "bar", "child": {"grandchild": {}}}`,
+		},
+		{
+			name:  "A.11 ignoring unrecognized elements",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			want:  `{"foo": "bar", "baz": "qux"}`,
+		},
+		{
+			name:  "A.12 adding to a nonexistent target",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			fails: true,
+		},
+		{
+			name:  "A.13 invalid JSON patch document",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux", "op": "remove"}]`,
+			fails: true,
+		},
+		{
+			name:  "A.14 ~ escape ordering",
+			doc:   `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": 10}]`,
+			want:  `{"/": 9, "~1": 10}`,
+		},
+		{
+			name:  "A.15 comparing strings and numbers",
+			doc:   `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": "10"}]`,
+			fails: true,
+			is:    ErrTestFailed,
+		},
+		{
+			name:  "A.16 adding an array value",
+			doc:   `{"foo": ["bar"]}`,
+			patch: `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			want:  `{"foo": ["bar", ["abc", "def"]]}`,
+		},
+		{
+			name:  "escaped slash",
+			doc:   `{"a/b": 1}`,
+			patch: `[{"op": "replace", "path": "/a~1b", "value": 2}]`,
+			want:  `{"a/b": 2}`,
+		},
+		{
+			name:  "insert at the start of an array",
+			doc:   `{"foo": [1, 2]}`,
+			patch: `[{"op": "add", "path": "/foo/0", "value": 0}]`,
+			want:  `{"foo": [0, 1, 2]}`,
+		},
+		{
+			name:  "insert at the end of an array by index",
+			doc:   `{"foo": [1, 2]}`,
+			patch: `[{"op": "add", "path": "/foo/2", "value": 3}]`,
+			want:  `{"foo": [1, 2, 3]}`,
+		},
+		{
+			name:  "insert past the end of an array",
+			doc:   `{"foo": [1, 2]}`,
+			patch: `[{"op": "add", "path": "/foo/3", "value": 3}]`,
+			fails: true,
+		},
+		{
+			name:  "array index with a leading zero",
+			doc:   `{"foo": [1, 2]}`,
+			patch: `[{"op": "remove", "path": "/foo/01"}]`,
+			fails: true,
+		},
+		{
+			name:  "remove the end of an array",
+			doc:   `{"foo": [1, 2]}`,
+			patch: `[{"op": "remove", "path": "/foo/-"}]`,
+			fails: true,
+		},
+		{
+			name:  "remove a missing member",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "remove", "path": "/baz"}]`,
+			fails: true,
+		},
+		{
+			name:  "replace a missing member",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "/baz", "value": 1}]`,
+			fails: true,
+		},
+		{
+			name:  "replace the whole document",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "", "value": [1]}]`,
+			want:  `[1]`,
+		},
+		{
+			name:  "add a null value",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": null}]`,
+			want:  `{"foo": "bar", "baz": null}`,
+		},
+		{
+			name:  "test a null value",
+			doc:   `{"foo": null}`,
+			patch: `[{"op": "test", "path": "/foo", "value": null}]`,
+			want:  `{"foo": null}`,
+		},
+		{
+			name:  "missing value",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz"}]`,
+			fails: true,
+			is:    ErrInvalidPatch,
+		},
+		{
+			name:  "missing path",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "remove"}]`,
+			fails: true,
+			is:    ErrInvalidPatch,
+		},
+		{
+			name:  "path without a leading slash",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "remove", "path": "foo"}]`,
+			fails: true,
+			is:    ErrInvalidPatch,
+		},
+		{
+			name:  "unknown operation",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "merge", "path": "/foo", "value": 1}]`,
+			fails: true,
+			is:    ErrInvalidPatch,
+		},
+		{
+			name:  "not an array of operations",
+			doc:   `{"foo": "bar"}`,
+			patch: `{"op": "remove", "path": "/foo"}`,
+			fails: true,
+			is:    ErrInvalidPatch,
+		},
+		{
+			name:  "move a value into itself",
+			doc:   `{"a": {"b": 1}}`,
+			patch: `[{"op": "move", "from": "/a", "path": "/a/c"}]`,
+			fails: true,
+			is:    ErrInvalidPatch,
+		},
+		{
+			name:  "move a value to its own path",
+			doc:   `{"a": {"b": 1}}`,
+			patch: `[{"op": "move", "from": "/a", "path": "/a"}]`,
+			want:  `{"a": {"b": 1}}`,
+		},
+		{
+			name:  "move to a sibling sharing a prefix",
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "move", "from": "/a", "path": "/ab"}]`,
+			want:  `{"ab": 1}`,
+		},
+		{
+			name:  "copy is independent of its source",
+			doc:   `{"a": {"b": 1}}`,
+			patch: `[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "replace", "path": "/c/b", "value": 2}]`,
+			want:  `{"a": {"b": 1}, "c": {"b": 2}}`,
+		},
+		{
+			name:  "test compares objects regardless of member order",
+			doc:   `{"a": {"x": 1, "y": [1, 2]}}`,
+			patch: `[{"op": "test", "path": "/a", "value": {"y": [1, 2], "x": 1.0}}]`,
+			want:  `{"a": {"x": 1, "y": [1, 2]}}`,
+		},
+		{
+			name:  "a failed operation applies none",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": 1}, {"op": "test", "path": "/foo", "value": "qux"}]`,
+			fails: true,
+			is:    ErrTestFailed,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+
+			if tt.fails {
+				if err == nil {
+					t.Fatalf("Apply = %s, want an error", got)
+				}
+
+				if tt.is != nil && !errors.Is(err, tt.is) {
+					t.Errorf("Apply error = %v, want %v", err, tt.is)
+				}
+
+				if got != nil {
+					t.Errorf("Apply returned %s with an error, want nothing", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+
+			if !sameJSON(t, got, []byte(tt.want)) {
+				t.Errorf("Apply = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// The examples of RFC 7396, Appendix A.
+func TestMergePatch(t *testing.T) {
+	for _, tt := range []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a": "b"}`, `{"a": "c"}`, `{"a": "c"}`},
+		{`{"a": "b"}`, `{"b": "c"}`, `{"a": "b", "b": "c"}`},
+		{`{"a": "b"}`, `{"a": null}`, `{}`},
+		{`{"a": "b", "b": "c"}`, `{"a": null}`, `{"b": "c"}`},
+		{`{"a": ["b"]}`, `{"a": "c"}`, `{"a": "c"}`},
+		{`{"a": "c"}`, `{"a": ["b"]}`, `{"a": ["b"]}`},
+		{`{"a": {"b": "c"}}`, `{"a": {"b": "d", "c": null}}`, `{"a": {"b": "d"}}`},
+		{`{"a": [{"b": "c"}]}`, `{"a": [1]}`, `{"a": [1]}`},
+		{`["a", "b"]`, `["c", "d"]`, `["c", "d"]`},
+		{`{"a": "b"}`, `["c"]`, `["c"]`},
+		{`{"a": "foo"}`, `null`, `null`},
+		{`{"a": "foo"}`, `"bar"`, `"bar"`},
+		{`{"e": null}`, `{"a": 1}`, `{"e": null, "a": 1}`},
+		{`[1, 2]`, `{"a": "b", "c": null}`, `{"a": "b"}`},
+		{`{}`, `{"a": {"bb": {"ccc": null}}}`, `{"a": {"bb": {}}}`},
+	} {
+		t.Run(tt.doc+" "+tt.patch, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch: %v", err)
+			}
+
+			if !sameJSON(t, got, []byte(tt.want)) {
+				t.Errorf("MergePatch = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchInvalid(t *testing.T) {
+	if _, err := MergePatch([]byte(`{"a": "b"}`), []byte(`{"a":`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("MergePatch error = %v, want %v", err, ErrInvalidPatch)
+	}
+}
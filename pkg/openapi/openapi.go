@@ -0,0 +1,297 @@
+// Package openapi validates HTTP requests and responses against the operations of a
+// Swagger 2.0 document.
+//
+// It supports the subset of the specification needed to describe a JSON API: path,
+// query and header parameters of primitive and array types, JSON bodies with schemas
+// built from type, format, enum, the numeric, length and item count bounds, pattern,
+// required, properties, items, additionalProperties and references to definitions,
+// and the x-nullable and x-oneOf extensions. Bodies of other media types, such as
+// uploaded files or event streams, are left unchecked.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-openapi/spec"
+)
+
+// ErrMalformedBody reports a request body that is not valid JSON.
+var ErrMalformedBody = errors.New("malformed request body")
+
+// Violation is a part of a request or response that does not match the document.
+// Field names the parameter, or the path of the value within a body, such as
+// operations[0].op; it is empty for the body as a whole.
+type Violation struct {
+	Field   string
+	Message string
+}
+
+type Validator struct {
+	doc *spec.Swagger
+
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+// New parses a Swagger 2.0 document in JSON.
+func New(doc []byte) (*Validator, error) {
+	var s spec.Swagger
+	if err := json.Unmarshal(doc, &s); err != nil {
+		return nil, fmt.Errorf("parse OpenAPI document: %s", err.Error())
+	}
+
+	if s.Swagger != "2.0" {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, expected 2.0", s.Swagger)
+	}
+
+	if s.Paths == nil {
+		return nil, errors.New("OpenAPI document has no paths")
+	}
+
+	return &Validator{doc: &s, patterns: map[string]*regexp.Regexp{}}, nil
+}
+
+// Operation is a documented operation of the API.
+type Operation struct {
+	v        *Validator
+	op       *spec.Operation
+	params   []spec.Parameter
+	consumes []string
+}
+
+// Operation returns the operation documented for method on the path template, relative
+// to the base path, such as /subscriptions/{id}. It returns nil for an undocumented
+// operation.
+func (v *Validator) Operation(method, path string) *Operation {
+	item, ok := v.doc.Paths.Paths[path]
+	if !ok {
+		return nil
+	}
+
+	var op *spec.Operation
+
+	switch method {
+	case http.MethodGet:
+		op = item.Get
+	case http.MethodPost:
+		op = item.Post
+	case http.MethodPut:
+		op = item.Put
+	case http.MethodPatch:
+		op = item.Patch
+	case http.MethodDelete:
+		op = item.Delete
+	case http.MethodHead:
+		op = item.Head
+	case http.MethodOptions:
+		op = item.Options
+	}
+
+	if op == nil {
+		return nil
+	}
+
+	// Operation parameters override the path-level ones of the same name and location.
+	params := slices.Clone(op.Parameters)
+	for _, p := range item.Parameters {
+		if !slices.ContainsFunc(params, func(o spec.Parameter) bool { return o.Name == p.Name && o.In == p.In }) {
+			params = append(params, p)
+		}
+	}
+
+	return &Operation{v: v, op: op, params: params, consumes: op.Consumes}
+}
+
+// Consumes reports whether the operation declares its own media types and, if so,
+// whether contentType is one of them. Operations relying on the media types of the
+// document accept any.
+func (o *Operation) Consumes(contentType string) bool {
+	if len(o.consumes) == 0 {
+		return true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	return slices.Contains(o.consumes, mediaType)
+}
+
+// MediaTypes returns the media types the operation declares it consumes.
+func (o *Operation) MediaTypes() []string {
+	return o.consumes
+}
+
+// ChecksBody reports whether the body of a request with contentType is checked
+// against the schema of the operation: it has a body parameter and the body is JSON.
+// A request without a content type is taken for JSON.
+func (o *Operation) ChecksBody(contentType string) bool {
+	if !slices.ContainsFunc(o.params, func(p spec.Parameter) bool { return p.In == "body" }) {
+		return false
+	}
+
+	return contentType == "" || isJSON(contentType)
+}
+
+// ChecksResponses reports whether the responses of the operation are checked against
+// their schemas, which is the case when it produces nothing but JSON.
+func (o *Operation) ChecksResponses() bool {
+	return !slices.ContainsFunc(o.op.Produces, func(mediaType string) bool { return !isJSON(mediaType) })
+}
+
+// ValidateRequest checks the parameters and the body of r. The values of the path
+// parameters are passed in pathParams, since matching the path is up to the router.
+// A body that is not JSON is reported as ErrMalformedBody.
+func (o *Operation) ValidateRequest(r *http.Request, pathParams map[string]string, body []byte) ([]Violation, error) {
+	var violations []Violation
+
+	query := r.URL.Query()
+
+	for _, p := range o.params {
+		var values []string
+
+		switch p.In {
+		case "path":
+			if v, ok := pathParams[p.Name]; ok {
+				values = []string{v}
+			}
+		case "query":
+			values = query[p.Name]
+		case "header":
+			values = r.Header.Values(p.Name)
+		case "body":
+			if !o.ChecksBody(r.Header.Get("Content-Type")) {
+				continue
+			}
+
+			vs, err := o.validateBody(p, body)
+			if err != nil {
+				return nil, err
+			}
+
+			violations = append(violations, vs...)
+
+			continue
+		default:
+			continue
+		}
+
+		violations = append(violations, o.v.validateParam(p, values)...)
+	}
+
+	return violations, nil
+}
+
+func (o *Operation) validateBody(p spec.Parameter, body []byte) ([]Violation, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if p.Required {
+			return []Violation{{Message: "request body is required"}}, nil
+		}
+
+		return nil, nil
+	}
+
+	if p.Schema == nil {
+		return nil, nil
+	}
+
+	value, err := decode(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMalformedBody, err.Error())
+	}
+
+	return o.v.validateSchema(p.Schema, value, ""), nil
+}
+
+// ValidateResponse checks that status is documented for the operation and that body
+// matches its schema.
+func (o *Operation) ValidateResponse(status int, body []byte) []Violation {
+	if o.op.Responses == nil {
+		return nil
+	}
+
+	resp, ok := o.op.Responses.StatusCodeResponses[status]
+	if !ok {
+		if o.op.Responses.Default == nil {
+			return []Violation{{Message: fmt.Sprintf("status %d is not documented", status)}}
+		}
+
+		resp = *o.op.Responses.Default
+	}
+
+	if resp.Schema == nil {
+		if len(bytes.TrimSpace(body)) > 0 {
+			return []Violation{{Message: fmt.Sprintf("status %d is documented without a body", status)}}
+		}
+
+		return nil
+	}
+
+	value, err := decode(body)
+	if err != nil {
+		return []Violation{{Message: "response body is not valid JSON: " + err.Error()}}
+	}
+
+	return o.v.validateSchema(resp.Schema, value, "")
+}
+
+// isJSON reports whether contentType is JSON: application/json or a type with the
+// +json suffix, such as application/merge-patch+json.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func decode(body []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	if dec.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+
+	return value, nil
+}
+
+func (v *Validator) pattern(p string) (*regexp.Regexp, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if re, ok := v.patterns[p]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	v.patterns[p] = re
+
+	return re, nil
+}
+
+// joinPath appends a property name to the path of a value within a body.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
@@ -0,0 +1,446 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-openapi/spec"
+	"github.com/google/uuid"
+)
+
+// constraints are the validations shared by parameters and schemas.
+type constraints struct {
+	typ          string
+	format       string
+	enum         []interface{}
+	minimum      *float64
+	maximum      *float64
+	exclusiveMin bool
+	exclusiveMax bool
+	minLength    *int64
+	maxLength    *int64
+	pattern      string
+	minItems     *int64
+	maxItems     *int64
+}
+
+func simpleConstraints(s spec.SimpleSchema, c spec.CommonValidations) constraints {
+	return constraints{
+		typ:          s.Type,
+		format:       s.Format,
+		enum:         c.Enum,
+		minimum:      c.Minimum,
+		maximum:      c.Maximum,
+		exclusiveMin: c.ExclusiveMinimum,
+		exclusiveMax: c.ExclusiveMaximum,
+		minLength:    c.MinLength,
+		maxLength:    c.MaxLength,
+		pattern:      c.Pattern,
+		minItems:     c.MinItems,
+		maxItems:     c.MaxItems,
+	}
+}
+
+func schemaConstraints(s *spec.Schema) constraints {
+	return constraints{
+		format:       s.Format,
+		enum:         s.Enum,
+		minimum:      s.Minimum,
+		maximum:      s.Maximum,
+		exclusiveMin: s.ExclusiveMinimum,
+		exclusiveMax: s.ExclusiveMaximum,
+		minLength:    s.MinLength,
+		maxLength:    s.MaxLength,
+		pattern:      s.Pattern,
+		minItems:     s.MinItems,
+		maxItems:     s.MaxItems,
+	}
+}
+
+// validateParam checks the raw values of a non-body parameter. An empty value counts
+// as a missing one, as it does for the handlers.
+func (v *Validator) validateParam(p spec.Parameter, values []string) []Violation {
+	if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+		if p.Required {
+			return []Violation{{Field: p.Name, Message: "is required"}}
+		}
+
+		return nil
+	}
+
+	c := simpleConstraints(p.SimpleSchema, p.CommonValidations)
+
+	if p.Type != "array" {
+		return v.validateRaw(p.Name, values[0], c)
+	}
+
+	items := splitCollection(values, p.CollectionFormat)
+
+	var violations []Violation
+
+	if c.minItems != nil && int64(len(items)) < *c.minItems {
+		violations = append(violations, Violation{Field: p.Name, Message: fmt.Sprintf("must have at least %d items", *c.minItems)})
+	}
+
+	if c.maxItems != nil && int64(len(items)) > *c.maxItems {
+		violations = append(violations, Violation{Field: p.Name, Message: fmt.Sprintf("must have at most %d items", *c.maxItems)})
+	}
+
+	if p.Items == nil {
+		return violations
+	}
+
+	ic := simpleConstraints(p.Items.SimpleSchema, p.Items.CommonValidations)
+	for _, item := range items {
+		violations = append(violations, v.validateRaw(p.Name, item, ic)...)
+	}
+
+	return violations
+}
+
+func splitCollection(values []string, format string) []string {
+	sep := ","
+
+	switch format {
+	case "multi":
+		return values
+	case "ssv":
+		sep = " "
+	case "tsv":
+		sep = "\t"
+	case "pipes":
+		sep = "|"
+	}
+
+	return strings.Split(values[0], sep)
+}
+
+// validateRaw checks a value given as text, converting it to the type of c first.
+func (v *Validator) validateRaw(field, raw string, c constraints) []Violation {
+	switch c.typ {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return []Violation{{Field: field, Message: "must be an integer"}}
+		}
+
+		return v.validateNumber(field, float64(n), raw, c)
+	case "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return []Violation{{Field: field, Message: "must be a number"}}
+		}
+
+		return v.validateNumber(field, n, raw, c)
+	case "boolean":
+		if _, err := strconv.ParseBool(raw); err != nil {
+			return []Violation{{Field: field, Message: "must be true or false"}}
+		}
+
+		return nil
+	}
+
+	return v.validateString(field, raw, c)
+}
+
+func (v *Validator) validateNumber(field string, n float64, text string, c constraints) []Violation {
+	var violations []Violation
+
+	add := func(format string, args ...interface{}) {
+		violations = append(violations, Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.minimum != nil {
+		if c.exclusiveMin && n <= *c.minimum {
+			add("must be greater than %s", formatNumber(*c.minimum))
+		} else if n < *c.minimum {
+			add("must be greater than or equal to %s", formatNumber(*c.minimum))
+		}
+	}
+
+	if c.maximum != nil {
+		if c.exclusiveMax && n >= *c.maximum {
+			add("must be less than %s", formatNumber(*c.maximum))
+		} else if n > *c.maximum {
+			add("must be less than or equal to %s", formatNumber(*c.maximum))
+		}
+	}
+
+	if len(c.enum) > 0 && !inEnum(text, c.enum) {
+		add("must be one of %s", enumList(c.enum))
+	}
+
+	return violations
+}
+
+func (v *Validator) validateString(field, s string, c constraints) []Violation {
+	var violations []Violation
+
+	add := func(format string, args ...interface{}) {
+		violations = append(violations, Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := int64(utf8.RuneCountInString(s))
+
+	if c.minLength != nil && length < *c.minLength {
+		add("must be at least %d characters", *c.minLength)
+	}
+
+	if c.maxLength != nil && length > *c.maxLength {
+		add("must be at most %d characters", *c.maxLength)
+	}
+
+	if c.pattern != "" {
+		re, err := v.pattern(c.pattern)
+		if err != nil {
+			add("has an invalid pattern in the API specification")
+		} else if !re.MatchString(s) {
+			add("must match %s", c.pattern)
+		}
+	}
+
+	if msg := checkFormat(c.format, s); msg != "" {
+		add("%s", msg)
+	}
+
+	if len(c.enum) > 0 && !inEnum(s, c.enum) {
+		add("must be one of %s", enumList(c.enum))
+	}
+
+	return violations
+}
+
+// checkFormat returns why s does not have the given format, or an empty string.
+// Unknown formats are not checked.
+func checkFormat(format, s string) string {
+	switch format {
+	case "uuid":
+		if _, err := uuid.Parse(s); err != nil {
+			return "must be a UUID"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return "must be a date in YYYY-MM-DD format"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return "must be an RFC 3339 timestamp"
+		}
+	}
+
+	return ""
+}
+
+// validateSchema checks a decoded JSON value, numbers kept as json.Number, against s.
+func (v *Validator) validateSchema(s *spec.Schema, value interface{}, path string) []Violation {
+	if ref := s.Ref.String(); ref != "" {
+		def, ok := v.doc.Definitions[strings.TrimPrefix(ref, "#/definitions/")]
+		if !ok {
+			return []Violation{{Field: path, Message: "references an undefined schema " + ref}}
+		}
+
+		return v.validateSchema(&def, value, path)
+	}
+
+	if alternatives, ok := s.Extensions["x-oneOf"]; ok {
+		return v.validateOneOf(alternatives, value, path)
+	}
+
+	if value == nil {
+		nullable, _ := s.Extensions.GetBool("x-nullable")
+		if nullable || s.Nullable || len(s.Type) == 0 {
+			return nil
+		}
+
+		return []Violation{{Field: path, Message: "must not be null"}}
+	}
+
+	kind := jsonKind(value)
+	if len(s.Type) > 0 && !s.Type.Contains(kind) && !(kind == "integer" && s.Type.Contains("number")) {
+		return []Violation{{Field: path, Message: "must be " + article(s.Type[0])}}
+	}
+
+	c := schemaConstraints(s)
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		return v.validateObject(s, val, path)
+	case []interface{}:
+		return v.validateArray(s, val, path, c)
+	case string:
+		return v.validateString(path, val, c)
+	case json.Number:
+		n, _ := val.Float64()
+		return v.validateNumber(path, n, val.String(), c)
+	}
+
+	return nil
+}
+
+// validateOneOf checks value against the schemas of the x-oneOf extension, which
+// Swagger 2.0 lacks for a value documented with alternative shapes. A value matching
+// none of them gets the violations of the first schema of its JSON type.
+func (v *Validator) validateOneOf(alternatives interface{}, value interface{}, path string) []Violation {
+	raw, err := json.Marshal(alternatives)
+	if err != nil {
+		return []Violation{{Field: path, Message: "has an invalid x-oneOf: " + err.Error()}}
+	}
+
+	var schemas []spec.Schema
+	if err := json.Unmarshal(raw, &schemas); err != nil || len(schemas) == 0 {
+		return []Violation{{Field: path, Message: "has an invalid x-oneOf, expected a list of schemas"}}
+	}
+
+	var (
+		closest []Violation
+		ofKind  bool
+	)
+
+	for i := range schemas {
+		violations := v.validateSchema(&schemas[i], value, path)
+		if len(violations) == 0 {
+			return nil
+		}
+
+		if closest == nil || !ofKind && v.hasKind(&schemas[i], value) {
+			closest = violations
+			ofKind = v.hasKind(&schemas[i], value)
+		}
+	}
+
+	return closest
+}
+
+// hasKind reports whether s admits values of the JSON type of value.
+func (v *Validator) hasKind(s *spec.Schema, value interface{}) bool {
+	if ref := s.Ref.String(); ref != "" {
+		def, ok := v.doc.Definitions[strings.TrimPrefix(ref, "#/definitions/")]
+		if !ok {
+			return false
+		}
+
+		s = &def
+	}
+
+	kind := jsonKind(value)
+
+	return len(s.Type) == 0 || s.Type.Contains(kind) || (kind == "integer" && s.Type.Contains("number"))
+}
+
+func (v *Validator) validateObject(s *spec.Schema, obj map[string]interface{}, path string) []Violation {
+	var violations []Violation
+
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			violations = append(violations, Violation{Field: joinPath(path, name), Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if prop, ok := s.Properties[name]; ok {
+			violations = append(violations, v.validateSchema(&prop, obj[name], joinPath(path, name))...)
+			continue
+		}
+
+		if s.AdditionalProperties == nil {
+			continue
+		}
+
+		if !s.AdditionalProperties.Allows {
+			violations = append(violations, Violation{Field: joinPath(path, name), Message: "is not a known field"})
+		} else if s.AdditionalProperties.Schema != nil {
+			violations = append(violations, v.validateSchema(s.AdditionalProperties.Schema, obj[name], joinPath(path, name))...)
+		}
+	}
+
+	return violations
+}
+
+func (v *Validator) validateArray(s *spec.Schema, items []interface{}, path string, c constraints) []Violation {
+	var violations []Violation
+
+	if c.minItems != nil && int64(len(items)) < *c.minItems {
+		violations = append(violations, Violation{Field: path, Message: fmt.Sprintf("must have at least %d items", *c.minItems)})
+	}
+
+	if c.maxItems != nil && int64(len(items)) > *c.maxItems {
+		violations = append(violations, Violation{Field: path, Message: fmt.Sprintf("must have at most %d items", *c.maxItems)})
+	}
+
+	if s.Items == nil || s.Items.Schema == nil {
+		return violations
+	}
+
+	for i, item := range items {
+		violations = append(violations, v.validateSchema(s.Items.Schema, item, fmt.Sprintf("%s[%d]", path, i))...)
+	}
+
+	return violations
+}
+
+func jsonKind(value interface{}) string {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+
+		return "number"
+	}
+
+	return "null"
+}
+
+func article(typ string) string {
+	switch typ {
+	case "object", "array", "integer":
+		return "an " + typ
+	case "boolean":
+		return "true or false"
+	}
+
+	return "a " + typ
+}
+
+func inEnum(s string, enum []interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func enumList(enum []interface{}) string {
+	items := make([]string, 0, len(enum))
+	for _, e := range enum {
+		items = append(items, fmt.Sprint(e))
+	}
+
+	return strings.Join(items, ", ")
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
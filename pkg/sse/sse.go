@@ -0,0 +1,68 @@
+// Package sse writes Server-Sent Events streams, as consumed by the EventSource API of
+// browsers.
+package sse
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type of an event stream.
+const ContentType = "text/event-stream"
+
+// Event is a message of a stream. ID is sent back by reconnecting clients in the
+// Last-Event-ID header; an event without one leaves the ID they send unchanged, unless
+// ClearID is set, which makes them send none. Event names the event type, "message"
+// when empty.
+type Event struct {
+	ID      string
+	ClearID bool
+	Event   string
+	Data    []byte
+}
+
+// Write writes e to w. Every line of Data becomes a data field, so that multiline data
+// reaches the client unchanged.
+func Write(w io.Writer, e Event) error {
+	var buf bytes.Buffer
+
+	if e.ID != "" || e.ClearID {
+		buf.WriteString("id: " + field(e.ID) + "\n")
+	}
+
+	if e.Event != "" {
+		buf.WriteString("event: " + field(e.Event) + "\n")
+	}
+
+	for _, line := range bytes.Split(e.Data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(bytes.TrimSuffix(line, []byte("\r")))
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Comment writes a comment line, which clients ignore. It keeps idle connections from
+// being closed by proxies.
+func Comment(w io.Writer, text string) error {
+	_, err := io.WriteString(w, ": "+field(text)+"\n\n")
+	return err
+}
+
+// Retry tells the client how long to wait before reconnecting once the stream ends.
+func Retry(w io.Writer, d time.Duration) error {
+	_, err := io.WriteString(w, "retry: "+strconv.FormatInt(d.Milliseconds(), 10)+"\n\n")
+	return err
+}
+
+// field drops the line breaks that would end a single line field early.
+func field(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
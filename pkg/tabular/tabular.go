@@ -0,0 +1,84 @@
+// Package tabular writes rows of a table as CSV or as an XLSX workbook, streaming them
+// to the underlying writer as they come.
+package tabular
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	CSVContentType  = "text/csv; charset=utf-8"
+	XLSXContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// Writer writes the rows of a table. A cell is a string or an integer; integers are
+// stored as numbers where the format has them. In CSV, strings that a spreadsheet would
+// take for a formula are written with a leading quote; XLSX stores them as text cells,
+// which are never evaluated, so they are kept as they are. Close must be called to
+// complete the output.
+type Writer interface {
+	WriteRow(cells []interface{}) error
+	Close() error
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func NewCSV(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (cw *csvWriter) WriteRow(cells []interface{}) error {
+	cw.record = cw.record[:0]
+
+	for _, c := range cells {
+		if s, ok := c.(string); ok {
+			cw.record = append(cw.record, neutralize(s))
+			continue
+		}
+
+		cw.record = append(cw.record, text(c))
+	}
+
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func text(cell interface{}) string {
+	switch v := cell.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case nil:
+		return ""
+	}
+
+	return fmt.Sprint(cell)
+}
+
+// formulaPrefixes are the first characters that make a spreadsheet evaluate a cell as a
+// formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// neutralize prefixes s with a quote when it would be evaluated as a formula, so that
+// a value such as =HYPERLINK(...) is shown as text when the file is opened.
+func neutralize(s string) string {
+	if s != "" && strings.IndexByte(formulaPrefixes, s[0]) >= 0 {
+		return "'" + s
+	}
+
+	return s
+}
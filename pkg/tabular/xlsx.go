@@ -0,0 +1,144 @@
+package tabular
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// xlsxWriter writes a workbook with a single sheet. The fixed parts of the package are
+// written up front so that the sheet, the last entry of the zip archive, can be
+// streamed row by row. Strings are stored inline rather than in a shared string
+// table, which would have to be written after all the rows are known.
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+// NewXLSX starts a workbook with one sheet of the given name. The name must be a valid
+// sheet name: at most 31 characters, none of them []:*?/\.
+func NewXLSX(w io.Writer, sheet string) (Writer, error) {
+	zw := zip.NewWriter(w)
+
+	var name bytes.Buffer
+	if err := xml.EscapeText(&name, []byte(sheet)); err != nil {
+		return nil, err
+	}
+
+	parts := []struct{ path, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, name.String())},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+
+	for _, p := range parts {
+		f, err := zw.Create(p.path)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	xw := &xlsxWriter{zw: zw, sheet: bufio.NewWriter(f)}
+	if _, err := xw.sheet.WriteString(xlsxSheetStart); err != nil {
+		return nil, err
+	}
+
+	return xw, nil
+}
+
+func (xw *xlsxWriter) WriteRow(cells []interface{}) error {
+	xw.row++
+	r := strconv.Itoa(xw.row)
+
+	xw.sheet.WriteString(`<row r="` + r + `">`)
+
+	for i, c := range cells {
+		ref := column(i) + r
+
+		switch v := c.(type) {
+		case nil:
+			continue
+		case int, int64:
+			xw.sheet.WriteString(`<c r="` + ref + `"><v>` + text(v) + `</v></c>`)
+		default:
+			xw.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+
+			if err := xml.EscapeText(xw.sheet, []byte(text(v))); err != nil {
+				return err
+			}
+
+			xw.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+
+	_, err := xw.sheet.WriteString(`</row>`)
+
+	return err
+}
+
+func (xw *xlsxWriter) Close() error {
+	if _, err := xw.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return err
+	}
+
+	if err := xw.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return xw.zw.Close()
+}
+
+// column returns the letters of the zero-based column i: A to Z, then AA and so on.
+func column(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+
+	return name
+}
@@ -0,0 +1,100 @@
+// Package textsearch prepares fuzzy search terms across the Cyrillic and Latin
+// alphabets and finds the fragment of a text matching a search term, the way the
+// pg_trgm extension of PostgreSQL compares them.
+package textsearch
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+// latinToCyrillic lists the Latin letter combinations longest first, so that they
+// are matched greedily.
+var latinToCyrillic = []struct {
+	latin    string
+	cyrillic string
+}{
+	{"shch", "щ"}, {"sch", "щ"},
+	{"zh", "ж"}, {"kh", "х"}, {"ts", "ц"}, {"ch", "ч"}, {"sh", "ш"}, {"yu", "ю"}, {"ya", "я"}, {"yo", "ё"},
+	{"a", "а"}, {"b", "б"}, {"c", "к"}, {"d", "д"}, {"e", "е"}, {"f", "ф"}, {"g", "г"}, {"h", "х"},
+	{"i", "и"}, {"j", "дж"}, {"k", "к"}, {"l", "л"}, {"m", "м"}, {"n", "н"}, {"o", "о"}, {"p", "п"},
+	{"q", "к"}, {"r", "р"}, {"s", "с"}, {"t", "т"}, {"u", "у"}, {"v", "в"}, {"w", "в"}, {"x", "кс"},
+	{"y", "ы"}, {"z", "з"},
+}
+
+// ToLatin transliterates the Cyrillic letters of s to Latin and lowercases it.
+func ToLatin(s string) string {
+	var b strings.Builder
+
+	for _, r := range strings.ToLower(s) {
+		if latin, ok := cyrillicToLatin[r]; ok {
+			b.WriteString(latin)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// ToCyrillic transliterates the Latin letters of s to Cyrillic and lowercases it.
+func ToCyrillic(s string) string {
+	s = strings.ToLower(s)
+
+	var b strings.Builder
+
+	for len(s) > 0 {
+		matched := false
+
+		for _, m := range latinToCyrillic {
+			if strings.HasPrefix(s, m.latin) {
+				b.WriteString(m.cyrillic)
+				s = s[len(m.latin):]
+				matched = true
+
+				break
+			}
+		}
+
+		if !matched {
+			r := []rune(s)[0]
+			b.WriteRune(r)
+			s = s[len(string(r)):]
+		}
+	}
+
+	return b.String()
+}
+
+// Variants returns the forms of a search term to match against: the term itself and
+// its transliterations, without duplicates.
+func Variants(term string) []string {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return nil
+	}
+
+	variants := []string{strings.ToLower(term)}
+
+	for _, v := range []string{ToLatin(term), ToCyrillic(term)} {
+		if !slices.Contains(variants, v) {
+			variants = append(variants, v)
+		}
+	}
+
+	return variants
+}
+
+// isWordRune reports whether r is part of a word, as pg_trgm considers it.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
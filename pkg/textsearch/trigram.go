@@ -0,0 +1,78 @@
+package textsearch
+
+import "strings"
+
+// Similarity is the trigram similarity of a and b, from 0 to 1, compared in the Latin
+// alphabet. Like pg_trgm, it splits the texts into words, pads each word with two
+// spaces in front and one behind, and divides the number of shared trigrams by the
+// number of distinct trigrams of both.
+func Similarity(a, b string) float64 {
+	ta, tb := trigrams(ToLatin(a)), trigrams(ToLatin(b))
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool { return !isWordRune(r) }) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+
+	return set
+}
+
+// Highlight returns the byte offsets of the run of words of text most similar to term,
+// or -1, -1 if no word shares a trigram with it.
+func Highlight(text, term string) (start, end int) {
+	type word struct{ start, end int }
+
+	var words []word
+
+	begin := -1
+	for i, r := range text {
+		switch {
+		case isWordRune(r) && begin < 0:
+			begin = i
+		case !isWordRune(r) && begin >= 0:
+			words = append(words, word{begin, i})
+			begin = -1
+		}
+	}
+
+	if begin >= 0 {
+		words = append(words, word{begin, len(text)})
+	}
+
+	// A run needs at most as many words as the term has, plus one for a term
+	// straddling two words of the text.
+	maxRun := len(strings.Fields(term)) + 1
+
+	best := 0.0
+	start, end = -1, -1
+
+	for i := range words {
+		for j := i; j < len(words) && j < i+maxRun; j++ {
+			score := Similarity(text[words[i].start:words[j].end], term)
+			if score > best {
+				best = score
+				start, end = words[i].start, words[j].end
+			}
+		}
+	}
+
+	return start, end
+}
@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"type":"subscription.created"}`)
+	sent := time.Unix(1750000000, 0)
+	signature := Sign("secret", sent, body)
+
+	for _, tt := range []struct {
+		name      string
+		secret    string
+		signature string
+		body      []byte
+		tolerance time.Duration
+		now       time.Time
+		want      error
+	}{
+		{"valid", "secret", signature, body, 5 * time.Minute, sent.Add(time.Minute), nil},
+		{"any age without tolerance", "secret", signature, body, 0, sent.Add(24 * time.Hour), nil},
+		{"other secret", "other", signature, body, 5 * time.Minute, sent, ErrInvalidSignature},
+		{"changed body", "secret", signature, []byte(`{"type":"subscription.deleted"}`), 5 * time.Minute, sent, ErrInvalidSignature},
+		{"changed timestamp", "secret", "t=1750000001" + signature[len("t=1750000000"):], body, 5 * time.Minute, sent, ErrInvalidSignature},
+		{"malformed", "secret", "v1", body, 5 * time.Minute, sent, ErrInvalidSignature},
+		{"missing", "secret", "", body, 5 * time.Minute, sent, ErrInvalidSignature},
+		{"expired", "secret", signature, body, 5 * time.Minute, sent.Add(10 * time.Minute), ErrSignatureExpired},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(tt.secret, tt.signature, tt.body, tt.tolerance, tt.now); err != tt.want {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}